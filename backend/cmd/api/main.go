@@ -1,36 +1,56 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
-	"os"
+	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"yoga-studio-app/internal/api"
 	"yoga-studio-app/internal/auth"
+	"yoga-studio-app/internal/config"
 	"yoga-studio-app/internal/database"
+	"yoga-studio-app/internal/mailer"
+	"yoga-studio-app/internal/metrics"
 	"yoga-studio-app/internal/middleware"
+	"yoga-studio-app/internal/receipts"
+	"yoga-studio-app/internal/telemetry"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+	// Load and validate configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	// Initialize tracing before anything that makes outbound calls
+	shutdownTracing, err := telemetry.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	// Initialize OAuth providers
-	auth.InitOAuth()
+	auth.InitOAuth(cfg)
 
 	// Initialize database with retry logic
 	var db *gorm.DB
-	var err error
-	maxRetries := 3
+	maxRetries := cfg.Database.ConnectRetries
 	for i := 0; i < maxRetries; i++ {
-		db, err = database.Connect()
+		db, err = database.Connect(cfg.Database)
 		if err == nil {
 			break
 		}
@@ -43,48 +63,99 @@ func main() {
 		log.Fatal("Failed to connect to database after retries:", err)
 	}
 
+	// Expose connection pool stats to Prometheus
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDBStats(sqlDB)
+	}
+
 	// Run migrations
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(db, cfg.DefaultStudio); err != nil {
 		log.Printf("Migration error (non-fatal): %v", err)
 	}
 
 	// Initialize Gin router
 	router := gin.New() // Use gin.New() instead of gin.Default()
 
+	// Only believe X-Forwarded-For from our own proxies, so client IPs
+	// (recorded on form signatures) can't be spoofed
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
+	}
+
 	// Add custom recovery middleware
 	router.Use(middleware.RecoveryMiddleware())
+
+	// Start a span per request, continuing any incoming W3C trace context
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
 	
 	// Add logger middleware
 	router.Use(gin.Logger())
 
+	// Add request metrics middleware
+	router.Use(middleware.MetricsMiddleware())
+
 	// CORS configuration
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{getEnv("FRONTEND_URL", "http://localhost:5173")},
+		AllowOrigins:     cfg.CORSAllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.StudioHeader},
 		AllowCredentials: true,
 	}))
 
+	// Render errors reported by handlers as the shared JSON envelope
+	router.Use(middleware.ErrorMiddleware())
+
 	// Serve static files (uploaded avatars)
 	router.Static("/uploads", "./uploads")
 
 	// Initialize API routes
-	api.RegisterRoutes(router, db)
+	api.RegisterRoutes(router, db, cfg)
 
 	// Start server
-	port := getEnv("PORT", "8080")
-	log.Printf("Server starting on port %s", port)
-	log.Printf("Frontend URL: %s", getEnv("FRONTEND_URL", "http://localhost:5173"))
-	log.Printf("Environment: %s", getEnv("ENV", "development"))
-	
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	log.Printf("Server starting on port %s", cfg.Port)
+	log.Printf("Frontend URL: %s", cfg.FrontendURL)
+	log.Printf("Environment: %s", cfg.Env)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
-}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM, then stop accepting connections and drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Email receipts in the background
+	if cfg.Mail.Enabled() {
+		go receipts.Deliver(ctx, db, mailer.New(cfg.Mail), time.Minute)
+	} else {
+		log.Println("SMTP_HOST not set, receipts will not be emailed")
 	}
-	return defaultValue
+
+	<-ctx.Done()
+	stop()
+
+	drainTimeout := cfg.ShutdownTimeout
+	log.Printf("Shutdown signal received, draining connections (timeout %s)", drainTimeout)
+	api.MarkDraining()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown did not complete: %v", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	log.Println("Server stopped")
 }
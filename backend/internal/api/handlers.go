@@ -1,31 +1,60 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"yoga-studio-app/internal/apperr"
 	"yoga-studio-app/internal/auth"
+	"yoga-studio-app/internal/calendar"
+	"yoga-studio-app/internal/database"
+	"yoga-studio-app/internal/metrics"
+	"yoga-studio-app/internal/middleware"
 	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/pricing"
+	"yoga-studio-app/internal/receipts"
+	"yoga-studio-app/internal/scheduling"
+	"yoga-studio-app/internal/tenancy"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/markbates/goth/gothic"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// requestDB scopes queries to the request context so they join the request's trace
+func requestDB(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return db.WithContext(c.Request.Context())
+}
+
 // ============ Auth Handler ============
 type AuthHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	tokens      *auth.TokenManager
+	frontendURL string
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *gorm.DB, tokens *auth.TokenManager, frontendURL string) *AuthHandler {
+	return &AuthHandler{db: db, tokens: tokens, frontendURL: frontendURL}
 }
 
 func (h *AuthHandler) GoogleLogin(c *gin.Context) {
+	rememberStudio(c)
+
 	q := c.Request.URL.Query()
 	q.Add("provider", "google")
 	c.Request.URL.RawQuery = q.Encode()
@@ -41,7 +70,8 @@ func (h *AuthHandler) GoogleCallback(c *gin.Context) {
 	gothUser, err := gothic.CompleteUserAuth(c.Writer, c.Request)
 	if err != nil {
 		fmt.Printf("Google OAuth error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate with Google", "details": err.Error()})
+		metrics.OAuthLoginFailures.WithLabelValues("google").Inc()
+		c.Error(apperr.Internal("Failed to authenticate with Google", err))
 		return
 	}
 
@@ -49,6 +79,8 @@ func (h *AuthHandler) GoogleCallback(c *gin.Context) {
 }
 
 func (h *AuthHandler) FacebookLogin(c *gin.Context) {
+	rememberStudio(c)
+
 	q := c.Request.URL.Query()
 	q.Add("provider", "facebook")
 	c.Request.URL.RawQuery = q.Encode()
@@ -63,18 +95,60 @@ func (h *AuthHandler) FacebookCallback(c *gin.Context) {
 
 	gothUser, err := gothic.CompleteUserAuth(c.Writer, c.Request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate with Facebook"})
+		metrics.OAuthLoginFailures.WithLabelValues("facebook").Inc()
+		c.Error(apperr.Internal("Failed to authenticate with Facebook", err))
 		return
 	}
 
 	h.handleOAuthCallback(c, gothUser.Email, gothUser.Name, gothUser.AvatarURL, "facebook", gothUser.UserID)
 }
 
+// oauthStudioCookie carries the studio a login started from across the
+// provider redirect, since callbacks arrive on the shared backend host
+const oauthStudioCookie = "oauth_studio"
+
+// rememberStudio records the studio a login starts from
+func rememberStudio(c *gin.Context) {
+	if studio := middleware.CurrentStudio(c); studio != nil {
+		c.SetCookie(oauthStudioCookie, studio.Slug, 600, "/", "", c.Request.TLS != nil, true)
+	}
+}
+
+// restoreStudio rescopes an OAuth callback to the studio its login started from
+func restoreStudio(c *gin.Context, db *gorm.DB) (*models.Studio, error) {
+	slug, err := c.Cookie(oauthStudioCookie)
+	if err != nil || slug == "" {
+		// No cookie: the login started on the studio resolved for this request
+		if studio := middleware.CurrentStudio(c); studio != nil {
+			return studio, nil
+		}
+		return nil, apperr.BadRequest("Login session expired, please sign in again")
+	}
+	c.SetCookie(oauthStudioCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	studio, err := tenancy.Find(requestDB(c, db), slug, "")
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Studio not found")
+		}
+		return nil, apperr.Internal("Failed to resolve studio", err)
+	}
+	c.Request = c.Request.WithContext(tenancy.WithStudio(c.Request.Context(), studio.ID))
+	return studio, nil
+}
+
 func (h *AuthHandler) handleOAuthCallback(c *gin.Context, email, name, avatarURL, provider, providerID string) {
+	studio, err := restoreStudio(c, h.db)
+	if err != nil {
+		metrics.OAuthLoginFailures.WithLabelValues(provider).Inc()
+		c.Error(err)
+		return
+	}
+
 	var user models.User
 
 	// Check if user exists
-	result := h.db.Where("email = ? AND auth_provider = ?", email, provider).First(&user)
+	result := requestDB(c, h.db).Where("email = ? AND auth_provider = ?", email, provider).First(&user)
 
 	if result.Error == gorm.ErrRecordNotFound {
 		// Create new user
@@ -87,28 +161,30 @@ func (h *AuthHandler) handleOAuthCallback(c *gin.Context, email, name, avatarURL
 			AuthProviderID: providerID,
 		}
 
-		if err := h.db.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		if err := requestDB(c, h.db).Create(&user).Error; err != nil {
+			metrics.OAuthLoginFailures.WithLabelValues(provider).Inc()
+			c.Error(apperr.Internal("Failed to create user", err))
 			return
 		}
 	} else if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		metrics.OAuthLoginFailures.WithLabelValues(provider).Inc()
+		c.Error(apperr.Internal("Database error", result.Error))
 		return
 	}
 
 	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email, string(user.Role))
+	token, err := h.tokens.GenerateToken(user.ID, studio.ID, user.Email, string(user.Role))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		metrics.OAuthLoginFailures.WithLabelValues(provider).Inc()
+		c.Error(apperr.Internal("Failed to generate token", err))
 		return
 	}
 
-	// Redirect to frontend with token
-	frontendURL := os.Getenv("FRONTEND_URL")
-	if frontendURL == "" {
-		frontendURL = "http://localhost:5173"
+	// Redirect to the studio's frontend with token
+	frontendURL := h.frontendURL
+	if studio.FrontendURL != "" {
+		frontendURL = studio.FrontendURL
 	}
-
 	c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%s/auth/callback?token=%s", frontendURL, token))
 }
 
@@ -117,6 +193,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 }
 
 // ============ Class Handler ============
+var classSortKeys = sortKeys{
+	"created_at": "created_at",
+	"title":      "title",
+	"duration":   "duration",
+	"capacity":   "capacity",
+}
+
 type ClassHandler struct {
 	db *gorm.DB
 }
@@ -130,20 +213,37 @@ func (h *ClassHandler) GetAll(c *gin.Context) {
 
 	if h.db == nil {
 		log.Println("ERROR: Database connection is nil in GetAll")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection error"})
+		c.Error(apperr.Internal("Database connection error", nil))
+		return
+	}
+
+	params, err := parseListParams(c, classSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	query := h.db.Where("is_active = ?", true)
+	query := requestDB(c, h.db).Model(&models.Class{}).Where("is_active = ?", true)
 
 	// Optional filters
 	if difficulty := c.Query("difficulty"); difficulty != "" {
 		query = query.Where("difficulty_level = ?", difficulty)
 	}
+	if instructor := c.Query("instructor"); instructor != "" {
+		query = query.Where("instructor_name ILIKE ?", likePattern(instructor))
+	}
+	if instructorID := c.Query("instructor_id"); instructorID != "" {
+		if _, err := uuid.Parse(instructorID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "instructor_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("instructor_id = ?", instructorID)
+	}
 
-	if err := query.Order("created_at DESC").Find(&classes).Error; err != nil {
+	total, err := paginate(query, params, &classes)
+	if err != nil {
 		log.Printf("ERROR: Failed to fetch classes: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch classes"})
+		c.Error(apperr.Internal("Failed to fetch classes", err))
 		return
 	}
 
@@ -152,7 +252,7 @@ func (h *ClassHandler) GetAll(c *gin.Context) {
 		classes = []models.Class{}
 	}
 
-	c.JSON(http.StatusOK, classes)
+	c.JSON(http.StatusOK, newListResponse(classes, total, params))
 }
 
 func (h *ClassHandler) GetByID(c *gin.Context) {
@@ -161,18 +261,18 @@ func (h *ClassHandler) GetByID(c *gin.Context) {
 	// Validate UUID
 	if _, err := uuid.Parse(id); err != nil {
 		log.Printf("ERROR: Invalid UUID format for class ID: %s", id)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID format"})
+		c.Error(apperr.BadRequest("Invalid class ID format"))
 		return
 	}
 
 	var class models.Class
-	if err := h.db.First(&class, "id = ?", id).Error; err != nil {
+	if err := requestDB(c, h.db).First(&class, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.Printf("WARN: Class not found with ID: %s", id)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+			c.Error(apperr.NotFound("Class not found"))
 		} else {
 			log.Printf("ERROR: Database error fetching class: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class"})
+			c.Error(apperr.Internal("Failed to fetch class", err))
 		}
 		return
 	}
@@ -181,32 +281,31 @@ func (h *ClassHandler) GetByID(c *gin.Context) {
 }
 
 func (h *ClassHandler) Create(c *gin.Context) {
-	var input models.Class
+	var input ClassRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		log.Printf("ERROR: Invalid JSON for class creation: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		c.Error(apperr.FromBind(err))
 		return
 	}
 
-	// Validate required fields
-	if input.Title == "" || input.InstructorName == "" {
-		log.Println("ERROR: Missing required fields for class creation")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and instructor name are required"})
+	instructor, err := loadInstructor(c, h.db, input.InstructorID, "instructor_id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Set default values
-	input.IsActive = true
+	class := models.Class{IsActive: true}
+	input.Apply(&class, instructor)
 
-	if err := h.db.Create(&input).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&class).Error; err != nil {
 		log.Printf("ERROR: Failed to create class: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create class"})
+		c.Error(apperr.Internal("Failed to create class", err))
 		return
 	}
 
-	log.Printf("INFO: Class created successfully: %s (ID: %s)", input.Title, input.ID)
-	c.JSON(http.StatusCreated, input)
+	log.Printf("INFO: Class created successfully: %s (ID: %s)", class.Title, class.ID)
+	c.JSON(http.StatusCreated, class)
 }
 
 func (h *ClassHandler) Update(c *gin.Context) {
@@ -215,32 +314,39 @@ func (h *ClassHandler) Update(c *gin.Context) {
 	// Validate UUID
 	if _, err := uuid.Parse(id); err != nil {
 		log.Printf("ERROR: Invalid UUID format for class update: %s", id)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID format"})
+		c.Error(apperr.BadRequest("Invalid class ID format"))
 		return
 	}
 
 	var class models.Class
-	if err := h.db.First(&class, "id = ?", id).Error; err != nil {
+	if err := requestDB(c, h.db).First(&class, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.Printf("WARN: Class not found for update: %s", id)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+			c.Error(apperr.NotFound("Class not found"))
 		} else {
 			log.Printf("ERROR: Database error in class update: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch class"})
+			c.Error(apperr.Internal("Failed to fetch class", err))
 		}
 		return
 	}
 
-	var input models.Class
+	var input ClassRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		log.Printf("ERROR: Invalid JSON for class update: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	instructor, err := loadInstructor(c, h.db, input.InstructorID, "instructor_id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := h.db.Model(&class).Updates(input).Error; err != nil {
+	input.Apply(&class, instructor)
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(&class).Error; err != nil {
 		log.Printf("ERROR: Failed to update class: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update class"})
+		c.Error(apperr.Internal("Failed to update class", err))
 		return
 	}
 
@@ -254,21 +360,21 @@ func (h *ClassHandler) Delete(c *gin.Context) {
 	// Validate UUID
 	if _, err := uuid.Parse(id); err != nil {
 		log.Printf("ERROR: Invalid UUID format for class delete: %s", id)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID format"})
+		c.Error(apperr.BadRequest("Invalid class ID format"))
 		return
 	}
 
 	// Soft delete by setting is_active to false
-	result := h.db.Model(&models.Class{}).Where("id = ?", id).Update("is_active", false)
+	result := requestDB(c, h.db).Model(&models.Class{}).Where("id = ?", id).Update("is_active", false)
 	if result.Error != nil {
 		log.Printf("ERROR: Failed to delete class: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete class"})
+		c.Error(apperr.Internal("Failed to delete class", result.Error))
 		return
 	}
 
 	if result.RowsAffected == 0 {
 		log.Printf("WARN: Class not found for deletion: %s", id)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		c.Error(apperr.NotFound("Class not found"))
 		return
 	}
 
@@ -277,6 +383,12 @@ func (h *ClassHandler) Delete(c *gin.Context) {
 }
 
 // ============ Schedule Handler ============
+var scheduleSortKeys = sortKeys{
+	"start_time": "schedules.start_time",
+	"created_at": "schedules.created_at",
+	"class":      "classes.title",
+}
+
 type ScheduleHandler struct {
 	db *gorm.DB
 }
@@ -290,19 +402,33 @@ func (h *ScheduleHandler) GetAll(c *gin.Context) {
 
 	if h.db == nil {
 		log.Println("ERROR: Database connection is nil in Schedule GetAll")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection error"})
+		c.Error(apperr.Internal("Database connection error", nil))
+		return
+	}
+
+	params, err := parseListParams(c, scheduleSortKeys, "start_time")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Preload Class with condition to only load active classes
-	query := h.db.Preload("Class", "is_active = ?", true).Preload("Enrollments")
+	// Only schedules of active classes are listed; the join keeps counts and pages consistent.
+	// Private sessions are only shown to those taking part.
+	query := requestDB(c, h.db).Model(&models.Schedule{}).
+		Joins("JOIN classes ON classes.id = schedules.class_id AND classes.is_active = ?", true).
+		Where("schedules.private_capacity = 0")
 
 	// Filter by date range if provided
-	if startDate := c.Query("start_date"); startDate != "" {
-		query = query.Where("start_time >= ?", startDate)
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if startDate != nil {
+		query = query.Where("schedules.start_time >= ?", *startDate)
 	}
-	if endDate := c.Query("end_date"); endDate != "" {
-		query = query.Where("start_time <= ?", endDate)
+	if endDate != nil {
+		query = query.Where("schedules.start_time < ?", *endDate)
 	}
 
 	// Filter by class if provided
@@ -310,38 +436,64 @@ func (h *ScheduleHandler) GetAll(c *gin.Context) {
 		// Validate UUID
 		if _, err := uuid.Parse(classID); err != nil {
 			log.Printf("ERROR: Invalid UUID format for class_id filter: %s", classID)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID format"})
+			c.Error(apperr.BadRequest("Invalid class ID format"))
 			return
 		}
-		query = query.Where("class_id = ?", classID)
+		query = query.Where("schedules.class_id = ?", classID)
 	}
 
-	if err := query.Order("start_time ASC").Find(&schedules).Error; err != nil {
+	// Filter by instructor, honouring per-schedule overrides of the class instructor
+	if instructorID := c.Query("instructor_id"); instructorID != "" {
+		if _, err := uuid.Parse(instructorID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "instructor_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("schedules.instructor_id = ? OR (schedules.instructor_id IS NULL AND classes.instructor_id = ?)", instructorID, instructorID)
+	}
+
+	// Filter by where the class is held
+	if locationID := c.Query("location_id"); locationID != "" {
+		if _, err := uuid.Parse(locationID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "location_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("schedules.room_id IN (?)", requestDB(c, h.db).Model(&models.Room{}).Select("id").Where("location_id = ?", locationID))
+	}
+	if roomID := c.Query("room_id"); roomID != "" {
+		if _, err := uuid.Parse(roomID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "room_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("schedules.room_id = ?", roomID)
+	}
+
+	total, err := paginate(query, params, &schedules,
+		preload("Class"), preload("Enrollments", activeEnrollments), preload("Instructor", instructorSummary),
+		preload("Substitutions", approvedSubstitutions), preload("Substitutions.Substitute", instructorSummary),
+		preload("Cancellations"), preload("Room.Location"))
+	if err != nil {
 		log.Printf("ERROR: Failed to fetch schedules: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules"})
+		c.Error(apperr.Internal("Failed to fetch schedules", err))
 		return
 	}
 
-	// Filter out schedules with deleted/inactive classes
-	activeSchedules := []models.Schedule{}
-	for _, schedule := range schedules {
-		// Check if Class was loaded (ID is not nil)
-		if schedule.Class.ID != uuid.Nil && schedule.Class.IsActive {
-			activeSchedules = append(activeSchedules, schedule)
-		} else {
-			log.Printf("WARN: Skipping schedule %s - associated class is inactive or not found", schedule.ID)
-		}
+	if schedules == nil {
+		schedules = []models.Schedule{}
 	}
 
-	c.JSON(http.StatusOK, activeSchedules)
+	c.JSON(http.StatusOK, newListResponse(schedules, total, params))
 }
 
 func (h *ScheduleHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 
+	// Private sessions are shown to those taking part through GET /private-sessions/:id
 	var schedule models.Schedule
-	if err := h.db.Preload("Class").Preload("Enrollments").First(&schedule, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	if err := requestDB(c, h.db).Preload("Class").Preload("Enrollments", activeEnrollments).Preload("Instructor", instructorSummary).
+		Preload("Substitutions", approvedSubstitutions).Preload("Substitutions.Substitute", instructorSummary).
+		Preload("Cancellations").Preload("Room.Location").
+		First(&schedule, "id = ? AND private_capacity = 0", id).Error; err != nil {
+		c.Error(apperr.NotFound("Schedule not found"))
 		return
 	}
 
@@ -353,27 +505,25 @@ func (h *ScheduleHandler) Create(c *gin.Context) {
 
 	if userID == "" {
 		log.Println("ERROR: user_id not found in context for schedule creation")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("Unauthorized"))
 		return
 	}
 
-	var input models.Schedule
+	var input ScheduleRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		log.Printf("ERROR: Invalid JSON for schedule creation: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Validate class exists
-	var class models.Class
-	if err := h.db.First(&class, "id = ?", input.ClassID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("WARN: Class not found for schedule: %s", input.ClassID)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Class not found"})
-		} else {
-			log.Printf("ERROR: Database error checking class: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate class"})
-		}
+	// Validate class exists and is bookable
+	class, err := h.loadActiveClass(c, input.ClassID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -381,635 +531,7673 @@ func (h *ScheduleHandler) Create(c *gin.Context) {
 	parsedUserID, err := uuid.Parse(userID)
 	if err != nil {
 		log.Printf("ERROR: Invalid user_id format: %s", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		c.Error(apperr.Internal("Invalid user ID", err))
+		return
+	}
+
+	instructor, err := loadInstructor(c, h.db, input.InstructorID, "instructor_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	room, err := loadRoom(c, h.db, input.RoomID, "room_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := checkCourse(c, h.db, input.CourseID); err != nil {
+		c.Error(err)
 		return
 	}
-	input.CreatedBy = parsedUserID
 
-	if err := h.db.Create(&input).Error; err != nil {
+	schedule := models.Schedule{CreatedBy: parsedUserID}
+	input.Apply(&schedule, scheduleTimezone(c, room))
+
+	if !input.OverrideConflicts {
+		if err := h.checkConflicts(c, schedule, class, instructor); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	if err := requestDB(c, h.db).Omit(clause.Associations).Create(&schedule).Error; err != nil {
 		log.Printf("ERROR: Failed to create schedule: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule"})
+		c.Error(apperr.Internal("Failed to create schedule", err))
 		return
 	}
 
-	// Load the class information
-	h.db.Preload("Class").First(&input, input.ID)
+	// Load the class, instructor and room information
+	requestDB(c, h.db).Preload("Class").Preload("Instructor", instructorSummary).Preload("Room.Location").First(&schedule, "id = ?", schedule.ID)
 
-	log.Printf("INFO: Schedule created successfully for class: %s", input.ClassID)
-	c.JSON(http.StatusCreated, input)
+	log.Printf("INFO: Schedule created successfully for class: %s", schedule.ClassID)
+	c.JSON(http.StatusCreated, schedule)
 }
 
 func (h *ScheduleHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 
 	var schedule models.Schedule
-	if err := h.db.First(&schedule, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	if err := requestDB(c, h.db).First(&schedule, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Schedule not found"))
 		return
 	}
 
-	var input models.Schedule
+	var input ScheduleRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	class, err := h.loadActiveClass(c, input.ClassID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	instructor, err := loadInstructor(c, h.db, input.InstructorID, "instructor_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	room, err := loadRoom(c, h.db, input.RoomID, "room_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := checkCourse(c, h.db, input.CourseID); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := h.db.Model(&schedule).Updates(input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+	// Students booked onto the class individually would lose their places
+	if input.CourseID != nil && schedule.CourseID == nil {
+		var booked int64
+		if err := requestDB(c, h.db).Model(&models.Enrollment{}).
+			Where("schedule_id = ? AND status IN ?", schedule.ID, []models.EnrollmentStatus{models.EnrollmentActive, models.EnrollmentWaitlisted}).
+			Count(&booked).Error; err != nil {
+			c.Error(apperr.Internal("Failed to update schedule", err))
+			return
+		}
+		if booked > 0 {
+			c.Error(apperr.Conflict("Schedule has bookings and can't become a course session"))
+			return
+		}
+	}
+
+	input.Apply(&schedule, scheduleTimezone(c, room))
+
+	if !input.OverrideConflicts {
+		if err := h.checkConflicts(c, schedule, class, instructor); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(&schedule).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update schedule", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, schedule)
 }
 
-func (h *ScheduleHandler) Delete(c *gin.Context) {
-	id := c.Param("id")
+// loadActiveClass loads a schedule's class, failing validation if it doesn't exist or is inactive
+func (h *ScheduleHandler) loadActiveClass(c *gin.Context, classID uuid.UUID) (*models.Class, error) {
+	var class models.Class
+	if err := requestDB(c, h.db).First(&class, "id = ?", classID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("WARN: Class not found for schedule: %s", classID)
+			return nil, apperr.Validation(apperr.FieldError{Field: "class_id", Message: "does not exist"})
+		}
+		log.Printf("ERROR: Database error checking class: %v", err)
+		return nil, apperr.Internal("Failed to validate class", err)
+	}
 
-	if err := h.db.Delete(&models.Schedule{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
-		return
+	if !class.IsActive {
+		return nil, apperr.Validation(apperr.FieldError{Field: "class_id", Message: "refers to an inactive class"})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+	return &class, nil
 }
 
-// ============ Enrollment Handler ============
-type EnrollmentHandler struct {
-	db *gorm.DB
+// checkCourse validates the course a schedule's sessions belong to
+func checkCourse(c *gin.Context, db *gorm.DB, courseID *uuid.UUID) error {
+	if courseID == nil {
+		return nil
+	}
+	var count int64
+	if err := requestDB(c, db).Model(&models.Course{}).Where("id = ?", *courseID).Count(&count).Error; err != nil {
+		return apperr.Internal("Failed to validate course", err)
+	}
+	if count == 0 {
+		return apperr.Validation(apperr.FieldError{Field: "course_id", Message: "does not exist"})
+	}
+	return nil
 }
 
-func NewEnrollmentHandler(db *gorm.DB) *EnrollmentHandler {
-	return &EnrollmentHandler{db: db}
+// loadSchedulesInWindow loads the active schedules, with class, instructor and
+// any extra associations, that may have an occurrence between from and to
+func loadSchedulesInWindow(c *gin.Context, db *gorm.DB, from, to time.Time, extra ...Preload) ([]models.Schedule, error) {
+	query := requestDB(c, db).
+		Joins("JOIN classes ON classes.id = schedules.class_id AND classes.is_active = ?", true).
+		Preload("Class").
+		Preload("Instructor", instructorSummary)
+	for _, p := range extra {
+		query = query.Preload(p.Association, p.Conds...)
+	}
+
+	var schedules []models.Schedule
+	err := query.
+		Where("schedules.start_time <= ?", to).
+		Where("(schedules.recurrence_type = ? AND schedules.end_time >= ?) OR "+
+			"(schedules.recurrence_type <> ? AND (schedules.recurrence_end_date IS NULL OR schedules.recurrence_end_date >= ?))",
+			models.Once, from, models.Once, from.Add(-24*time.Hour)).
+		Find(&schedules).Error
+	return schedules, err
 }
 
-func (h *EnrollmentHandler) Create(c *gin.Context) {
-	userID := c.GetString("user_id")
+// maxReportedConflicts bounds the conflict list returned to the client
+const maxReportedConflicts = 50
 
-	if userID == "" {
-		log.Println("ERROR: user_id not found in context for enrollment")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
+// checkConflicts compares every occurrence of schedule with the occurrences of
+// all other active schedules, returning a 409 listing the overlaps
+func (h *ScheduleHandler) checkConflicts(c *gin.Context, schedule models.Schedule, class *models.Class, instructor *models.User) error {
+	schedule.Class = *class
+	schedule.Instructor = instructor
+	from, to := scheduling.Window(schedule)
 
-	var input struct {
-		ScheduleID string `json:"schedule_id" binding:"required"`
+	existing, err := loadSchedulesInWindow(c, h.db, from, to)
+	if err != nil {
+		return apperr.Internal("Failed to check schedule conflicts", err)
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Printf("ERROR: Invalid JSON for enrollment: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
-		return
+	conflicts := scheduling.FindConflicts(schedule, existing)
+	if len(conflicts) == 0 {
+		return nil
 	}
 
-	// Validate schedule ID format
-	if _, err := uuid.Parse(input.ScheduleID); err != nil {
-		log.Printf("ERROR: Invalid schedule ID format: %s", input.ScheduleID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID format"})
-		return
+	log.Printf("WARN: Schedule for class %s conflicts with %d existing occurrence(s)", schedule.ClassID, len(conflicts))
+	total := len(conflicts)
+	if total > maxReportedConflicts {
+		conflicts = conflicts[:maxReportedConflicts]
 	}
+	return apperr.Conflict(fmt.Sprintf("Schedule overlaps %d existing class occurrence(s)", total)).WithDetails(map[string]interface{}{
+		"conflicts":       conflicts,
+		"total_conflicts": total,
+	})
+}
 
-	// Check if schedule exists and has capacity
-	var schedule models.Schedule
-	if err := h.db.Preload("Class").Preload("Enrollments").First(&schedule, "id = ?", input.ScheduleID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("WARN: Schedule not found for enrollment: %s", input.ScheduleID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
-		} else {
-			log.Printf("ERROR: Database error fetching schedule: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
-		}
-		return
-	}
+// maxOccurrenceRange bounds GET /schedules/occurrences
+const maxOccurrenceRange = 92 * 24 * time.Hour
+
+// ScheduledOccurrence is one concrete session generated from a schedule
+type ScheduledOccurrence struct {
+	ScheduleID         uuid.UUID  `json:"schedule_id"`
+	ClassID            uuid.UUID  `json:"class_id"`
+	ClassTitle         string     `json:"class_title"`
+	InstructorID       *uuid.UUID `json:"instructor_id"`
+	Instructor         string     `json:"instructor"`
+	Substitute         bool       `json:"substitute"`
+	RoomID             *uuid.UUID `json:"room_id"`
+	Room               string     `json:"room,omitempty"`
+	Location           string     `json:"location,omitempty"`
+	CourseID           *uuid.UUID `json:"course_id,omitempty"` // booked by joining the course
+	Capacity           int        `json:"capacity"`
+	Booked             int        `json:"booked"`
+	Waitlisted         int        `json:"waitlisted"`
+	Start              time.Time  `json:"start"`
+	End                time.Time  `json:"end"`
+	Cancelled          bool       `json:"cancelled"`
+	CancellationReason string     `json:"cancellation_reason,omitempty"`
+}
 
-	// Check if Class is loaded
-	if schedule.Class.ID == uuid.Nil {
-		log.Printf("ERROR: Class not loaded for schedule: %s", input.ScheduleID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Schedule configuration error"})
+// GetOccurrences - Public: expands schedules into sessions between start_date
+// and end_date. Sessions during studio closures are left out; sessions called
+// off by the studio are flagged as cancelled; substitutes are shown.
+func (h *ScheduleHandler) GetOccurrences(c *gin.Context) {
+	from, to, err := occurrenceWindow(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
-	// Check capacity
-	if len(schedule.Enrollments) >= schedule.Class.Capacity {
-		log.Printf("WARN: Class full for schedule: %s", input.ScheduleID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Class is full"})
+	occurrences, closures, err := h.listOccurrences(c, from, to)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Check if already enrolled
-	var existing models.Enrollment
-	result := h.db.Where("user_id = ? AND schedule_id = ?", userID, input.ScheduleID).First(&existing)
-	if result.Error == nil {
-		log.Printf("WARN: User already enrolled: user=%s, schedule=%s", userID, input.ScheduleID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Already enrolled in this class"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from,
+		"to":          to,
+		"occurrences": occurrences,
+		"closures":    closures,
+	})
+}
 
-	// Parse user ID
-	parsedUserID, err := uuid.Parse(userID)
+// GetCalendar - Public: the sessions of GetOccurrences as an iCalendar feed
+// for calendar apps. Cancelled sessions keep their event with a cancelled
+// status so subscribers see the change; closures are listed as free time.
+func (h *ScheduleHandler) GetCalendar(c *gin.Context) {
+	from, to, err := occurrenceWindow(c)
 	if err != nil {
-		log.Printf("ERROR: Invalid user_id format: %s", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		c.Error(err)
 		return
 	}
-
-	parsedScheduleID, err := uuid.Parse(input.ScheduleID)
+	occurrences, closures, err := h.listOccurrences(c, from, to)
 	if err != nil {
-		log.Printf("ERROR: Invalid schedule_id format: %s", input.ScheduleID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		c.Error(err)
 		return
 	}
 
-	// Create enrollment
-	enrollment := models.Enrollment{
-		UserID:        parsedUserID,
-		ScheduleID:    parsedScheduleID,
-		PaymentStatus: models.PaymentCompleted, // Free for now
+	events := make([]calendar.Event, 0, len(occurrences)+len(closures))
+	for _, o := range occurrences {
+		event := calendar.Event{
+			UID:       fmt.Sprintf("%s-%d@schedules", o.ScheduleID, o.Start.Unix()),
+			Summary:   o.ClassTitle,
+			Start:     o.Start,
+			End:       o.End,
+			Cancelled: o.Cancelled,
+		}
+		if o.Instructor != "" {
+			event.Description = "Instructor: " + o.Instructor
+		}
+		switch {
+		case o.Room != "" && o.Location != "":
+			event.Location = o.Room + ", " + o.Location
+		case o.Room != "":
+			event.Location = o.Room
+		}
+		if o.CancellationReason != "" {
+			if event.Description != "" {
+				event.Description += "\n"
+			}
+			event.Description += "Cancelled: " + o.CancellationReason
+		}
+		events = append(events, event)
+	}
+	for _, closure := range closures {
+		summary := "Studio closed: " + closure.Title
+		if closure.RoomID != nil {
+			summary = "Room closed: " + closure.Title
+		}
+		events = append(events, calendar.Event{
+			UID:     closure.ID.String() + "@closures",
+			Summary: summary,
+			Start:   closure.StartsAt,
+			End:     closure.EndsAt,
+			Free:    true,
+		})
 	}
 
-	if err := h.db.Create(&enrollment).Error; err != nil {
-		log.Printf("ERROR: Failed to create enrollment: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create enrollment"})
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, "Class schedule", time.Now(), events); err != nil {
+		c.Error(apperr.Internal("Failed to render calendar", err))
 		return
 	}
+	c.Header("Content-Disposition", `inline; filename="schedule.ics"`)
+	c.Data(http.StatusOK, calendar.ContentType, buf.Bytes())
+}
 
-	// Load relationships
-	if err := h.db.Preload("Schedule.Class").First(&enrollment, enrollment.ID).Error; err != nil {
-		log.Printf("WARN: Failed to load enrollment relationships: %v", err)
-		// Still return success since enrollment was created
+// occurrenceWindow reads start_date and end_date for the occurrence listings,
+// defaulting to the next two weeks
+func occurrenceWindow(c *gin.Context) (from, to time.Time, err error) {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return from, to, err
 	}
-
-	log.Printf("INFO: Enrollment created: user=%s, schedule=%s", userID, input.ScheduleID)
-	c.JSON(http.StatusCreated, enrollment)
+	from = time.Now().In(studioZone(c))
+	if start != nil {
+		from = *start
+	}
+	to = from.Add(14 * 24 * time.Hour)
+	if end != nil {
+		to = *end
+	}
+	if !to.After(from) {
+		return from, to, apperr.Validation(apperr.FieldError{Field: "end_date", Message: "must be after start_date"})
+	}
+	if to.Sub(from) > maxOccurrenceRange {
+		return from, to, apperr.Validation(apperr.FieldError{Field: "end_date", Message: "must be within 92 days of start_date"})
+	}
+	return from, to, nil
 }
 
-func (h *EnrollmentHandler) GetMyEnrollments(c *gin.Context) {
-	userID := c.GetString("user_id")
+// listOccurrences expands the schedules running between from and to into
+// sessions sorted by start, along with the closures in that period
+func (h *ScheduleHandler) listOccurrences(c *gin.Context, from, to time.Time) ([]ScheduledOccurrence, []models.Closure, error) {
+	schedules, err := loadSchedulesInWindow(c, h.db, from, to,
+		preload("Cancellations"), preload("Room.Location"), preload("Enrollments", sessionEnrollments(from, to)),
+		preload("Substitutions", approvedSubstitutions), preload("Substitutions.Substitute", instructorSummary))
+	if err != nil {
+		return nil, nil, apperr.Internal("Failed to fetch schedules", err)
+	}
 
-	var enrollments []models.Enrollment
-	if err := h.db.Preload("Schedule.Class").Where("user_id = ?", userID).Order("created_at DESC").Find(&enrollments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch enrollments"})
-		return
+	var closures []models.Closure
+	if err := requestDB(c, h.db).Where("starts_at < ? AND ends_at > ?", to, from).Order("starts_at").Find(&closures).Error; err != nil {
+		return nil, nil, apperr.Internal("Failed to fetch closures", err)
 	}
 
-	c.JSON(http.StatusOK, enrollments)
-}
+	occurrences := []ScheduledOccurrence{}
+	for _, schedule := range schedules {
+		if schedule.PrivateCapacity > 0 {
+			continue
+		}
+		for _, o := range scheduling.Expand(schedule, from, to) {
+			if closed(closures, schedule.RoomID, o) {
+				continue
+			}
+
+			item := ScheduledOccurrence{
+				ScheduleID:   schedule.ID,
+				ClassID:      schedule.ClassID,
+				ClassTitle:   schedule.Class.Title,
+				InstructorID: schedule.EffectiveInstructorID(),
+				Instructor:   schedule.Class.InstructorName,
+				RoomID:       schedule.RoomID,
+				CourseID:     schedule.CourseID,
+				Capacity:     schedule.Capacity(),
+				Start:        o.Start,
+				End:          o.End,
+			}
+			item.Booked, item.Waitlisted = sessionPlaces(schedule.Enrollments, o.Start)
+			if schedule.Instructor != nil {
+				item.Instructor = schedule.Instructor.Name
+			}
+			if schedule.Room != nil {
+				item.Room = schedule.Room.Name
+				if schedule.Room.Location != nil {
+					item.Location = schedule.Room.Location.Name
+				}
+			}
+			for _, sub := range schedule.Substitutions {
+				if sub.OccurrenceStart.Equal(o.Start) && sub.Substitute != nil {
+					item.InstructorID = sub.SubstituteID
+					item.Instructor = sub.Substitute.Name
+					item.Substitute = true
+				}
+			}
+			for _, cancellation := range schedule.Cancellations {
+				if cancellation.OccurrenceStart.Equal(o.Start) {
+					item.Cancelled = true
+					item.CancellationReason = cancellation.Reason
+				}
+			}
+			occurrences = append(occurrences, item)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
 
-func (h *EnrollmentHandler) Cancel(c *gin.Context) {
-	userID := c.GetString("user_id")
-	enrollmentID := c.Param("id")
+	if closures == nil {
+		closures = []models.Closure{}
+	}
+	return occurrences, closures, nil
+}
 
-	if userID == "" {
-		log.Println("ERROR: user_id not found in context for enrollment cancellation")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+// closed reports whether any closure overlaps the occurrence in the given room
+func closed(closures []models.Closure, roomID *uuid.UUID, o scheduling.Occurrence) bool {
+	for i := range closures {
+		if closures[i].Covers(roomID, o.Start, o.End) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Validate enrollment ID
-	if _, err := uuid.Parse(enrollmentID); err != nil {
-		log.Printf("ERROR: Invalid enrollment ID format: %s", enrollmentID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid enrollment ID format"})
-		return
+// cancelOccurrence calls off one session of schedule inside tx: it records the
+// cancellation, withdraws open cover requests and notifies attendees. Once no
+// session of the schedule is left to attend, its enrollments are marked
+// cancelled by the studio. schedule must have Class and Cancellations loaded;
+// the new cancellation is appended so repeated calls see each other.
+func cancelOccurrence(tx *gorm.DB, schedule *models.Schedule, occurrence scheduling.Occurrence, reason string, actorID uuid.UUID) (attendees int, enrollmentsCancelled int64, err error) {
+	cancellation := models.OccurrenceCancellation{
+		ScheduleID:      schedule.ID,
+		OccurrenceStart: occurrence.Start,
+		OccurrenceEnd:   occurrence.End,
+		Reason:          reason,
+		CancelledBy:     actorID,
 	}
+	if err := tx.Create(&cancellation).Error; err != nil {
+		return 0, 0, err
+	}
+	schedule.Cancellations = append(schedule.Cancellations, cancellation)
 
-	// Verify ownership and load schedule
-	var enrollment models.Enrollment
-	if err := h.db.Preload("Schedule").Where("id = ? AND user_id = ?", enrollmentID, userID).First(&enrollment).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("WARN: Enrollment not found or unauthorized: enrollment=%s, user=%s", enrollmentID, userID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Enrollment not found"})
-		} else {
-			log.Printf("ERROR: Database error fetching enrollment: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch enrollment"})
+	// Nobody needs to cover a session that isn't happening
+	var open []models.Substitution
+	if err := tx.Where("schedule_id = ? AND occurrence_start = ? AND status IN ?", schedule.ID, occurrence.Start,
+		[]models.SubstitutionStatus{models.SubstitutionRequested, models.SubstitutionAccepted}).Find(&open).Error; err != nil {
+		return 0, 0, err
+	}
+	for i := range open {
+		open[i].Status = models.SubstitutionCancelled
+		if err := tx.Omit(clause.Associations).Save(&open[i]).Error; err != nil {
+			return 0, 0, err
+		}
+		if err := recordSubstitutionEvent(tx, &open[i], actorID, "Occurrence cancelled by studio"); err != nil {
+			return 0, 0, err
 		}
-		return
 	}
 
-	// Check if schedule exists
-	if enrollment.Schedule.ID == uuid.Nil {
-		log.Printf("ERROR: Schedule not loaded for enrollment: %s", enrollmentID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Enrollment configuration error"})
-		return
+	var attendeeIDs []uuid.UUID
+	if err := tx.Model(&models.Enrollment{}).
+		Where("schedule_id = ? AND status IN ? AND (occurrence_start IS NULL OR occurrence_start = ?)", schedule.ID,
+			[]models.EnrollmentStatus{models.EnrollmentActive, models.EnrollmentWaitlisted}, occurrence.Start).
+		Pluck("user_id", &attendeeIDs).Error; err != nil {
+		return 0, 0, err
+	}
+	if schedule.CourseID != nil {
+		var roster []uuid.UUID
+		if err := tx.Model(&models.CourseEnrollment{}).
+			Where("course_id = ? AND status = ?", *schedule.CourseID, models.CourseEnrolled).
+			Pluck("user_id", &roster).Error; err != nil {
+			return 0, 0, err
+		}
+		attendeeIDs = append(attendeeIDs, roster...)
 	}
 
-	// Check if cancellation is within 1 hour of class start
+	// Bookings of just this session end with it
 	now := time.Now()
-	classStartTime := enrollment.Schedule.StartTime
-	timeUntilClass := classStartTime.Sub(now)
-
-	if timeUntilClass < time.Hour && timeUntilClass > 0 {
-		log.Printf("WARN: Cancellation denied - within 1 hour of class: enrollment=%s, time_until_class=%v", enrollmentID, timeUntilClass)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":               "Cannot cancel within 1 hour of class start time",
-			"minutes_until_class": int(timeUntilClass.Minutes()),
+	result := tx.Model(&models.Enrollment{}).
+		Where("schedule_id = ? AND occurrence_start = ? AND status IN ?", schedule.ID, occurrence.Start,
+			[]models.EnrollmentStatus{models.EnrollmentActive, models.EnrollmentWaitlisted}).
+		Updates(map[string]interface{}{
+			"status":       models.EnrollmentCancelledByStudio,
+			"cancelled_at": now,
 		})
-		return
+	if result.Error != nil {
+		return 0, 0, result.Error
 	}
+	enrollmentsCancelled = result.RowsAffected
 
-	// Allow cancellation if class has already started or passed (for cleanup)
-	if timeUntilClass < 0 {
-		log.Printf("WARN: Cancelling enrollment for past class: enrollment=%s", enrollmentID)
+	// Enrollments for the schedule as a whole only end with its last session
+	cancelled := make(map[int64]bool, len(schedule.Cancellations))
+	for _, existing := range schedule.Cancellations {
+		cancelled[existing.OccurrenceStart.Unix()] = true
+	}
+	from, to := scheduling.Window(*schedule)
+	seriesOver := true
+	for _, o := range scheduling.Expand(*schedule, from, to) {
+		if o.End.After(now) && !cancelled[o.Start.Unix()] {
+			seriesOver = false
+			break
+		}
 	}
 
-	if err := h.db.Delete(&enrollment).Error; err != nil {
-		log.Printf("ERROR: Failed to cancel enrollment: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel enrollment"})
+	if seriesOver {
+		// Classes aren't charged through the app, so payment status is left alone
+		result := tx.Model(&models.Enrollment{}).
+			Where("schedule_id = ? AND status = ? AND occurrence_start IS NULL", schedule.ID, models.EnrollmentActive).
+			Updates(map[string]interface{}{
+				"status":       models.EnrollmentCancelledByStudio,
+				"cancelled_at": now,
+			})
+		if result.Error != nil {
+			return 0, 0, result.Error
+		}
+		enrollmentsCancelled += result.RowsAffected
+	}
+
+	message := fmt.Sprintf("%s on %s has been cancelled", schedule.Class.Title, occurrence.Start.Format(notificationTimeFormat))
+	if reason != "" {
+		message += ": " + reason
+	}
+	recipients := attendeeIDs
+	for _, sub := range open {
+		if sub.SubstituteID != nil {
+			recipients = append(recipients, *sub.SubstituteID)
+		}
+	}
+	if id := schedule.EffectiveInstructorID(); id != nil {
+		recipients = append(recipients, *id)
+	}
+	if err := notifyUsers(tx, recipients, models.NotificationClassCancelled, "Class cancelled", message); err != nil {
+		return 0, 0, err
+	}
+
+	return len(attendeeIDs), enrollmentsCancelled, nil
+}
+
+// CancelOccurrence - Admin: call off one session. The cancellation stays on the
+// public schedule and attendees are notified.
+func (h *ScheduleHandler) CancelOccurrence(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input OccurrenceCancellationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).Preload("Class").Preload("Cancellations").First(&schedule, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(apperr.NotFound("Schedule not found"))
+		return
+	}
+
+	occurrence, ok := scheduling.OccurrenceAt(schedule, input.OccurrenceStart)
+	if !ok {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "is not an occurrence of this schedule"}))
+		return
+	}
+	for _, existing := range schedule.Cancellations {
+		if existing.OccurrenceStart.Equal(occurrence.Start) {
+			c.Error(apperr.Conflict("This occurrence is already cancelled"))
+			return
+		}
+	}
+
+	var attendees int
+	var enrollmentsCancelled int64
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		attendees, enrollmentsCancelled, err = cancelOccurrence(tx, &schedule, occurrence, input.Reason, adminID)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to cancel occurrence: schedule=%s, occurrence=%s: %v", schedule.ID, occurrence.Start.Format(time.RFC3339), err)
+		c.Error(apperr.Internal("Failed to cancel occurrence", err))
+		return
+	}
+	metrics.EnrollmentCancellations.Add(float64(enrollmentsCancelled))
+
+	log.Printf("INFO: Occurrence cancelled: schedule=%s, occurrence=%s, attendees=%d, enrollments_cancelled=%d",
+		schedule.ID, occurrence.Start.Format(time.RFC3339), attendees, enrollmentsCancelled)
+	c.JSON(http.StatusOK, gin.H{
+		"cancellation":          schedule.Cancellations[len(schedule.Cancellations)-1],
+		"attendees_notified":    attendees,
+		"enrollments_cancelled": enrollmentsCancelled,
+	})
+}
+
+// Delete - Admin: remove a schedule that nobody has booked. Schedules with
+// enrollments or approved cover keep their history and must be cancelled instead.
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid schedule ID format"))
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		var enrollments int64
+		if err := tx.Model(&models.Enrollment{}).Where("schedule_id = ?", id).Count(&enrollments).Error; err != nil {
+			return err
+		}
+		if enrollments > 0 {
+			return apperr.Conflict("Schedule has enrollments; cancel its occurrences instead").WithDetails(map[string]interface{}{
+				"enrollments": enrollments,
+			})
+		}
+
+		var approved int64
+		if err := tx.Model(&models.Substitution{}).Where("schedule_id = ? AND status = ?", id, models.SubstitutionApproved).Count(&approved).Error; err != nil {
+			return err
+		}
+		if approved > 0 {
+			return apperr.Conflict("Schedule has approved substitutions that are kept for payroll")
+		}
+
+		var attendance int64
+		if err := tx.Model(&models.CourseAttendance{}).Where("schedule_id = ?", id).Count(&attendance).Error; err != nil {
+			return err
+		}
+		if attendance > 0 {
+			return apperr.Conflict("Schedule has course attendance that is kept for certification")
+		}
+
+		subs := tx.Model(&models.Substitution{}).Select("id").Where("schedule_id = ?", id)
+		if err := tx.Where("substitution_id IN (?)", subs).Delete(&models.SubstitutionEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.Substitution{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.OccurrenceCancellation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Schedule{}, "id = ?", id).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// ============ Location Handler ============
+type LocationHandler struct {
+	db *gorm.DB
+}
+
+func NewLocationHandler(db *gorm.DB) *LocationHandler {
+	return &LocationHandler{db: db}
+}
+
+// activeRooms limits a preload to rooms that can still be scheduled
+func activeRooms(db *gorm.DB) *gorm.DB {
+	return db.Where("is_active = ?", true).Order("name")
+}
+
+// loadRoom resolves an optional room reference from a request body, failing
+// validation on field unless it refers to an active room at an active location
+func loadRoom(c *gin.Context, db *gorm.DB, id *uuid.UUID, field string) (*models.Room, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	var room models.Room
+	if err := requestDB(c, db).Preload("Location").First(&room, "id = ?", *id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.Validation(apperr.FieldError{Field: field, Message: "does not exist"})
+		}
+		return nil, apperr.Internal("Failed to load room", err)
+	}
+
+	if !room.IsActive || room.Location == nil || !room.Location.IsActive {
+		return nil, apperr.Validation(apperr.FieldError{Field: field, Message: "refers to an inactive room"})
+	}
+
+	return &room, nil
+}
+
+// scheduleTimezone is the zone a schedule held in room recurs in: the room's
+// location's when set, else the studio's
+func scheduleTimezone(c *gin.Context, room *models.Room) string {
+	if room != nil && room.Location != nil && room.Location.Timezone != "" {
+		return room.Location.Timezone
+	}
+	if studio := middleware.CurrentStudio(c); studio != nil && studio.Timezone != "" {
+		return studio.Timezone
+	}
+	return models.DefaultTimezone
+}
+
+// GetAll - Public: active locations with their active rooms
+func (h *LocationHandler) GetAll(c *gin.Context) {
+	var locations []models.Location
+	if err := requestDB(c, h.db).Preload("Rooms", activeRooms).
+		Where("is_active = ?", true).
+		Order("name").
+		Find(&locations).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch locations", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// Create - Admin: add a location
+func (h *LocationHandler) Create(c *gin.Context) {
+	var input LocationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	location := models.Location{IsActive: true}
+	input.Apply(&location)
+	if err := requestDB(c, h.db).Create(&location).Error; err != nil {
+		c.Error(apperr.Internal("Failed to create location", err))
+		return
+	}
+
+	log.Printf("INFO: Location created: %s", location.Name)
+	c.JSON(http.StatusCreated, location)
+}
+
+// Update - Admin: edit a location's details
+func (h *LocationHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid location ID format"))
+		return
+	}
+
+	var location models.Location
+	if err := requestDB(c, h.db).First(&location, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Location not found"))
+		return
+	}
+
+	var input LocationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	previousTimezone := location.Timezone
+	input.Apply(&location)
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&location).Error; err != nil {
+			return err
+		}
+		if location.Timezone == previousTimezone {
+			return nil
+		}
+		// Schedules in this location's rooms now recur in its new zone
+		rooms := tx.Model(&models.Room{}).Select("id").Where("location_id = ?", location.ID)
+		return tx.Model(&models.Schedule{}).Where("room_id IN (?)", rooms).
+			UpdateColumn("timezone", scheduleTimezone(c, &models.Room{Location: &location})).Error
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to update location", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// Delete - Admin: retire a location. Like classes, locations are only
+// deactivated so existing schedules keep their history.
+func (h *LocationHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid location ID format"))
+		return
+	}
+
+	result := requestDB(c, h.db).Model(&models.Location{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		c.Error(apperr.Internal("Failed to delete location", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperr.NotFound("Location not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted successfully"})
+}
+
+// CreateRoom - Admin: add a room to a location
+func (h *LocationHandler) CreateRoom(c *gin.Context) {
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(apperr.BadRequest("Invalid location ID format"))
+		return
+	}
+
+	var location models.Location
+	if err := requestDB(c, h.db).First(&location, "id = ?", locationID).Error; err != nil {
+		c.Error(apperr.NotFound("Location not found"))
+		return
+	}
+
+	var input RoomRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	room := models.Room{LocationID: location.ID, IsActive: true}
+	input.Apply(&room)
+	if err := requestDB(c, h.db).Create(&room).Error; err != nil {
+		c.Error(apperr.Internal("Failed to create room", err))
+		return
+	}
+
+	log.Printf("INFO: Room created: %s at %s", room.Name, location.Name)
+	c.JSON(http.StatusCreated, room)
+}
+
+// UpdateRoom - Admin: edit a room's name, capacity or amenities
+func (h *LocationHandler) UpdateRoom(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid room ID format"))
+		return
+	}
+
+	var room models.Room
+	if err := requestDB(c, h.db).First(&room, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Room not found"))
+		return
+	}
+
+	var input RoomRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	input.Apply(&room)
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(&room).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update room", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+// DeleteRoom - Admin: retire a room; schedules already using it keep it
+func (h *LocationHandler) DeleteRoom(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid room ID format"))
+		return
+	}
+
+	result := requestDB(c, h.db).Model(&models.Room{}).Where("id = ?", id).Update("is_active", false)
+	if result.Error != nil {
+		c.Error(apperr.Internal("Failed to delete room", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperr.NotFound("Room not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Room deleted successfully"})
+}
+
+// ============ Closure Handler ============
+var closureSortKeys = sortKeys{
+	"starts_at":  "starts_at",
+	"created_at": "created_at",
+}
+
+type ClosureHandler struct {
+	db *gorm.DB
+}
+
+func NewClosureHandler(db *gorm.DB) *ClosureHandler {
+	return &ClosureHandler{db: db}
+}
+
+// GetAll - Public: closures overlapping start_date..end_date, or upcoming ones by default
+func (h *ClosureHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, closureSortKeys, "starts_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Closure{})
+
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if startDate == nil && endDate == nil {
+		now := time.Now()
+		startDate = &now
+	}
+	if startDate != nil {
+		query = query.Where("ends_at > ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("starts_at < ?", *endDate)
+	}
+
+	var closures []models.Closure
+	total, err := paginate(query, params, &closures)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch closures", err))
+		return
+	}
+
+	if closures == nil {
+		closures = []models.Closure{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(closures, total, params))
+}
+
+// Create - Admin: close the studio for a period. Upcoming sessions in it are
+// cancelled, which notifies anyone already booked.
+func (h *ClosureHandler) Create(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input ClosureRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if _, err := loadRoom(c, h.db, input.RoomID, "room_id"); err != nil {
+		c.Error(err)
+		return
+	}
+
+	closure := models.Closure{
+		Title:     input.Title,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		RoomID:    input.RoomID,
+		CreatedBy: adminID,
+	}
+
+	schedules, err := loadSchedulesInWindow(c, h.db, closure.StartsAt, closure.EndsAt, preload("Cancellations"))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch affected schedules", err))
+		return
+	}
+
+	now := time.Now()
+	var occurrencesCancelled int
+	var enrollmentsCancelled int64
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&closure).Error; err != nil {
+			return err
+		}
+
+		for i := range schedules {
+			schedule := &schedules[i]
+			length := schedule.EndTime.Sub(schedule.StartTime)
+			for _, o := range scheduling.Expand(*schedule, closure.StartsAt.Add(-length), closure.EndsAt) {
+				if !closure.Covers(schedule.RoomID, o.Start, o.End) || !o.End.After(now) || isCancelled(schedule, o) {
+					continue
+				}
+				_, cancelled, err := cancelOccurrence(tx, schedule, o, "Studio closed: "+closure.Title, adminID)
+				if err != nil {
+					return err
+				}
+				occurrencesCancelled++
+				enrollmentsCancelled += cancelled
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to create closure: %v", err)
+		c.Error(apperr.Internal("Failed to create closure", err))
+		return
+	}
+	metrics.EnrollmentCancellations.Add(float64(enrollmentsCancelled))
+
+	log.Printf("INFO: Closure created: %s (%s - %s), occurrences_cancelled=%d",
+		closure.Title, closure.StartsAt.Format(time.RFC3339), closure.EndsAt.Format(time.RFC3339), occurrencesCancelled)
+	c.JSON(http.StatusCreated, gin.H{
+		"closure":               closure,
+		"occurrences_cancelled": occurrencesCancelled,
+		"enrollments_cancelled": enrollmentsCancelled,
+	})
+}
+
+// Delete - Admin: remove a closure. Sessions it cancelled stay cancelled.
+func (h *ClosureHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid closure ID format"))
+		return
+	}
+
+	result := requestDB(c, h.db).Delete(&models.Closure{}, "id = ?", id)
+	if result.Error != nil {
+		c.Error(apperr.Internal("Failed to delete closure", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperr.NotFound("Closure not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Closure deleted successfully"})
+}
+
+// closuresFor limits a closure query to those that apply to a room
+func closuresFor(roomID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if roomID == nil {
+			return db.Where("room_id IS NULL")
+		}
+		return db.Where("room_id IS NULL OR room_id = ?", *roomID)
+	}
+}
+
+// isCancelled reports whether the studio already called off this occurrence
+func isCancelled(schedule *models.Schedule, o scheduling.Occurrence) bool {
+	for _, cancellation := range schedule.Cancellations {
+		if cancellation.OccurrenceStart.Equal(o.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============ Enrollment Handler ============
+
+// activeEnrollments limits a preload to enrollments that hold a place in
+// every session of their schedule, as opposed to single-session bookings
+func activeEnrollments(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ? AND occurrence_start IS NULL", models.EnrollmentActive)
+}
+
+// sessionEnrollments limits a preload to enrollments holding or waiting for a
+// place in sessions starting between from and to
+func sessionEnrollments(from, to time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status IN ? AND (occurrence_start IS NULL OR occurrence_start BETWEEN ? AND ?)",
+			[]models.EnrollmentStatus{models.EnrollmentActive, models.EnrollmentWaitlisted}, from, to)
+	}
+}
+
+// sessionPlaces counts the places taken and waitlisted in the session starting at start
+func sessionPlaces(enrollments []models.Enrollment, start time.Time) (booked, waitlisted int) {
+	for i := range enrollments {
+		e := &enrollments[i]
+		switch {
+		case e.HoldsPlace(start):
+			booked++
+		case e.Status == models.EnrollmentWaitlisted && e.OccurrenceStart != nil && e.OccurrenceStart.Equal(start):
+			waitlisted++
+		}
+	}
+	return booked, waitlisted
+}
+
+// fillFromWaitlist gives free places in the session of scheduleID starting at
+// start to waitlisted students, first come first served, and tells them
+func fillFromWaitlist(tx *gorm.DB, scheduleID uuid.UUID, start time.Time) error {
+	var schedule models.Schedule
+	if err := tx.Preload("Class").Preload("Room").Preload("Enrollments", sessionEnrollments(start, start)).
+		First(&schedule, "id = ?", scheduleID).Error; err != nil {
+		return err
+	}
+
+	booked, waitlisted := sessionPlaces(schedule.Enrollments, start)
+	free := schedule.Capacity() - booked
+	if free <= 0 || waitlisted == 0 {
+		return nil
+	}
+
+	var promoted []models.Enrollment
+	if err := tx.Where("schedule_id = ? AND occurrence_start = ? AND status = ?", scheduleID, start, models.EnrollmentWaitlisted).
+		Order("created_at").Limit(free).Find(&promoted).Error; err != nil {
+		return err
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(promoted))
+	students := make([]uuid.UUID, len(promoted))
+	for i, e := range promoted {
+		ids[i], students[i] = e.ID, e.UserID
+	}
+	if err := tx.Model(&models.Enrollment{}).Where("id IN ?", ids).Update("status", models.EnrollmentActive).Error; err != nil {
+		return err
+	}
+
+	metrics.WaitlistPromotions.Add(float64(len(promoted)))
+	log.Printf("INFO: Waitlist promoted: schedule=%s, occurrence=%s, students=%d", scheduleID, start.Format(time.RFC3339), len(promoted))
+	return notifyUsers(tx, students, models.NotificationWaitlist, "You're booked in",
+		fmt.Sprintf("A place opened up in %s on %s and you're now booked in", schedule.Class.Title,
+			start.In(schedule.Zone()).Format(notificationTimeFormat)))
+}
+
+// fillUpcomingFromWaitlist fills the sessions of scheduleID starting after
+// after that have a waitlist, as when a place in every session frees up
+func fillUpcomingFromWaitlist(tx *gorm.DB, scheduleID uuid.UUID, after time.Time) error {
+	var starts []time.Time
+	if err := tx.Model(&models.Enrollment{}).
+		Where("schedule_id = ? AND status = ? AND occurrence_start > ?", scheduleID, models.EnrollmentWaitlisted, after).
+		Distinct("occurrence_start").Order("occurrence_start").Pluck("occurrence_start", &starts).Error; err != nil {
+		return err
+	}
+	for _, start := range starts {
+		if err := fillFromWaitlist(tx, scheduleID, start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var enrollmentSortKeys = sortKeys{
+	"created_at": "enrollments.created_at",
+	"start_time": "coalesce(enrollments.occurrence_start, schedules.start_time)",
+}
+
+type EnrollmentHandler struct {
+	db *gorm.DB
+}
+
+func NewEnrollmentHandler(db *gorm.DB) *EnrollmentHandler {
+	return &EnrollmentHandler{db: db}
+}
+
+func (h *EnrollmentHandler) Create(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if userID == "" {
+		log.Println("ERROR: user_id not found in context for enrollment")
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input struct {
+		ScheduleID string     `json:"schedule_id" binding:"required"`
+		UserID     *uuid.UUID `json:"user_id"` // a household member to book for
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Printf("ERROR: Invalid JSON for enrollment: %v", err)
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	// Validate schedule ID format
+	if _, err := uuid.Parse(input.ScheduleID); err != nil {
+		log.Printf("ERROR: Invalid schedule ID format: %s", input.ScheduleID)
+		c.Error(apperr.BadRequest("Invalid schedule ID format"))
+		return
+	}
+
+	// Bookings may be made for another member of the user's household
+	attendee, bookedBy, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	userID = attendee.String()
+
+	// Clients must have signed the current forms and completed their profile
+	if err := requireBookable(c, requestDB(c, h.db), attendee); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Check if schedule exists and has capacity
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).Preload("Class").Preload("Enrollments", activeEnrollments).Preload("Cancellations").Preload("Room").
+		First(&schedule, "id = ?", input.ScheduleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("WARN: Schedule not found for enrollment: %s", input.ScheduleID)
+			c.Error(apperr.NotFound("Schedule not found"))
+		} else {
+			log.Printf("ERROR: Database error fetching schedule: %v", err)
+			c.Error(apperr.Internal("Failed to fetch schedule", err))
+		}
+		return
+	}
+
+	// Check if Class is loaded
+	if schedule.Class.ID == uuid.Nil {
+		log.Printf("ERROR: Class not loaded for schedule: %s", input.ScheduleID)
+		c.Error(apperr.Internal("Schedule configuration error", nil))
+		return
+	}
+
+	// Course sessions come with a place on the course roster
+	if schedule.CourseID != nil {
+		c.Error(apperr.BadRequest("This class is part of a course; join the course instead"))
+		return
+	}
+
+	// Private sessions are booked against instructor availability, and the
+	// second place on a semi-private one is taken with its invite code
+	if schedule.PrivateCapacity > 0 {
+		c.Error(apperr.BadRequest("This is a private session; join it with its invite code"))
+		return
+	}
+
+	// A cancelled one-off class has nothing left to book
+	if schedule.RecurrenceType == models.Once && len(schedule.Cancellations) > 0 {
+		c.Error(apperr.BadRequest("This class has been cancelled"))
+		return
+	}
+	if schedule.RecurrenceType == models.Once {
+		var closures int64
+		if err := requestDB(c, h.db).Model(&models.Closure{}).Scopes(closuresFor(schedule.RoomID)).
+			Where("starts_at < ? AND ends_at > ?", schedule.EndTime, schedule.StartTime).
+			Count(&closures).Error; err != nil {
+			c.Error(apperr.Internal("Failed to check studio closures", err))
+			return
+		}
+		if closures > 0 {
+			c.Error(apperr.BadRequest("The studio is closed at this time"))
+			return
+		}
+	}
+
+	// Check capacity. A place in every session needs room in the busiest
+	// upcoming session, counting students who booked single sessions.
+	var busiest int64
+	if err := requestDB(c, h.db).Model(&models.Enrollment{}).Select("count(*)").
+		Where("schedule_id = ? AND status = ? AND occurrence_start > ?", schedule.ID, models.EnrollmentActive, time.Now()).
+		Group("occurrence_start").Order("count(*) DESC").Limit(1).Scan(&busiest).Error; err != nil {
+		c.Error(apperr.Internal("Failed to check capacity", err))
+		return
+	}
+	if len(schedule.Enrollments)+int(busiest) >= schedule.Capacity() {
+		log.Printf("WARN: Class full for schedule: %s", input.ScheduleID)
+		c.Error(apperr.BadRequest("Class is full"))
+		return
+	}
+
+	// Check if already enrolled
+	var existing models.Enrollment
+	result := requestDB(c, h.db).Where("user_id = ? AND schedule_id = ? AND occurrence_start IS NULL AND status = ?", userID, input.ScheduleID, models.EnrollmentActive).First(&existing)
+	if result.Error == nil {
+		log.Printf("WARN: User already enrolled: user=%s, schedule=%s", userID, input.ScheduleID)
+		c.Error(apperr.BadRequest("Already enrolled in this class"))
+		return
+	}
+
+	// Parse user ID
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("ERROR: Invalid user_id format: %s", userID)
+		c.Error(apperr.Internal("Invalid user ID", err))
+		return
+	}
+
+	parsedScheduleID, err := uuid.Parse(input.ScheduleID)
+	if err != nil {
+		log.Printf("ERROR: Invalid schedule_id format: %s", input.ScheduleID)
+		c.Error(apperr.BadRequest("Invalid schedule ID"))
+		return
+	}
+
+	// Create enrollment
+	enrollment := models.Enrollment{
+		UserID:     parsedUserID,
+		ScheduleID: parsedScheduleID,
+		BookedBy:   bookedBy,
+	}
+
+	if err := requestDB(c, h.db).Create(&enrollment).Error; err != nil {
+		log.Printf("ERROR: Failed to create enrollment: %v", err)
+		c.Error(apperr.Internal("Failed to create enrollment", err))
+		return
+	}
+	metrics.EnrollmentsCreated.Inc()
+
+	// Load relationships
+	if err := requestDB(c, h.db).Preload("Schedule.Class").First(&enrollment, enrollment.ID).Error; err != nil {
+		log.Printf("WARN: Failed to load enrollment relationships: %v", err)
+		// Still return success since enrollment was created
+	}
+
+	log.Printf("INFO: Enrollment created: user=%s, schedule=%s", userID, input.ScheduleID)
+	c.JSON(http.StatusCreated, enrollment)
+}
+
+// GetMyEnrollments - the current user's bookings, or with user_id those of
+// a member of their household
+func (h *EnrollmentHandler) GetMyEnrollments(c *gin.Context) {
+	params, err := parseListParams(c, enrollmentSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	userID, err := memberParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Enrollment{}).
+		Joins("JOIN schedules ON schedules.id = enrollments.schedule_id").
+		Where("enrollments.user_id = ?", userID)
+
+	// Filter by class date range and booking status. A single-session
+	// booking is dated by its session, not by the schedule's first one.
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if startDate != nil {
+		query = query.Where("coalesce(enrollments.occurrence_start, schedules.start_time) >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("coalesce(enrollments.occurrence_start, schedules.start_time) < ?", *endDate)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("enrollments.status = ?", status)
+	}
+
+	var enrollments []models.Enrollment
+	total, err := paginate(query, params, &enrollments, preload("Schedule.Class"))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch enrollments", err))
+		return
+	}
+
+	if enrollments == nil {
+		enrollments = []models.Enrollment{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(enrollments, total, params))
+}
+
+func (h *EnrollmentHandler) Cancel(c *gin.Context) {
+	userID := c.GetString("user_id")
+	enrollmentID := c.Param("id")
+
+	if userID == "" {
+		log.Println("ERROR: user_id not found in context for enrollment cancellation")
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	// Validate enrollment ID
+	if _, err := uuid.Parse(enrollmentID); err != nil {
+		log.Printf("ERROR: Invalid enrollment ID format: %s", enrollmentID)
+		c.Error(apperr.BadRequest("Invalid enrollment ID format"))
+		return
+	}
+
+	// Verify ownership and load schedule. Household members may cancel each other's bookings.
+	managed, err := managedUsers(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var enrollment models.Enrollment
+	if err := requestDB(c, h.db).Preload("Schedule").Where("id = ? AND user_id IN ?", enrollmentID, managed).First(&enrollment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.Printf("WARN: Enrollment not found or unauthorized: enrollment=%s, user=%s", enrollmentID, userID)
+			c.Error(apperr.NotFound("Enrollment not found"))
+		} else {
+			log.Printf("ERROR: Database error fetching enrollment: %v", err)
+			c.Error(apperr.Internal("Failed to fetch enrollment", err))
+		}
+		return
+	}
+
+	// Check if schedule exists
+	if enrollment.Schedule.ID == uuid.Nil {
+		log.Printf("ERROR: Schedule not loaded for enrollment: %s", enrollmentID)
+		c.Error(apperr.Internal("Enrollment configuration error", nil))
+		return
+	}
+
+	// Check if cancellation is within 1 hour of class start
+	now := time.Now()
+	classStartTime := enrollment.Schedule.StartTime
+	if enrollment.OccurrenceStart != nil {
+		classStartTime = *enrollment.OccurrenceStart
+	}
+	timeUntilClass := classStartTime.Sub(now)
+
+	if timeUntilClass < time.Hour && timeUntilClass > 0 {
+		log.Printf("WARN: Cancellation denied - within 1 hour of class: enrollment=%s, time_until_class=%v", enrollmentID, timeUntilClass)
+		c.Error(apperr.BadRequest("Cannot cancel within 1 hour of class start time").WithDetails(map[string]interface{}{
+			"minutes_until_class": int(timeUntilClass.Minutes()),
+		}))
+		return
+	}
+
+	// Allow cancellation if class has already started or passed (for cleanup)
+	if timeUntilClass < 0 {
+		log.Printf("WARN: Cancelling enrollment for past class: enrollment=%s", enrollmentID)
+	}
+
+	if enrollment.Schedule.PrivateCapacity > 0 {
+		err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+			return cancelPrivateEnrollment(tx, &enrollment, now)
+		})
+	} else {
+		// Cancelled bookings are kept for the history, and free their place
+		// for the waitlist of every session they held one in
+		err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+			wasActive := enrollment.Status == models.EnrollmentActive
+			if err := tx.Model(&enrollment).Omit(clause.Associations).Updates(map[string]interface{}{
+				"status":       models.EnrollmentCancelled,
+				"cancelled_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			if !wasActive {
+				return nil
+			}
+			if enrollment.OccurrenceStart == nil {
+				return fillUpcomingFromWaitlist(tx, enrollment.ScheduleID, now)
+			}
+			return fillFromWaitlist(tx, enrollment.ScheduleID, *enrollment.OccurrenceStart)
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to cancel enrollment: %v", err)
+		c.Error(apperr.Internal("Failed to cancel enrollment", err))
+		return
+	}
+	metrics.EnrollmentCancellations.Inc()
+
+	log.Printf("INFO: Enrollment cancelled successfully: enrollment=%s, user=%s", enrollmentID, userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Enrollment cancelled successfully"})
+}
+
+// cancelPrivateEnrollment cancels a client's place on a private session.
+// When nobody is left the session is called off, which frees the instructor's
+// time and lets them know.
+func cancelPrivateEnrollment(tx *gorm.DB, enrollment *models.Enrollment, now time.Time) error {
+	if err := tx.Model(enrollment).Omit(clause.Associations).Updates(map[string]interface{}{
+		"status":       models.EnrollmentCancelled,
+		"cancelled_at": now,
+	}).Error; err != nil {
+		return err
+	}
+
+	var remaining int64
+	if err := tx.Model(&models.Enrollment{}).
+		Where("schedule_id = ? AND status = ?", enrollment.ScheduleID, models.EnrollmentActive).
+		Count(&remaining).Error; err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	var schedule models.Schedule
+	if err := tx.Preload("Class").Preload("Cancellations").First(&schedule, "id = ?", enrollment.ScheduleID).Error; err != nil {
+		return err
+	}
+	occurrence, ok := scheduling.OccurrenceAt(schedule, schedule.StartTime)
+	if !ok || isCancelled(&schedule, occurrence) {
+		return nil
+	}
+	_, _, err := cancelOccurrence(tx, &schedule, occurrence, "Cancelled by the client", enrollment.UserID)
+	return err
+}
+
+// Outcomes of booking one session of a series
+const (
+	SessionBooked        = "booked"
+	SessionWaitlisted    = "waitlisted"
+	SessionFull          = "full"
+	SessionCancelled     = "cancelled"
+	SessionClosed        = "closed"
+	SessionAlreadyBooked = "already_booked"
+)
+
+// SeriesSessionResult reports what happened to one session of a series booking
+type SeriesSessionResult struct {
+	OccurrenceStart time.Time  `json:"occurrence_start"`
+	OccurrenceEnd   time.Time  `json:"occurrence_end"`
+	Status          string     `json:"status"`
+	EnrollmentID    *uuid.UUID `json:"enrollment_id,omitempty"`
+}
+
+// BookSeries - books every upcoming session of a recurring schedule up to a
+// date in one action. Full sessions are waitlisted unless join_waitlist is
+// false; the response reports the outcome for each session.
+func (h *EnrollmentHandler) BookSeries(c *gin.Context) {
+	var input SeriesBookingRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	now := time.Now()
+	if err := input.Validate(now); err != nil {
+		c.Error(err)
+		return
+	}
+	userID, bookedBy, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := requireBookable(c, requestDB(c, h.db), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	series := models.EnrollmentSeries{UserID: userID, ScheduleID: input.ScheduleID, Until: input.Until, Status: models.SeriesActive}
+	results := []SeriesSessionResult{}
+	var booked, waitlisted int
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the schedule so concurrent bookings can't both take the last place
+		var locked models.Schedule
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, "id = ?", input.ScheduleID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Schedule not found")
+			}
+			return err
+		}
+
+		var schedule models.Schedule
+		if err := tx.Preload("Class").Preload("Room").Preload("Cancellations").
+			Preload("Enrollments", sessionEnrollments(now, input.Until)).
+			First(&schedule, "id = ?", input.ScheduleID).Error; err != nil {
+			return err
+		}
+		if !schedule.Class.IsActive {
+			return apperr.BadRequest("This class is no longer offered")
+		}
+		if schedule.RecurrenceType == models.Once || schedule.RecurrenceType == "" {
+			return apperr.BadRequest("Only recurring classes can be booked as a series")
+		}
+		if schedule.CourseID != nil {
+			return apperr.BadRequest("This class is part of a course; join the course instead")
+		}
+		for _, e := range schedule.Enrollments {
+			if e.UserID == userID && e.OccurrenceStart == nil {
+				return apperr.Conflict("Already enrolled in every session of this class")
+			}
+		}
+
+		var closures []models.Closure
+		if err := tx.Scopes(closuresFor(schedule.RoomID)).Where("starts_at < ? AND ends_at > ?", input.Until, now).Find(&closures).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		capacity := schedule.Capacity()
+		for _, o := range scheduling.Expand(schedule, now, input.Until) {
+			result := SeriesSessionResult{OccurrenceStart: o.Start, OccurrenceEnd: o.End}
+			taken, _ := sessionPlaces(schedule.Enrollments, o.Start)
+
+			switch {
+			case isCancelled(&schedule, o):
+				result.Status = SessionCancelled
+			case closed(closures, schedule.RoomID, o):
+				result.Status = SessionClosed
+			case hasSessionBooking(schedule.Enrollments, userID, o.Start):
+				result.Status = SessionAlreadyBooked
+			case taken >= capacity && !input.Waitlist():
+				result.Status = SessionFull
+			default:
+				start := o.Start
+				enrollment := models.Enrollment{
+					UserID:          userID,
+					ScheduleID:      schedule.ID,
+					OccurrenceStart: &start,
+					SeriesID:        &series.ID,
+					BookedBy:        bookedBy,
+					Status:          models.EnrollmentActive,
+				}
+				result.Status = SessionBooked
+				if taken >= capacity {
+					enrollment.Status = models.EnrollmentWaitlisted
+					result.Status = SessionWaitlisted
+				}
+				if err := tx.Omit(clause.Associations).Create(&enrollment).Error; err != nil {
+					return err
+				}
+				result.EnrollmentID = &enrollment.ID
+				if enrollment.Status == models.EnrollmentActive {
+					booked++
+				} else {
+					waitlisted++
+				}
+			}
+			results = append(results, result)
+		}
+
+		if booked+waitlisted == 0 {
+			return apperr.Conflict("No sessions could be booked").WithDetails(map[string]interface{}{"sessions": results})
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	metrics.EnrollmentsCreated.Add(float64(booked + waitlisted))
+
+	log.Printf("INFO: Series booked: user=%s, schedule=%s, booked=%d, waitlisted=%d", userID, input.ScheduleID, booked, waitlisted)
+	c.JSON(http.StatusCreated, gin.H{
+		"series":     series,
+		"sessions":   results,
+		"booked":     booked,
+		"waitlisted": waitlisted,
+	})
+}
+
+// hasSessionBooking reports whether userID already holds or waits for a place in the session starting at start
+func hasSessionBooking(enrollments []models.Enrollment, userID uuid.UUID, start time.Time) bool {
+	for _, e := range enrollments {
+		if e.UserID == userID && e.OccurrenceStart != nil && e.OccurrenceStart.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSeries - a series booked by the current user, with its sessions
+func (h *EnrollmentHandler) GetSeries(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid series ID format"))
+		return
+	}
+
+	managed, err := managedUsers(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var series models.EnrollmentSeries
+	err = requestDB(c, h.db).
+		Preload("Schedule.Class").
+		Preload("Enrollments", func(db *gorm.DB) *gorm.DB { return db.Order("occurrence_start") }).
+		First(&series, "id = ? AND user_id IN ?", id, managed).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apperr.NotFound("Series not found"))
+			return
+		}
+		c.Error(apperr.Internal("Failed to fetch series", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// CancelSeries - cancels the remaining sessions of a series. Sessions within
+// an hour of starting are kept, as with single cancellations.
+func (h *EnrollmentHandler) CancelSeries(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid series ID format"))
+		return
+	}
+
+	managed, err := managedUsers(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	now := time.Now()
+	var series models.EnrollmentSeries
+	var cancelled int
+	kept := []time.Time{}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&series, "id = ? AND user_id IN ?", id, managed).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Series not found")
+			}
+			return err
+		}
+		if series.Status == models.SeriesCancelled {
+			return apperr.Conflict("Series is already cancelled")
+		}
+
+		var remaining []models.Enrollment
+		if err := tx.Where("series_id = ? AND status IN ? AND occurrence_start > ?", series.ID,
+			[]models.EnrollmentStatus{models.EnrollmentActive, models.EnrollmentWaitlisted}, now).
+			Order("occurrence_start").Find(&remaining).Error; err != nil {
+			return err
+		}
+
+		for _, e := range remaining {
+			if e.OccurrenceStart.Sub(now) < time.Hour {
+				kept = append(kept, *e.OccurrenceStart)
+				continue
+			}
+			if err := tx.Model(&e).Updates(map[string]interface{}{
+				"status":       models.EnrollmentCancelled,
+				"cancelled_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			cancelled++
+			if e.Status == models.EnrollmentActive {
+				if err := fillFromWaitlist(tx, e.ScheduleID, *e.OccurrenceStart); err != nil {
+					return err
+				}
+			}
+		}
+
+		series.Status = models.SeriesCancelled
+		series.CancelledAt = &now
+		return tx.Omit(clause.Associations).Save(&series).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	metrics.EnrollmentCancellations.Add(float64(cancelled))
+
+	log.Printf("INFO: Series cancelled: series=%s, sessions=%d, kept=%d", series.ID, cancelled, len(kept))
+	c.JSON(http.StatusOK, gin.H{
+		"series":    series,
+		"cancelled": cancelled,
+		"kept":      kept,
+	})
+}
+
+// ============ Course Handler ============
+var courseSortKeys = sortKeys{
+	"created_at":             "created_at",
+	"title":                  "title",
+	"registration_closes_at": "registration_closes_at",
+}
+
+var courseEnrollmentSortKeys = sortKeys{
+	"created_at": "created_at",
+	"status":     "status",
+}
+
+type CourseHandler struct {
+	db *gorm.DB
+}
+
+func NewCourseHandler(db *gorm.DB) *CourseHandler {
+	return &CourseHandler{db: db}
+}
+
+// CourseSession is one meeting of a course, expanded from its schedules
+type CourseSession struct {
+	ScheduleID uuid.UUID  `json:"schedule_id"`
+	ClassTitle string     `json:"class_title"`
+	Start      time.Time  `json:"start"`
+	End        time.Time  `json:"end"`
+	Hours      float64    `json:"hours"`
+	RoomID     *uuid.UUID `json:"room_id"`
+	Cancelled  bool       `json:"cancelled"`
+}
+
+// CourseDetail is a course with its timetable, the places left on the
+// roster and what it costs with tax
+type CourseDetail struct {
+	models.Course
+	Sessions   []CourseSession   `json:"sessions"`
+	TotalHours float64           `json:"total_hours"`
+	PlacesLeft int               `json:"places_left"`
+	Pricing    pricing.Breakdown `json:"pricing"`
+}
+
+// CourseProgress is a course enrollment with the student's certification hours
+type CourseProgress struct {
+	models.CourseEnrollment
+	HoursAttended float64 `json:"hours_attended"`
+	HoursRequired float64 `json:"hours_required"`
+	Certified     bool    `json:"certified"`
+	Balance       int64   `json:"balance"` // in cents
+}
+
+// sessionHours is the length of a session counted towards certification
+func sessionHours(o scheduling.Occurrence) float64 {
+	return o.End.Sub(o.Start).Hours()
+}
+
+// courseSessions expands a course's schedules into its sessions in order.
+// Schedules must have Class and Cancellations loaded.
+func courseSessions(schedules []models.Schedule) ([]CourseSession, float64) {
+	sessions := []CourseSession{}
+	var hours float64
+	for i := range schedules {
+		schedule := &schedules[i]
+		from, to := scheduling.Window(*schedule)
+		for _, o := range scheduling.Expand(*schedule, from, to) {
+			session := CourseSession{
+				ScheduleID: schedule.ID,
+				ClassTitle: schedule.Class.Title,
+				Start:      o.Start,
+				End:        o.End,
+				Hours:      sessionHours(o),
+				RoomID:     schedule.RoomID,
+				Cancelled:  isCancelled(schedule, o),
+			}
+			if !session.Cancelled {
+				hours += session.Hours
+			}
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+	return sessions, hours
+}
+
+// courseProgress totals the hours a student attended. The enrollment must
+// have Course and Attendance loaded.
+func courseProgress(enrollment models.CourseEnrollment) CourseProgress {
+	progress := CourseProgress{
+		CourseEnrollment: enrollment,
+		HoursRequired:    enrollment.Course.CertificationHours,
+		Balance:          enrollment.AmountDue() - enrollment.AmountPaid,
+	}
+	for _, a := range enrollment.Attendance {
+		if a.Present {
+			progress.HoursAttended += a.Hours
+		}
+	}
+	progress.Certified = progress.HoursRequired > 0 && progress.HoursAttended >= progress.HoursRequired
+	return progress
+}
+
+// courseSchedules orders a course's preloaded schedules
+func courseSchedules(db *gorm.DB) *gorm.DB {
+	return db.Order("start_time")
+}
+
+// enrolledCounts counts the enrolled students of each course
+func enrolledCounts(db *gorm.DB, courseIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		CourseID uuid.UUID
+		Count    int
+	}
+	counts := make(map[uuid.UUID]int, len(courseIDs))
+	if len(courseIDs) == 0 {
+		return counts, nil
+	}
+	err := db.Model(&models.CourseEnrollment{}).
+		Select("course_id, count(*) AS count").
+		Where("course_id IN ? AND status = ?", courseIDs, models.CourseEnrolled).
+		Group("course_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.CourseID] = row.Count
+	}
+	return counts, err
+}
+
+func courseDetail(course models.Course, enrolled int, price pricing.Breakdown) CourseDetail {
+	sessions, hours := courseSessions(course.Schedules)
+	places := course.Capacity - enrolled
+	if places < 0 {
+		places = 0
+	}
+	return CourseDetail{Course: course, Sessions: sessions, TotalHours: hours, PlacesLeft: places, Pricing: price}
+}
+
+// coursePrices prices each of courses before any discount, loading the tax
+// rule of each location once
+func coursePrices(db *gorm.DB, courses []models.Course) (map[uuid.UUID]pricing.Breakdown, error) {
+	rules := make(map[uuid.UUID]pricing.Rule) // uuid.Nil for the studio's default rule
+	prices := make(map[uuid.UUID]pricing.Breakdown, len(courses))
+	for _, course := range courses {
+		key := uuid.Nil
+		if course.LocationID != nil {
+			key = *course.LocationID
+		}
+		rule, ok := rules[key]
+		if !ok {
+			var err error
+			if rule, err = taxRule(db, course.LocationID); err != nil {
+				return nil, err
+			}
+			rules[key] = rule
+		}
+		prices[course.ID] = pricing.Compute(course.Price, course.Currency, rule)
+	}
+	return prices, nil
+}
+
+// GetAll - Public: active courses with their timetables
+func (h *CourseHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, courseSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Course{}).Where("is_active = ?", true)
+
+	var courses []models.Course
+	total, err := paginate(query, params, &courses,
+		preload("Schedules", courseSchedules), preload("Schedules.Class"), preload("Schedules.Cancellations"))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch courses", err))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(courses))
+	for i := range courses {
+		ids[i] = courses[i].ID
+	}
+	counts, err := enrolledCounts(requestDB(c, h.db), ids)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch courses", err))
+		return
+	}
+
+	prices, err := coursePrices(requestDB(c, h.db), courses)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to price courses", err))
+		return
+	}
+
+	details := make([]CourseDetail, len(courses))
+	for i := range courses {
+		details[i] = courseDetail(courses[i], counts[courses[i].ID], prices[courses[i].ID])
+	}
+
+	c.JSON(http.StatusOK, newListResponse(details, total, params))
+}
+
+// GetByID - Public: one active course with its timetable
+func (h *CourseHandler) GetByID(c *gin.Context) {
+	course, err := h.loadCourse(requestDB(c, h.db), c.Param("id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	if !course.IsActive {
+		c.Error(apperr.NotFound("Course not found"))
+		return
+	}
+	if err := requestDB(c, h.db).Preload("Class").Preload("Cancellations").Order("start_time").
+		Find(&course.Schedules, "course_id = ?", course.ID).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch course", err))
+		return
+	}
+
+	counts, err := enrolledCounts(requestDB(c, h.db), []uuid.UUID{course.ID})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch course", err))
+		return
+	}
+
+	price, err := coursePrice(requestDB(c, h.db), course, 0)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to price course", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, courseDetail(*course, counts[course.ID], price))
+}
+
+// loadCourse loads a course by ID with db, which may carry a row lock
+func (h *CourseHandler) loadCourse(db *gorm.DB, id string) (*models.Course, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.BadRequest("Invalid course ID format")
+	}
+
+	var course models.Course
+	if err := db.First(&course, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Course not found")
+		}
+		return nil, err
+	}
+	return &course, nil
+}
+
+// Create - Admin: add a course. Its sessions are added as schedules with course_id set.
+func (h *CourseHandler) Create(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input CourseRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := checkLocation(c, h.db, input.LocationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	course := models.Course{CreatedBy: adminID, IsActive: true, Currency: studioCurrency(c)}
+	input.Apply(&course)
+
+	if err := requestDB(c, h.db).Create(&course).Error; err != nil {
+		c.Error(apperr.Internal("Failed to create course", err))
+		return
+	}
+
+	log.Printf("INFO: Course created: id=%s, title=%s", course.ID, course.Title)
+	c.JSON(http.StatusCreated, course)
+}
+
+// Update - Admin: change a course. The roster is kept even if capacity drops below it.
+func (h *CourseHandler) Update(c *gin.Context) {
+	course, err := h.loadCourse(requestDB(c, h.db), c.Param("id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	var input CourseRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := checkLocation(c, h.db, input.LocationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	input.Apply(course)
+	course.Currency = studioCurrency(c)
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(course).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update course", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, course)
+}
+
+// Enroll - joins a course, or applies to it when the course requires an
+// application. Joining takes a place on the roster straight away.
+func (h *CourseHandler) Enroll(c *gin.Context) {
+	var input CourseApplicationRequest
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	userID, bookedBy, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := requireBookable(c, requestDB(c, h.db), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var enrollment models.CourseEnrollment
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the course so concurrent joins can't both take the last place
+		course, err := h.loadCourse(tx.Clauses(clause.Locking{Strength: "UPDATE"}), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		if !course.IsActive {
+			return apperr.NotFound("Course not found")
+		}
+		if !course.RegistrationOpen(time.Now()) {
+			return apperr.BadRequest("Registration for this course is closed")
+		}
+
+		var existing int64
+		if err := tx.Model(&models.CourseEnrollment{}).
+			Where("course_id = ? AND user_id = ? AND status IN ?", course.ID, userID,
+				[]models.CourseEnrollmentStatus{models.CourseApplied, models.CourseEnrolled}).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return apperr.Conflict("Already enrolled in or applied to this course")
+		}
+
+		enrollment = models.CourseEnrollment{
+			CourseID:      course.ID,
+			UserID:        userID,
+			BookedBy:      bookedBy,
+			Status:        models.CourseEnrolled,
+			Application:   input.Application,
+			PaymentStatus: models.CourseUnpaid,
+		}
+		if course.RequiresApplication {
+			if strings.TrimSpace(input.Application) == "" {
+				return apperr.Validation(apperr.FieldError{Field: "application", Message: "is required for this course"})
+			}
+			if input.GiftCardCode != "" {
+				return apperr.Validation(apperr.FieldError{Field: "gift_card_code", Message: "can be redeemed once your application is accepted"})
+			}
+			enrollment.Status = models.CourseApplied
+		} else if err := checkCoursePlace(tx, course); err != nil {
+			return err
+		}
+
+		var promo *models.PromoCode
+		if input.PromoCode != "" {
+			if promo, err = lockPromoCode(tx, input.PromoCode, userID, course, time.Now()); err != nil {
+				return err
+			}
+			enrollment.PromoCodeID = &promo.ID
+			enrollment.Discount = promo.Discount(course.Price)
+		}
+		price, err := coursePrice(tx, course, enrollment.Discount)
+		if err != nil {
+			return err
+		}
+		enrollment.Subtotal = price.Subtotal
+		enrollment.Tax = price.Tax
+		enrollment.Total = price.Total
+		enrollment.TaxLabel = price.Label()
+		enrollment.Currency = price.Currency
+
+		if err := tx.Omit(clause.Associations).Create(&enrollment).Error; err != nil {
+			return err
+		}
+		if promo != nil {
+			if err := redeemPromoCode(tx, promo, &enrollment); err != nil {
+				return err
+			}
+		}
+		if input.GiftCardCode != "" {
+			if _, err := redeemGiftCard(tx, input.GiftCardCode, course, &enrollment, time.Now()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Course enrollment created: course=%s, user=%s, status=%s", enrollment.CourseID, userID, enrollment.Status)
+	c.JSON(http.StatusCreated, enrollment)
+}
+
+// RedeemGiftCard - pays towards the current user's place on a course, or
+// that of a member of their household, from a gift card
+func (h *CourseHandler) RedeemGiftCard(c *gin.Context) {
+	courseID := c.Param("id")
+	if _, err := uuid.Parse(courseID); err != nil {
+		c.Error(apperr.BadRequest("Invalid course ID format"))
+		return
+	}
+
+	var input GiftCardRedemptionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	userID, _, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var enrollment models.CourseEnrollment
+	var payment *models.CoursePayment
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("course_id = ? AND user_id = ? AND status = ?", courseID, userID, models.CourseEnrolled).
+			First(&enrollment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Course enrollment not found")
+			}
+			return err
+		}
+		course, err := h.loadCourse(tx, courseID)
+		if err != nil {
+			return err
+		}
+		payment, err = redeemGiftCard(tx, input.Code, course, &enrollment, time.Now())
+		return err
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Gift card redeemed for course: enrollment=%s, amount=%d", enrollment.ID, payment.Amount)
+	c.JSON(http.StatusCreated, gin.H{
+		"payment":    payment,
+		"enrollment": enrollment,
+	})
+}
+
+// PayWithCredit - pays towards a household member's place on a course
+// from the household's shared credit
+func (h *CourseHandler) PayWithCredit(c *gin.Context) {
+	courseID := c.Param("id")
+	if _, err := uuid.Parse(courseID); err != nil {
+		c.Error(apperr.BadRequest("Invalid course ID format"))
+		return
+	}
+
+	var input CreditPaymentRequest
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	userID, _, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var enrollment models.CourseEnrollment
+	var payment *models.CoursePayment
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		actor, household, err := myHousehold(c, tx, true)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("course_id = ? AND user_id = ? AND status = ?", courseID, userID, models.CourseEnrolled).
+			First(&enrollment).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Course enrollment not found")
+			}
+			return err
+		}
+		course, err := h.loadCourse(tx, courseID)
+		if err != nil {
+			return err
+		}
+		payment, err = redeemCredit(tx, household, course, &enrollment, actor.ID, time.Now())
+		return err
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Household credit spent on course: enrollment=%s, amount=%d", enrollment.ID, payment.Amount)
+	c.JSON(http.StatusCreated, gin.H{
+		"payment":    payment,
+		"enrollment": enrollment,
+	})
+}
+
+// checkCoursePlace fails with 409 when the course roster is full
+func checkCoursePlace(tx *gorm.DB, course *models.Course) error {
+	counts, err := enrolledCounts(tx, []uuid.UUID{course.ID})
+	if err != nil {
+		return err
+	}
+	if counts[course.ID] >= course.Capacity {
+		return apperr.Conflict("Course is full")
+	}
+	return nil
+}
+
+// Withdraw - leaves a course or withdraws an application, for the current
+// user or, with user_id, a member of their household. Payments already
+// made are kept on the enrollment; refunds are handled by the studio.
+func (h *CourseHandler) Withdraw(c *gin.Context) {
+	courseID := c.Param("id")
+	if _, err := uuid.Parse(courseID); err != nil {
+		c.Error(apperr.BadRequest("Invalid course ID format"))
+		return
+	}
+	userID, err := memberParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var enrollment models.CourseEnrollment
+	if err := requestDB(c, h.db).
+		Where("course_id = ? AND user_id = ? AND status IN ?", courseID, userID,
+			[]models.CourseEnrollmentStatus{models.CourseApplied, models.CourseEnrolled}).
+		First(&enrollment).Error; err != nil {
+		c.Error(apperr.NotFound("Course enrollment not found"))
+		return
+	}
+
+	now := time.Now()
+	enrollment.Status = models.CourseWithdrawn
+	enrollment.WithdrawnAt = &now
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(&enrollment).Error; err != nil {
+		c.Error(apperr.Internal("Failed to withdraw from course", err))
+		return
+	}
+
+	log.Printf("INFO: Course enrollment withdrawn: course=%s, user=%s", enrollment.CourseID, enrollment.UserID)
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// GetMine - the current user's courses with attendance and certification
+// hours, or with user_id those of a member of their household
+func (h *CourseHandler) GetMine(c *gin.Context) {
+	params, err := parseListParams(c, courseEnrollmentSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	userID, err := memberParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.CourseEnrollment{}).Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var enrollments []models.CourseEnrollment
+	total, err := paginate(query, params, &enrollments,
+		preload("Course"), preload("Attendance", courseAttendanceOrder), preload("Payments", coursePaymentOrder))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch courses", err))
+		return
+	}
+
+	progress := make([]CourseProgress, len(enrollments))
+	for i := range enrollments {
+		progress[i] = courseProgress(enrollments[i])
+	}
+
+	c.JSON(http.StatusOK, newListResponse(progress, total, params))
+}
+
+func courseAttendanceOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("occurrence_start")
+}
+
+func coursePaymentOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("paid_at")
+}
+
+// studentSummary limits a preloaded student to what a roster shows
+func studentSummary(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "name", "email", "avatar_url")
+}
+
+// GetRoster - Admin: a course's applications and enrollments with payments and hours
+func (h *CourseHandler) GetRoster(c *gin.Context) {
+	course, err := h.loadCourse(requestDB(c, h.db), c.Param("id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	params, err := parseListParams(c, courseEnrollmentSortKeys, "created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.CourseEnrollment{}).Where("course_id = ?", course.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var enrollments []models.CourseEnrollment
+	total, err := paginate(query, params, &enrollments,
+		preload("User", studentSummary), preload("Attendance", courseAttendanceOrder), preload("Payments", coursePaymentOrder))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch course roster", err))
+		return
+	}
+
+	progress := make([]CourseProgress, len(enrollments))
+	for i := range enrollments {
+		enrollments[i].Course = course
+		progress[i] = courseProgress(enrollments[i])
+		progress[i].Course = nil
+	}
+
+	c.JSON(http.StatusOK, newListResponse(progress, total, params))
+}
+
+// lockCourseEnrollment loads an enrollment of courseID for update inside tx
+func lockCourseEnrollment(tx *gorm.DB, courseID uuid.UUID, id string) (*models.CourseEnrollment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.BadRequest("Invalid enrollment ID format")
+	}
+
+	var enrollment models.CourseEnrollment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&enrollment, "id = ? AND course_id = ?", id, courseID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Course enrollment not found")
+		}
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// Decide - Admin: accept or reject an application, or withdraw a student.
+// The student is notified of the decision.
+func (h *CourseHandler) Decide(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input CourseDecisionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var enrollment *models.CourseEnrollment
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		course, err := h.loadCourse(tx.Clauses(clause.Locking{Strength: "UPDATE"}), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		enrollment, err = lockCourseEnrollment(tx, course.ID, c.Param("enrollmentId"))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch input.Status {
+		case models.CourseEnrolled, models.CourseRejected:
+			if enrollment.Status != models.CourseApplied {
+				return apperr.Conflict(fmt.Sprintf("Only applications can be decided; this enrollment is %s", enrollment.Status))
+			}
+			if input.Status == models.CourseEnrolled {
+				if err := checkCoursePlace(tx, course); err != nil {
+					return err
+				}
+			}
+		case models.CourseWithdrawn:
+			if enrollment.Status != models.CourseApplied && enrollment.Status != models.CourseEnrolled {
+				return apperr.Conflict(fmt.Sprintf("Cannot withdraw an enrollment that is %s", enrollment.Status))
+			}
+			enrollment.WithdrawnAt = &now
+		}
+
+		enrollment.Status = input.Status
+		enrollment.DecisionNote = input.Note
+		enrollment.DecidedBy = &adminID
+		enrollment.DecidedAt = &now
+		if err := tx.Omit(clause.Associations).Save(enrollment).Error; err != nil {
+			return err
+		}
+
+		message := courseDecisionMessage(course, input.Status)
+		if input.Note != "" {
+			message += ": " + input.Note
+		}
+		return notifyUsers(tx, []uuid.UUID{enrollment.UserID}, models.NotificationCourse, course.Title, message)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Course enrollment %s: enrollment=%s, by=%s", input.Status, enrollment.ID, adminID)
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func courseDecisionMessage(course *models.Course, status models.CourseEnrollmentStatus) string {
+	switch status {
+	case models.CourseEnrolled:
+		if course.Deposit > 0 {
+			return fmt.Sprintf("Your application to %s has been accepted. A deposit of %s secures your place", course.Title, pricing.Format(course.Deposit, course.Currency))
+		}
+		return fmt.Sprintf("Your application to %s has been accepted", course.Title)
+	case models.CourseRejected:
+		return fmt.Sprintf("Your application to %s was not accepted", course.Title)
+	default:
+		return fmt.Sprintf("You have been withdrawn from %s", course.Title)
+	}
+}
+
+// RecordPayment - Admin: record a deposit or installment received for a course enrollment
+func (h *CourseHandler) RecordPayment(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input CoursePaymentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var enrollment *models.CourseEnrollment
+	payment := models.CoursePayment{Amount: input.Amount, Note: input.Note, Reference: input.Reference, RecordedBy: adminID}
+	if input.PaidAt != nil {
+		payment.PaidAt = *input.PaidAt
+	}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		course, err := h.loadCourse(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		enrollment, err = lockCourseEnrollment(tx, course.ID, c.Param("enrollmentId"))
+		if err != nil {
+			return err
+		}
+		if enrollment.Status != models.CourseApplied && enrollment.Status != models.CourseEnrolled {
+			return apperr.Conflict(fmt.Sprintf("Cannot record a payment for an enrollment that is %s", enrollment.Status))
+		}
+		if balance := enrollment.AmountDue() - enrollment.AmountPaid; input.Amount > balance {
+			return apperr.Validation(apperr.FieldError{Field: "amount", Message: fmt.Sprintf("must not exceed the balance of %s", pricing.Format(balance, enrollment.Currency))})
+		}
+
+		payment.CourseEnrollmentID = enrollment.ID
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		paidBefore := enrollment.AmountPaid
+		enrollment.RecordPayment(course, input.Amount)
+		if err := tx.Omit(clause.Associations).Save(enrollment).Error; err != nil {
+			return err
+		}
+		if err := issueReceipt(tx, &payment, enrollment, course); err != nil {
+			return err
+		}
+		return postLedgerWithTax(tx, models.LedgerEntry{
+			Kind:               models.LedgerCharge,
+			DebitAccount:       models.AccountCash,
+			CreditAccount:      models.AccountCourseRevenue,
+			Amount:             payment.Amount,
+			Currency:           enrollment.Currency,
+			UserID:             &enrollment.UserID,
+			CourseEnrollmentID: &enrollment.ID,
+			CoursePaymentID:    &payment.ID,
+			Reference:          payment.Reference,
+			Description:        "Payment for " + course.Title,
+			RecordedBy:         adminID,
+		}, paymentTax(enrollment, paidBefore, enrollment.AmountPaid))
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Course payment recorded: enrollment=%s, amount=%d, status=%s", enrollment.ID, input.Amount, enrollment.PaymentStatus)
+	c.JSON(http.StatusCreated, gin.H{
+		"payment":    payment,
+		"enrollment": enrollment,
+	})
+}
+
+// RefundPayment - Admin: give back part or all of a course payment. Gift card
+// and household credit payments are returned to where they came from; other
+// refunds are made through the payment provider and recorded here with its
+// refund reference.
+func (h *CourseHandler) RefundPayment(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+	if _, err := uuid.Parse(c.Param("paymentId")); err != nil {
+		c.Error(apperr.BadRequest("Invalid payment ID format"))
+		return
+	}
+
+	var input RefundRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var enrollment *models.CourseEnrollment
+	var payment models.CoursePayment
+	refund := models.Refund{Amount: input.Amount, Reason: input.Reason, Reference: input.Reference, RecordedBy: adminID}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		course, err := h.loadCourse(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		enrollment, err = lockCourseEnrollment(tx, course.ID, c.Param("enrollmentId"))
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&payment, "id = ? AND course_enrollment_id = ?", c.Param("paymentId"), enrollment.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Payment not found")
+			}
+			return err
+		}
+		if remaining := payment.Amount - payment.Refunded; input.Amount > remaining {
+			return apperr.Validation(apperr.FieldError{Field: "amount", Message: fmt.Sprintf("must not exceed the %s left to refund", pricing.Format(remaining, enrollment.Currency))})
+		}
+
+		refund.CoursePaymentID = payment.ID
+		refund.CourseEnrollmentID = enrollment.ID
+		refund.GiftCardID = payment.GiftCardID
+		refund.HouseholdID = payment.HouseholdID
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+		payment.Refunded += input.Amount
+		if err := tx.Model(&payment).UpdateColumn("refunded", payment.Refunded).Error; err != nil {
+			return err
+		}
+		paidBefore := enrollment.AmountPaid
+		enrollment.RecordRefund(course, input.Amount)
+		if err := tx.Omit(clause.Associations).Save(enrollment).Error; err != nil {
+			return err
+		}
+
+		entry := models.LedgerEntry{
+			Kind:               models.LedgerRefund,
+			DebitAccount:       models.AccountRefunds,
+			CreditAccount:      models.AccountCash,
+			Amount:             input.Amount,
+			Currency:           enrollment.Currency,
+			UserID:             &enrollment.UserID,
+			CourseEnrollmentID: &enrollment.ID,
+			CoursePaymentID:    &payment.ID,
+			RefundID:           &refund.ID,
+			Reference:          input.Reference,
+			Description:        "Refund for " + course.Title + ": " + input.Reason,
+			RecordedBy:         adminID,
+		}
+		if payment.GiftCardID != nil {
+			if err := tx.Model(&models.GiftCard{}).Where("id = ?", *payment.GiftCardID).
+				UpdateColumn("balance", gorm.Expr("balance + ?", input.Amount)).Error; err != nil {
+				return err
+			}
+			entry.Kind = models.LedgerGiftCardCredit
+			entry.CreditAccount = models.AccountGiftCardLiability
+			entry.GiftCardID = payment.GiftCardID
+		}
+		if payment.HouseholdID != nil {
+			if err := refundCredit(tx, *payment.HouseholdID, enrollment, &payment, input.Amount, adminID); err != nil {
+				return err
+			}
+			entry.Kind = models.LedgerCreditRefund
+			entry.CreditAccount = models.AccountHouseholdCredit
+			entry.HouseholdID = payment.HouseholdID
+		}
+		tax := paymentTax(enrollment, paidBefore, enrollment.AmountPaid)
+		if err := postLedgerWithTax(tx, entry, tax); err != nil {
+			return err
+		}
+		if err := issueCreditNote(tx, &refund, tax, enrollment, course); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("A refund of %s for %s has been issued", pricing.Format(input.Amount, enrollment.Currency), course.Title)
+		if payment.GiftCardID != nil {
+			message += " to your gift card"
+		}
+		if payment.HouseholdID != nil {
+			message += " to your household's credit"
+		}
+		return notifyUsers(tx, []uuid.UUID{enrollment.UserID}, models.NotificationCourse, course.Title, message)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Course payment refunded: payment=%s, amount=%d, by=%s", payment.ID, input.Amount, adminID)
+	c.JSON(http.StatusCreated, gin.H{
+		"refund":     refund,
+		"payment":    payment,
+		"enrollment": enrollment,
+	})
+}
+
+// RecordAttendance - Admin: take the register for one session of a course.
+// Marks are upserted, so a register can be corrected by sending it again.
+func (h *CourseHandler) RecordAttendance(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input AttendanceRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	course, err := h.loadCourse(requestDB(c, h.db), c.Param("id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).Preload("Cancellations").
+		First(&schedule, "id = ? AND course_id = ?", input.ScheduleID, course.ID).Error; err != nil {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "schedule_id", Message: "is not a session of this course"}))
+		return
+	}
+	occurrence, ok := scheduling.OccurrenceAt(schedule, input.OccurrenceStart)
+	if !ok {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "is not an occurrence of this schedule"}))
+		return
+	}
+	if isCancelled(&schedule, occurrence) {
+		c.Error(apperr.Conflict("This session was cancelled"))
+		return
+	}
+	if occurrence.Start.After(time.Now()) {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "has not started yet"}))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(input.Attendees))
+	for i, mark := range input.Attendees {
+		ids[i] = mark.EnrollmentID
+	}
+	var enrolled int64
+	if err := requestDB(c, h.db).Model(&models.CourseEnrollment{}).
+		Where("id IN ? AND course_id = ? AND status = ?", ids, course.ID, models.CourseEnrolled).
+		Count(&enrolled).Error; err != nil {
+		c.Error(apperr.Internal("Failed to record attendance", err))
+		return
+	}
+	if int(enrolled) != len(distinctIDs(ids)) {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "attendees", Message: "must only list students enrolled on this course"}))
+		return
+	}
+
+	register := make([]models.CourseAttendance, len(input.Attendees))
+	for i, mark := range input.Attendees {
+		register[i] = models.CourseAttendance{
+			CourseEnrollmentID: mark.EnrollmentID,
+			ScheduleID:         schedule.ID,
+			OccurrenceStart:    occurrence.Start,
+			Present:            mark.Present,
+			Hours:              sessionHours(occurrence),
+			RecordedBy:         adminID,
+		}
+	}
+	if err := requestDB(c, h.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "course_enrollment_id"}, {Name: "schedule_id"}, {Name: "occurrence_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"present", "hours", "recorded_by", "updated_at"}),
+	}).Create(&register).Error; err != nil {
+		c.Error(apperr.Internal("Failed to record attendance", err))
+		return
+	}
+
+	log.Printf("INFO: Course attendance recorded: course=%s, schedule=%s, occurrence=%s, students=%d",
+		course.ID, schedule.ID, occurrence.Start.Format(time.RFC3339), len(register))
+	c.JSON(http.StatusOK, gin.H{
+		"schedule_id":      schedule.ID,
+		"occurrence_start": occurrence.Start,
+		"attendance":       register,
+	})
+}
+
+// distinctIDs drops repeated IDs
+func distinctIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	var distinct []uuid.UUID
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+	return distinct
+}
+
+// ============ Private Session Handler ============
+
+// slotStep is the spacing of private session start times within a window
+const slotStep = 15 * time.Minute
+
+// maxSlotRange bounds GET /private-sessions/slots
+const maxSlotRange = 31 * 24 * time.Hour
+
+type PrivateSessionHandler struct {
+	db *gorm.DB
+}
+
+func NewPrivateSessionHandler(db *gorm.DB) *PrivateSessionHandler {
+	return &PrivateSessionHandler{db: db}
+}
+
+// privatePolicy returns the current studio's buffer around an instructor's
+// sessions and minimum notice for private bookings
+func privatePolicy(c *gin.Context) (buffer, notice time.Duration) {
+	studio := middleware.CurrentStudio(c)
+	if studio == nil {
+		return 15 * time.Minute, 24 * time.Hour
+	}
+	return time.Duration(studio.PrivateBufferMinutes) * time.Minute, time.Duration(studio.PrivateNoticeHours) * time.Hour
+}
+
+// availabilityOwner resolves whose availability a request manages: the
+// current instructor, or any instructor when an admin names one
+func availabilityOwner(c *gin.Context, db *gorm.DB, requested *uuid.UUID) (uuid.UUID, error) {
+	actor, err := currentUser(c, db)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if requested != nil && *requested != actor.ID {
+		if !actor.IsAdmin() {
+			return uuid.Nil, apperr.Forbidden("Only admins can manage another instructor's availability")
+		}
+		instructor, err := loadInstructor(c, db, requested, "instructor_id")
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return instructor.ID, nil
+	}
+	if !actor.IsInstructor {
+		return uuid.Nil, apperr.Forbidden("Only instructors can publish availability")
+	}
+	return actor.ID, nil
+}
+
+// loadAvailability loads an instructor's weekly windows and the exceptions overlapping from..to
+func loadAvailability(db *gorm.DB, instructorID uuid.UUID, from, to time.Time) ([]models.AvailabilityWindow, []models.AvailabilityException, error) {
+	var windows []models.AvailabilityWindow
+	if err := db.Where("instructor_id = ?", instructorID).Order("day_of_week, opens").Find(&windows).Error; err != nil {
+		return nil, nil, err
+	}
+	var exceptions []models.AvailabilityException
+	if err := db.Where("instructor_id = ? AND starts_at < ? AND ends_at > ?", instructorID, to, from).
+		Order("starts_at").Find(&exceptions).Error; err != nil {
+		return nil, nil, err
+	}
+	return windows, exceptions, nil
+}
+
+// instructorBusy loads everything that can keep instructorID from teaching
+// between from and to, and returns a check for whether a session would clash
+// with it: another session within buffer, or a studio-wide closure
+func instructorBusy(c *gin.Context, db *gorm.DB, instructorID uuid.UUID, from, to time.Time, buffer time.Duration) (func(scheduling.Occurrence) bool, error) {
+	schedules, err := loadSchedulesInWindow(c, db, from.Add(-buffer), to.Add(buffer), preload("Cancellations"))
+	if err != nil {
+		return nil, err
+	}
+
+	var substitutions []models.Substitution
+	if err := requestDB(c, db).
+		Where("status = ? AND occurrence_start < ? AND occurrence_end > ?", models.SubstitutionApproved, to.Add(buffer), from.Add(-buffer)).
+		Find(&substitutions).Error; err != nil {
+		return nil, err
+	}
+
+	var closures []models.Closure
+	if err := requestDB(c, db).Scopes(closuresFor(nil)).Where("starts_at < ? AND ends_at > ?", to, from).Find(&closures).Error; err != nil {
+		return nil, err
+	}
+
+	return func(o scheduling.Occurrence) bool {
+		padded := scheduling.Occurrence{Start: o.Start.Add(-buffer), End: o.End.Add(buffer)}
+		return closed(closures, nil, o) || scheduling.InstructorBusy(instructorID, padded, schedules, substitutions)
+	}, nil
+}
+
+// GetAvailability - Public: an instructor's weekly windows and upcoming exceptions
+func (h *PrivateSessionHandler) GetAvailability(c *gin.Context) {
+	instructorID, err := uuid.Parse(c.Query("instructor_id"))
+	if err != nil {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "instructor_id", Message: "must be a valid UUID"}))
+		return
+	}
+	if _, err := loadInstructor(c, h.db, &instructorID, "instructor_id"); err != nil {
+		c.Error(err)
+		return
+	}
+
+	now := time.Now()
+	windows, exceptions, err := loadAvailability(requestDB(c, h.db), instructorID, now, now.Add(maxSlotRange))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch availability", err))
+		return
+	}
+	if windows == nil {
+		windows = []models.AvailabilityWindow{}
+	}
+	if exceptions == nil {
+		exceptions = []models.AvailabilityException{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instructor_id": instructorID,
+		"timezone":      studioZone(c).String(),
+		"windows":       windows,
+		"exceptions":    exceptions,
+	})
+}
+
+// SetAvailability - replaces an instructor's weekly availability windows
+func (h *PrivateSessionHandler) SetAvailability(c *gin.Context) {
+	var input AvailabilityRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	instructorID, err := availabilityOwner(c, h.db, input.InstructorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	windows := input.Build(instructorID)
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("instructor_id = ?", instructorID).Delete(&models.AvailabilityWindow{}).Error; err != nil {
+			return err
+		}
+		if len(windows) == 0 {
+			return nil
+		}
+		return tx.Create(&windows).Error
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to update availability", err))
+		return
+	}
+
+	log.Printf("INFO: Availability updated: instructor=%s, windows=%d", instructorID, len(windows))
+	c.JSON(http.StatusOK, gin.H{
+		"instructor_id": instructorID,
+		"windows":       windows,
+	})
+}
+
+// AddException - blocks out time, or adds time outside the weekly windows
+func (h *PrivateSessionHandler) AddException(c *gin.Context) {
+	var input AvailabilityExceptionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	instructorID, err := availabilityOwner(c, h.db, input.InstructorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	exception := models.AvailabilityException{
+		InstructorID: instructorID,
+		StartsAt:     input.StartsAt,
+		EndsAt:       input.EndsAt,
+		Available:    input.Available,
+		Reason:       input.Reason,
+	}
+	if err := requestDB(c, h.db).Create(&exception).Error; err != nil {
+		c.Error(apperr.Internal("Failed to add availability exception", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, exception)
+}
+
+// DeleteException - removes an availability exception
+func (h *PrivateSessionHandler) DeleteException(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid exception ID format"))
+		return
+	}
+
+	var exception models.AvailabilityException
+	if err := requestDB(c, h.db).First(&exception, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Availability exception not found"))
+		return
+	}
+	if _, err := availabilityOwner(c, h.db, &exception.InstructorID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := requestDB(c, h.db).Delete(&exception).Error; err != nil {
+		c.Error(apperr.Internal("Failed to delete availability exception", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Availability exception deleted successfully"})
+}
+
+// GetSlots - Public: open start times for a private session of the given
+// duration with an instructor, between start_date and end_date
+func (h *PrivateSessionHandler) GetSlots(c *gin.Context) {
+	instructorID, err := uuid.Parse(c.Query("instructor_id"))
+	if err != nil {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "instructor_id", Message: "must be a valid UUID"}))
+		return
+	}
+	if _, err := loadInstructor(c, h.db, &instructorID, "instructor_id"); err != nil {
+		c.Error(err)
+		return
+	}
+
+	minutes := 60
+	if raw := c.Query("duration"); raw != "" {
+		minutes, err = strconv.Atoi(raw)
+		if err != nil || minutes < 15 || minutes > 480 {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "duration", Message: "must be between 15 and 480 minutes"}))
+			return
+		}
+	}
+	duration := time.Duration(minutes) * time.Minute
+
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	now := time.Now()
+	from := now.In(studioZone(c))
+	if start != nil {
+		from = *start
+	}
+	to := from.Add(14 * 24 * time.Hour)
+	if end != nil {
+		to = *end
+	}
+	if !to.After(from) {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "end_date", Message: "must be after start_date"}))
+		return
+	}
+	if to.Sub(from) > maxSlotRange {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "end_date", Message: "must be within 31 days of start_date"}))
+		return
+	}
+
+	buffer, notice := privatePolicy(c)
+	slots, err := h.openSlots(c, requestDB(c, h.db), instructorID, from, to, duration, buffer, now.Add(notice))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to find open slots", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instructor_id": instructorID,
+		"duration":      minutes,
+		"from":          from,
+		"to":            to,
+		"slots":         slots,
+	})
+}
+
+// openSlots lists the sessions of length duration instructorID could teach
+// between from and to, starting no earlier than earliest
+func (h *PrivateSessionHandler) openSlots(c *gin.Context, db *gorm.DB, instructorID uuid.UUID, from, to time.Time, duration, buffer time.Duration, earliest time.Time) ([]scheduling.Occurrence, error) {
+	windows, exceptions, err := loadAvailability(db, instructorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := instructorBusy(c, db, instructorID, from, to.Add(duration), buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if earliest.Before(from) {
+		earliest = from
+	}
+	periods := scheduling.AvailablePeriods(windows, exceptions, studioZone(c), from, to.Add(duration))
+	slots := scheduling.OpenSlots(periods, duration, slotStep, earliest, busy)
+
+	// Slots must start within the range asked for
+	for i, slot := range slots {
+		if !slot.Start.Before(to) {
+			return slots[:i], nil
+		}
+	}
+	return slots, nil
+}
+
+// Book - books a private or semi-private session in an open slot. The
+// session is created as a one-off schedule with its own capacity, and the
+// client's enrollment with it, so it appears wherever other classes do.
+func (h *PrivateSessionHandler) Book(c *gin.Context) {
+	var input PrivateSessionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if input.Capacity == 0 {
+		input.Capacity = 1
+	}
+
+	// The client may be a member of the current user's household
+	userID, bookedBy, err := bookingFor(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var client models.User
+	if err := requestDB(c, h.db).Select("id", "name").First(&client, "id = ?", userID).Error; err != nil {
+		c.Error(apperr.Internal("Failed to load client", err))
+		return
+	}
+	createdBy := userID
+	if bookedBy != nil {
+		createdBy = *bookedBy
+	}
+	if err := requireBookable(c, requestDB(c, h.db), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	instructor, err := loadInstructor(c, h.db, &input.InstructorID, "instructor_id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var class models.Class
+	if err := requestDB(c, h.db).First(&class, "id = ? AND is_active = ?", input.ClassID, true).Error; err != nil {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "class_id", Message: "does not exist or is inactive"}))
+		return
+	}
+
+	zone := studioZone(c)
+	now := time.Now()
+	buffer, notice := privatePolicy(c)
+	duration := time.Duration(input.Duration) * time.Minute
+	requested := scheduling.Occurrence{Start: input.StartTime.In(zone), End: input.StartTime.Add(duration).In(zone)}
+	if requested.Start.Before(now.Add(notice)) {
+		c.Error(apperr.Validation(apperr.FieldError{
+			Field:   "start_time",
+			Message: fmt.Sprintf("must be at least %d hours from now", int(notice.Hours())),
+		}))
+		return
+	}
+
+	schedule := models.Schedule{
+		ClassID:         class.ID,
+		StartTime:       requested.Start,
+		EndTime:         requested.End,
+		RecurrenceType:  models.Once,
+		InstructorID:    &instructor.ID,
+		PrivateCapacity: input.Capacity,
+		Timezone:        scheduleTimezone(c, nil),
+		CreatedBy:       createdBy,
+	}
+	if schedule.PrivateCapacity > 1 {
+		// The client passes this on to whoever shares the session
+		if schedule.InviteCode, err = newPrivateInviteCode(); err != nil {
+			c.Error(apperr.Internal("Failed to create invite code", err))
+			return
+		}
+	}
+	enrollment := models.Enrollment{
+		UserID:        userID,
+		BookedBy:      bookedBy,
+		PaymentStatus: models.PaymentCompleted, // Free for now
+		Status:        models.EnrollmentActive,
+	}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the instructor so two clients can't book the same slot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, "id = ?", instructor.ID).Error; err != nil {
+			return err
+		}
+
+		// The requested time must be one of the open slots, which rechecks
+		// availability, buffers and closures under the lock
+		dayStart := requested.Start.Add(-24 * time.Hour)
+		slots, err := h.openSlots(c, tx, instructor.ID, dayStart, requested.End, duration, buffer, requested.Start)
+		if err != nil {
+			return err
+		}
+		open := false
+		for _, slot := range slots {
+			if slot.Start.Equal(requested.Start) {
+				open = true
+				break
+			}
+		}
+		if !open {
+			return apperr.Conflict("This time is not available; choose one of the open slots")
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&schedule).Error; err != nil {
+			return err
+		}
+		enrollment.ScheduleID = schedule.ID
+		if err := tx.Omit(clause.Associations).Create(&enrollment).Error; err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("%s booked a private %s on %s", client.Name, class.Title, requested.Start.Format(notificationTimeFormat))
+		return notifyUsers(tx, []uuid.UUID{instructor.ID}, models.NotificationPrivateSession, "Private session booked", message)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	metrics.EnrollmentsCreated.Inc()
+
+	log.Printf("INFO: Private session booked: schedule=%s, instructor=%s, user=%s", schedule.ID, instructor.ID, userID)
+	response := gin.H{
+		"schedule":   schedule,
+		"enrollment": enrollment,
+	}
+	if schedule.InviteCode != "" {
+		response["invite_code"] = schedule.InviteCode
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// Join - takes the open place on a semi-private session with the invite
+// code its booker shared. The code stays valid until the session starts, so
+// a place freed by a cancellation can be taken again.
+func (h *PrivateSessionHandler) Join(c *gin.Context) {
+	var input JoinPrivateSessionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var schedule models.Schedule
+	var enrollment models.Enrollment
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		client, err := currentUser(c, tx)
+		if err != nil {
+			return err
+		}
+
+		// Lock the session so two clients can't take the same place
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("invite_code = ? AND private_capacity > 0", strings.ToUpper(strings.TrimSpace(input.Code))).
+			First(&schedule).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.Validation(apperr.FieldError{Field: "code", Message: "is not a valid invite code"})
+			}
+			return err
+		}
+		if !schedule.StartTime.After(time.Now()) {
+			return apperr.Validation(apperr.FieldError{Field: "code", Message: "has expired"})
+		}
+		if err := tx.Preload("Class").Preload("Cancellations").Preload("Enrollments", activeEnrollments).
+			First(&schedule, "id = ?", schedule.ID).Error; err != nil {
+			return err
+		}
+		if len(schedule.Cancellations) > 0 {
+			return apperr.BadRequest("This session has been cancelled")
+		}
+		for _, e := range schedule.Enrollments {
+			if e.UserID == client.ID {
+				return apperr.BadRequest("Already booked on this session")
+			}
+		}
+		if len(schedule.Enrollments) >= schedule.Capacity() {
+			return apperr.BadRequest("This session is full")
+		}
+
+		enrollment = models.Enrollment{
+			UserID:        client.ID,
+			ScheduleID:    schedule.ID,
+			PaymentStatus: models.PaymentCompleted, // Free for now
+			Status:        models.EnrollmentActive,
+		}
+		if err := tx.Omit(clause.Associations).Create(&enrollment).Error; err != nil {
+			return err
+		}
+
+		recipients := []uuid.UUID{}
+		if schedule.InstructorID != nil {
+			recipients = append(recipients, *schedule.InstructorID)
+		}
+		for _, e := range schedule.Enrollments {
+			recipients = append(recipients, e.UserID)
+		}
+		message := fmt.Sprintf("%s joined the private %s on %s", client.Name, schedule.Class.Title, schedule.StartTime.Format(notificationTimeFormat))
+		return notifyUsers(tx, recipients, models.NotificationPrivateSession, "Private session joined", message)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	metrics.EnrollmentsCreated.Inc()
+
+	log.Printf("INFO: Private session joined: schedule=%s, user=%s", schedule.ID, enrollment.UserID)
+	c.JSON(http.StatusCreated, enrollment)
+}
+
+// GetByID - a private session, for its instructor, the clients booked on it
+// and admins. Participants also get the invite code while a place is open.
+func (h *PrivateSessionHandler) GetByID(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).
+		Preload("Class").Preload("Instructor", instructorSummary).Preload("Cancellations").
+		Preload("Enrollments", activeEnrollments).Preload("Enrollments.User", instructorSummary).
+		First(&schedule, "id = ? AND private_capacity > 0", c.Param("id")).Error; err != nil {
+		c.Error(apperr.NotFound("Private session not found"))
+		return
+	}
+
+	participant := schedule.InstructorID != nil && *schedule.InstructorID == actor.ID
+	for _, e := range schedule.Enrollments {
+		if e.UserID == actor.ID {
+			participant = true
+		}
+	}
+	if !participant && !actor.IsAdmin() {
+		// Don't reveal that the session exists
+		c.Error(apperr.NotFound("Private session not found"))
+		return
+	}
+
+	response := gin.H{"schedule": schedule}
+	if schedule.InviteCode != "" && len(schedule.Enrollments) < schedule.Capacity() {
+		response["invite_code"] = schedule.InviteCode
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// newPrivateInviteCode generates a random code for sharing a semi-private session
+func newPrivateInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(buf)), nil
+}
+
+// GetMine - upcoming private sessions the current user teaches or has booked
+func (h *PrivateSessionHandler) GetMine(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	booked := requestDB(c, h.db).Model(&models.Enrollment{}).Select("schedule_id").
+		Where("user_id = ? AND status = ?", userID, models.EnrollmentActive)
+	var schedules []models.Schedule
+	if err := requestDB(c, h.db).
+		Preload("Class").Preload("Instructor", instructorSummary).Preload("Cancellations").
+		Preload("Enrollments", activeEnrollments).Preload("Enrollments.User", instructorSummary).
+		Where("private_capacity > 0 AND end_time > ?", time.Now()).
+		Where("instructor_id = ? OR id IN (?)", userID, booked).
+		Order("start_time").
+		Find(&schedules).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch private sessions", err))
+		return
+	}
+
+	if schedules == nil {
+		schedules = []models.Schedule{}
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// ============ Promotion Handler ============
+var promoCodeSortKeys = sortKeys{
+	"created_at":       "created_at",
+	"code":             "code",
+	"redemption_count": "redemption_count",
+}
+
+var giftCardSortKeys = sortKeys{
+	"created_at": "created_at",
+	"balance":    "balance",
+}
+
+var redemptionSortKeys = sortKeys{
+	"created_at": "created_at",
+	"amount":     "amount",
+}
+
+// giftCardAlphabet leaves out characters that are easily misread (0/O, 1/I/L)
+const giftCardAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// giftCardCodeLength gives about 80 bits of randomness, too many to guess
+const giftCardCodeLength = 16
+
+type PromotionHandler struct {
+	db *gorm.DB
+}
+
+func NewPromotionHandler(db *gorm.DB) *PromotionHandler {
+	return &PromotionHandler{db: db}
+}
+
+// newGiftCardCode generates a random gift card code
+func newGiftCardCode() (string, error) {
+	buf := make([]byte, giftCardCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = giftCardAlphabet[int(b)%len(giftCardAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// normalizeGiftCardCode accepts codes typed in any case with spaces or dashes
+func normalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// lockPromoCode loads a promo code for update inside tx and checks that
+// userID may use it for courseID at now
+func lockPromoCode(tx *gorm.DB, code string, userID uuid.UUID, course *models.Course, now time.Time) (*models.PromoCode, error) {
+	invalid := func(message string) error {
+		return apperr.Validation(apperr.FieldError{Field: "promo_code", Message: message})
+	}
+
+	var promo models.PromoCode
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&promo, "code = ?", strings.ToUpper(strings.TrimSpace(code))).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid("is not a valid code")
+		}
+		return nil, err
+	}
+	if !promo.ValidAt(now) {
+		return nil, invalid("is not valid at this time")
+	}
+	if !promo.AppliesTo(course.ID) {
+		return nil, invalid("does not apply to this course")
+	}
+	if promo.DiscountType == models.DiscountFixed && promo.Currency != course.Currency {
+		return nil, invalid("is in a different currency from this course")
+	}
+	if promo.Exhausted() {
+		return nil, invalid("has been fully redeemed")
+	}
+	if promo.PerUserLimit != nil {
+		var used int64
+		if err := tx.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND user_id = ?", promo.ID, userID).
+			Count(&used).Error; err != nil {
+			return nil, err
+		}
+		if int(used) >= *promo.PerUserLimit {
+			return nil, invalid("has already been used the maximum number of times")
+		}
+	}
+	return &promo, nil
+}
+
+// redeemPromoCode records the use of a locked promo code for enrollment
+func redeemPromoCode(tx *gorm.DB, promo *models.PromoCode, enrollment *models.CourseEnrollment) error {
+	if err := tx.Create(&models.PromoRedemption{
+		PromoCodeID:        promo.ID,
+		UserID:             enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		Amount:             enrollment.Discount,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(promo).UpdateColumn("redemption_count", gorm.Expr("redemption_count + 1")).Error
+}
+
+// redeemGiftCard pays as much of enrollment's balance as the gift card
+// covers, inside tx. enrollment should be locked or newly created.
+func redeemGiftCard(tx *gorm.DB, code string, course *models.Course, enrollment *models.CourseEnrollment, now time.Time) (*models.CoursePayment, error) {
+	invalid := func(message string) error {
+		return apperr.Validation(apperr.FieldError{Field: "gift_card_code", Message: message})
+	}
+
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&card, "code = ?", normalizeGiftCardCode(code)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid("is not a valid gift card")
+		}
+		return nil, err
+	}
+	if !card.UsableAt(now) {
+		return nil, invalid("has no balance or has expired")
+	}
+	if card.Currency != enrollment.Currency {
+		return nil, invalid("is in a different currency from this course")
+	}
+
+	amount := enrollment.AmountDue() - enrollment.AmountPaid
+	if amount <= 0 {
+		return nil, apperr.Conflict("Nothing is left to pay for this course")
+	}
+	if amount > card.Balance {
+		amount = card.Balance
+	}
+
+	payment := models.CoursePayment{
+		CourseEnrollmentID: enrollment.ID,
+		Amount:             amount,
+		Note:               "Gift card ending " + card.Code[len(card.Code)-4:],
+		GiftCardID:         &card.ID,
+		RecordedBy:         enrollment.UserID,
+		PaidAt:             now,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+	paidBefore := enrollment.AmountPaid
+	enrollment.RecordPayment(course, amount)
+	if err := tx.Omit(clause.Associations).Save(enrollment).Error; err != nil {
+		return nil, err
+	}
+	if err := issueReceipt(tx, &payment, enrollment, course); err != nil {
+		return nil, err
+	}
+
+	card.Balance -= amount
+	if err := tx.Omit(clause.Associations).Save(&card).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&models.GiftCardRedemption{
+		GiftCardID:         card.ID,
+		UserID:             enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		CoursePaymentID:    &payment.ID,
+		Amount:             amount,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := postLedgerWithTax(tx, models.LedgerEntry{
+		Kind:               models.LedgerGiftCardRedemption,
+		DebitAccount:       models.AccountGiftCardLiability,
+		CreditAccount:      models.AccountCourseRevenue,
+		Amount:             amount,
+		Currency:           enrollment.Currency,
+		UserID:             &enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		CoursePaymentID:    &payment.ID,
+		GiftCardID:         &card.ID,
+		Description:        "Gift card payment for " + course.Title,
+		RecordedBy:         enrollment.UserID,
+	}, paymentTax(enrollment, paidBefore, enrollment.AmountPaid)); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CheckPromoCode - previews the discount a promo code gives on a course
+func (h *PromotionHandler) CheckPromoCode(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+	courseID, err := uuid.Parse(c.Query("course_id"))
+	if err != nil {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "course_id", Message: "must be a valid UUID"}))
+		return
+	}
+
+	var course models.Course
+	if err := requestDB(c, h.db).First(&course, "id = ? AND is_active = ?", courseID, true).Error; err != nil {
+		c.Error(apperr.NotFound("Course not found"))
+		return
+	}
+
+	var promo *models.PromoCode
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		promo, err = lockPromoCode(tx, c.Query("code"), userID, &course, time.Now())
+		return err
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	discount := promo.Discount(course.Price)
+	price, err := coursePrice(requestDB(c, h.db), &course, discount)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to price course", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":          promo.Code,
+		"discount_type": promo.DiscountType,
+		"amount":        promo.Amount,
+		"price":         course.Price,
+		"discount":      discount,
+		"subtotal":      price.Subtotal,
+		"tax":           price.Tax,
+		"taxes":         price.Taxes,
+		"total":         price.Total,
+		"currency":      price.Currency,
+	})
+}
+
+// GetGiftCardBalance - the remaining balance of a gift card
+func (h *PromotionHandler) GetGiftCardBalance(c *gin.Context) {
+	var card models.GiftCard
+	if err := requestDB(c, h.db).First(&card, "code = ?", normalizeGiftCardCode(c.Query("code"))).Error; err != nil {
+		c.Error(apperr.NotFound("Gift card not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance":    card.Balance,
+		"currency":   card.Currency,
+		"expires_at": card.ExpiresAt,
+		"usable":     card.UsableAt(time.Now()),
+	})
+}
+
+// GetPromoCodes - Admin: promo codes, optionally only active ones
+func (h *PromotionHandler) GetPromoCodes(c *gin.Context) {
+	params, err := parseListParams(c, promoCodeSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.PromoCode{})
+	active, err := parseBoolParam(c, "active")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if active != nil {
+		query = query.Where("is_active = ?", *active)
+	}
+
+	var promos []models.PromoCode
+	total, err := paginate(query, params, &promos)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch promo codes", err))
+		return
+	}
+
+	if promos == nil {
+		promos = []models.PromoCode{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(promos, total, params))
+}
+
+// checkPromoCodeUnique fails with 409 when another promo code has the same code
+func (h *PromotionHandler) checkPromoCodeUnique(c *gin.Context, promo models.PromoCode) error {
+	var count int64
+	if err := requestDB(c, h.db).Model(&models.PromoCode{}).
+		Where("code = ? AND id <> ?", promo.Code, promo.ID).
+		Count(&count).Error; err != nil {
+		return apperr.Internal("Failed to check promo code", err)
+	}
+	if count > 0 {
+		return apperr.Conflict("A promo code with this code already exists")
+	}
+	return nil
+}
+
+// CreatePromoCode - Admin: add a promo code
+func (h *PromotionHandler) CreatePromoCode(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input PromoCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	promo := models.PromoCode{CreatedBy: adminID, IsActive: true, Currency: studioCurrency(c)}
+	input.Apply(&promo)
+	if err := h.checkPromoCodeUnique(c, promo); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := requestDB(c, h.db).Create(&promo).Error; err != nil {
+		c.Error(apperr.Internal("Failed to create promo code", err))
+		return
+	}
+
+	log.Printf("INFO: Promo code created: code=%s, by=%s", promo.Code, adminID)
+	c.JSON(http.StatusCreated, promo)
+}
+
+// UpdatePromoCode - Admin: change a promo code. Past redemptions keep the discount they gave.
+func (h *PromotionHandler) UpdatePromoCode(c *gin.Context) {
+	var promo models.PromoCode
+	if err := requestDB(c, h.db).First(&promo, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(apperr.NotFound("Promo code not found"))
+		return
+	}
+
+	var input PromoCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	input.Apply(&promo)
+	promo.Currency = studioCurrency(c)
+	if err := h.checkPromoCodeUnique(c, promo); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := requestDB(c, h.db).Omit(clause.Associations).Save(&promo).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update promo code", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// DeletePromoCode - Admin: remove a promo code nobody has used. Redeemed
+// codes are kept for reporting and must be deactivated instead.
+func (h *PromotionHandler) DeletePromoCode(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid promo code ID format"))
+		return
+	}
+
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		var redemptions int64
+		if err := tx.Model(&models.PromoRedemption{}).Where("promo_code_id = ?", id).Count(&redemptions).Error; err != nil {
+			return err
+		}
+		if redemptions > 0 {
+			return apperr.Conflict("Promo code has been redeemed; deactivate it instead")
+		}
+
+		result := tx.Delete(&models.PromoCode{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperr.NotFound("Promo code not found")
+		}
+		return nil
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promo code deleted successfully"})
+}
+
+// GetPromoRedemptions - Admin: who used a promo code and the discount they got
+func (h *PromotionHandler) GetPromoRedemptions(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid promo code ID format"))
+		return
+	}
+
+	params, err := parseListParams(c, redemptionSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.PromoRedemption{}).Where("promo_code_id = ?", id)
+
+	var redemptions []models.PromoRedemption
+	total, err := paginate(query, params, &redemptions, preload("User", studentSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch redemptions", err))
+		return
+	}
+
+	if redemptions == nil {
+		redemptions = []models.PromoRedemption{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(redemptions, total, params))
+}
+
+// GetGiftCards - Admin: issued gift cards, optionally only those with a balance left
+func (h *PromotionHandler) GetGiftCards(c *gin.Context) {
+	params, err := parseListParams(c, giftCardSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.GiftCard{})
+	outstanding, err := parseBoolParam(c, "outstanding")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if outstanding != nil && *outstanding {
+		query = query.Where("balance > 0 AND is_active = ?", true)
+	}
+
+	var cards []models.GiftCard
+	total, err := paginate(query, params, &cards)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch gift cards", err))
+		return
+	}
+
+	if cards == nil {
+		cards = []models.GiftCard{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(cards, total, params))
+}
+
+// CreateGiftCard - Admin: issue a gift card with a new random code
+func (h *PromotionHandler) CreateGiftCard(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input GiftCardRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	code, err := newGiftCardCode()
+	if err != nil {
+		c.Error(apperr.Internal("Failed to generate gift card code", err))
+		return
+	}
+
+	card := models.GiftCard{
+		Code:           code,
+		InitialBalance: input.InitialBalance,
+		Balance:        input.InitialBalance,
+		Currency:       studioCurrency(c),
+		IssuedTo:       input.IssuedTo,
+		ExpiresAt:      input.ExpiresAt,
+		IsActive:       true,
+		CreatedBy:      adminID,
+	}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if input.PurchaserID != nil {
+			if err := tx.Select("id").First(&models.User{}, "id = ?", *input.PurchaserID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return apperr.Validation(apperr.FieldError{Field: "purchaser_id", Message: "does not exist"})
+				}
+				return err
+			}
+		}
+		if err := tx.Create(&card).Error; err != nil {
+			return err
+		}
+		if err := postLedger(tx, &models.LedgerEntry{
+			Kind:          models.LedgerGiftCardIssued,
+			DebitAccount:  models.AccountCash,
+			CreditAccount: models.AccountGiftCardLiability,
+			Amount:        card.InitialBalance,
+			UserID:        input.PurchaserID,
+			Currency:      card.Currency,
+			GiftCardID:    &card.ID,
+			Reference:     input.Reference,
+			Description:   "Gift card sold",
+			RecordedBy:    adminID,
+		}); err != nil {
+			return err
+		}
+
+		// The receipt is emailed when the purchaser has an account; otherwise
+		// admins hand it over from GET /admin/receipts
+		return storeReceipt(tx, &models.Receipt{
+			UserID:        input.PurchaserID,
+			GiftCardID:    &card.ID,
+			PaymentMethod: "Payment to studio",
+			PaidAt:        card.CreatedAt,
+			Currency:      card.Currency,
+			Subtotal:      card.InitialBalance,
+			Total:         card.InitialBalance,
+			Lines: []models.ReceiptLine{{
+				Position:    1,
+				Description: "Gift card ending " + card.Code[len(card.Code)-4:],
+				Quantity:    1,
+				UnitAmount:  card.InitialBalance,
+				Amount:      card.InitialBalance,
+			}},
+		})
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Gift card issued: id=%s, balance=%d, by=%s", card.ID, card.InitialBalance, adminID)
+	c.JSON(http.StatusCreated, card)
+}
+
+// UpdateGiftCard - Admin: change who a card was issued to, its expiry, or deactivate it
+func (h *PromotionHandler) UpdateGiftCard(c *gin.Context) {
+	var card models.GiftCard
+	if err := requestDB(c, h.db).First(&card, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(apperr.NotFound("Gift card not found"))
+		return
+	}
+
+	var input GiftCardUpdateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	card.IssuedTo = input.IssuedTo
+	card.ExpiresAt = input.ExpiresAt
+	if input.IsActive != nil {
+		card.IsActive = *input.IsActive
+	}
+	if err := requestDB(c, h.db).Model(&card).Select("issued_to", "expires_at", "is_active").Updates(&card).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update gift card", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// GetGiftCardRedemptions - Admin: what a gift card has been spent on
+func (h *PromotionHandler) GetGiftCardRedemptions(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid gift card ID format"))
+		return
+	}
+
+	params, err := parseListParams(c, redemptionSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.GiftCardRedemption{}).Where("gift_card_id = ?", id)
+
+	var redemptions []models.GiftCardRedemption
+	total, err := paginate(query, params, &redemptions, preload("User", studentSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch redemptions", err))
+		return
+	}
+
+	if redemptions == nil {
+		redemptions = []models.GiftCardRedemption{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(redemptions, total, params))
+}
+
+// PromoCodeUsage totals the redemptions of one promo code in a report
+type PromoCodeUsage struct {
+	PromoCodeID   uuid.UUID `json:"promo_code_id"`
+	Code          string    `json:"code"`
+	Redemptions   int64     `json:"redemptions"`
+	DiscountTotal int64     `json:"discount_total"`
+}
+
+// GetReport - Admin: promo code and gift card redemptions between
+// start_date and end_date, with the gift card balance still outstanding
+func (h *PromotionHandler) GetReport(c *gin.Context) {
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	inRange := func(db *gorm.DB, column string) *gorm.DB {
+		if startDate != nil {
+			db = db.Where(column+" >= ?", *startDate)
+		}
+		if endDate != nil {
+			db = db.Where(column+" < ?", *endDate)
+		}
+		return db
+	}
+
+	usage := []PromoCodeUsage{}
+	if err := inRange(requestDB(c, h.db).Model(&models.PromoRedemption{}), "promo_redemptions.created_at").
+		Select("promo_redemptions.promo_code_id, promo_codes.code, count(*) AS redemptions, coalesce(sum(promo_redemptions.amount), 0) AS discount_total").
+		Joins("JOIN promo_codes ON promo_codes.id = promo_redemptions.promo_code_id").
+		Group("promo_redemptions.promo_code_id, promo_codes.code").
+		Order("discount_total DESC").
+		Scan(&usage).Error; err != nil {
+		c.Error(apperr.Internal("Failed to build promotions report", err))
+		return
+	}
+
+	var giftCards struct {
+		Redemptions   int64 `json:"redemptions"`
+		RedeemedTotal int64 `json:"redeemed_total"`
+		Outstanding   int64 `json:"outstanding_balance"`
+	}
+	if err := inRange(requestDB(c, h.db).Model(&models.GiftCardRedemption{}), "created_at").
+		Select("count(*) AS redemptions, coalesce(sum(amount), 0) AS redeemed_total").
+		Scan(&giftCards).Error; err != nil {
+		c.Error(apperr.Internal("Failed to build promotions report", err))
+		return
+	}
+	if err := requestDB(c, h.db).Model(&models.GiftCard{}).
+		Where("is_active = ? AND (expires_at IS NULL OR expires_at > ?)", true, time.Now()).
+		Select("coalesce(sum(balance), 0)").
+		Scan(&giftCards.Outstanding).Error; err != nil {
+		c.Error(apperr.Internal("Failed to build promotions report", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        startDate,
+		"to":          endDate,
+		"promo_codes": usage,
+		"gift_cards":  giftCards,
+	})
+}
+
+// ============ Ledger Handler ============
+var ledgerSortKeys = sortKeys{
+	"sequence": "sequence",
+}
+
+// ledgerGenesisHash is the PrevHash of a studio's first ledger entry
+var ledgerGenesisHash = strings.Repeat("0", 64)
+
+type LedgerHandler struct {
+	db *gorm.DB
+}
+
+func NewLedgerHandler(db *gorm.DB) *LedgerHandler {
+	return &LedgerHandler{db: db}
+}
+
+// postLedger appends entry to the studio's ledger inside tx, chaining it to
+// the previous entry. The studio row is locked so that concurrent postings
+// are chained one after another.
+func postLedger(tx *gorm.DB, entry *models.LedgerEntry) error {
+	studio, err := lockStudio(tx)
+	if err != nil {
+		return err
+	}
+
+	var last []models.LedgerEntry
+	if err := tx.Select("sequence", "hash").Order("sequence DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	entry.Sequence = 1
+	entry.PrevHash = ledgerGenesisHash
+	if len(last) > 0 {
+		entry.Sequence = last[0].Sequence + 1
+		entry.PrevHash = last[0].Hash
+	}
+
+	entry.StudioID = studio.ID
+	if entry.Currency == "" {
+		entry.Currency = studio.Currency
+	}
+	entry.ID = uuid.New()
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Hash = entry.ComputeHash()
+	return tx.Create(entry).Error
+}
+
+// GetEntries - Admin: ledger entries in posting order, filtered by kind,
+// account (either side), user and date range
+func (h *LedgerHandler) GetEntries(c *gin.Context) {
+	params, err := parseListParams(c, ledgerSortKeys, "sequence")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.LedgerEntry{})
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if account := c.Query("account"); account != "" {
+		query = query.Where("debit_account = ? OR credit_account = ?", account, account)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "user_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if startDate != nil {
+		query = query.Where("created_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("created_at < ?", *endDate)
+	}
+
+	var entries []models.LedgerEntry
+	total, err := paginate(query, params, &entries)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch ledger entries", err))
+		return
+	}
+
+	if entries == nil {
+		entries = []models.LedgerEntry{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(entries, total, params))
+}
+
+// AccountBalance totals one ledger account in one currency over a period
+type AccountBalance struct {
+	Account  models.LedgerAccount `json:"account"`
+	Currency string               `json:"currency"`
+	Debits   int64                `json:"debits"`
+	Credits  int64                `json:"credits"`
+	Balance  int64                `json:"balance"` // debits minus credits
+}
+
+// GetBalances - Admin: debits, credits and balance of every account between
+// start_date and end_date, per currency
+func (h *LedgerHandler) GetBalances(c *gin.Context) {
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var debits, credits []struct {
+		Account  models.LedgerAccount
+		Currency string
+		Debits   int64
+		Credits  int64
+	}
+	query := requestDB(c, h.db).Model(&models.LedgerEntry{})
+	if startDate != nil {
+		query = query.Where("created_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("created_at < ?", *endDate)
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("debit_account AS account, currency, coalesce(sum(amount), 0) AS debits, 0 AS credits").
+		Group("debit_account, currency").Scan(&debits).Error; err != nil {
+		c.Error(apperr.Internal("Failed to total ledger", err))
+		return
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("credit_account AS account, currency, 0 AS debits, coalesce(sum(amount), 0) AS credits").
+		Group("credit_account, currency").Scan(&credits).Error; err != nil {
+		c.Error(apperr.Internal("Failed to total ledger", err))
+		return
+	}
+
+	// Entries posted before currencies were recorded are in the studio's,
+	// which can't change once the ledger has entries
+	type accountCurrency struct {
+		Account  models.LedgerAccount
+		Currency string
+	}
+	totals := make(map[accountCurrency]*AccountBalance)
+	for _, side := range append(debits, credits...) {
+		if side.Currency == "" {
+			side.Currency = studioCurrency(c)
+		}
+		key := accountCurrency{side.Account, side.Currency}
+		balance, ok := totals[key]
+		if !ok {
+			balance = &AccountBalance{Account: side.Account, Currency: side.Currency}
+			totals[key] = balance
+		}
+		balance.Debits += side.Debits
+		balance.Credits += side.Credits
+		balance.Balance = balance.Debits - balance.Credits
+	}
+	balances := make([]AccountBalance, 0, len(totals))
+	for _, balance := range totals {
+		balances = append(balances, *balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		return balances[i].Account < balances[j].Account
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     startDate,
+		"to":       endDate,
+		"accounts": balances,
+	})
+}
+
+// Verify - Admin: recompute the ledger's hash chain. The head hash can be
+// noted down outside the system so that later removal of the newest entries
+// is detectable too.
+func (h *LedgerHandler) Verify(c *gin.Context) {
+	chain := newLedgerChain()
+	for {
+		var batch []models.LedgerEntry
+		if err := requestDB(c, h.db).Where("sequence >= ?", chain.expected).
+			Order("sequence").Limit(ledgerVerifyBatch).Find(&batch).Error; err != nil {
+			c.Error(apperr.Internal("Failed to verify ledger", err))
+			return
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			if problem := chain.next(entry); problem != "" {
+				log.Printf("WARN: Ledger verification failed: studio=%s, sequence=%d, problem=%s", entry.StudioID, entry.Sequence, problem)
+				c.JSON(http.StatusOK, gin.H{
+					"valid":           false,
+					"entries_checked": chain.checked(),
+					"broken_sequence": entry.Sequence,
+					"problem":         problem,
+				})
+				return
+			}
+		}
+		if len(batch) < ledgerVerifyBatch {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":           true,
+		"entries_checked": chain.checked(),
+		"head_sequence":   chain.checked(),
+		"head_hash":       chain.prevHash,
+	})
+}
+
+// ledgerChain follows a studio's ledger in sequence order, checking that
+// each entry is the next one and is intact
+type ledgerChain struct {
+	expected int64
+	prevHash string
+}
+
+func newLedgerChain() *ledgerChain {
+	return &ledgerChain{expected: 1, prevHash: ledgerGenesisHash}
+}
+
+// next checks entry against the chain so far and, if it fits, appends it.
+// It returns what is wrong with the entry, or "" when nothing is.
+func (l *ledgerChain) next(entry *models.LedgerEntry) string {
+	switch {
+	case entry.Sequence != l.expected:
+		return fmt.Sprintf("expected sequence %d", l.expected)
+	case entry.PrevHash != l.prevHash:
+		return "previous hash does not match the entry before it"
+	case entry.Hash != entry.ComputeHash():
+		return "hash does not match the entry's contents"
+	}
+	l.expected++
+	l.prevHash = entry.Hash
+	return ""
+}
+
+// checked is the number of entries found intact so far
+func (l *ledgerChain) checked() int64 {
+	return l.expected - 1
+}
+
+// ledgerVerifyBatch is how many entries Verify loads at a time
+const ledgerVerifyBatch = 500
+
+// ReconcileMismatch is a reference whose amounts differ between the ledger
+// and the payment provider
+type ReconcileMismatch struct {
+	Reference      string `json:"reference"`
+	Kind           string `json:"kind"`
+	LedgerAmount   int64  `json:"ledger_amount"`
+	ProviderAmount int64  `json:"provider_amount"`
+}
+
+// reconcileKey identifies money movement by provider reference and direction
+type reconcileKey struct {
+	Reference string
+	Kind      string
+}
+
+// Reconcile - Admin: compare the money that passed through the payment
+// provider, as recorded in the ledger between start_date and end_date,
+// against the provider's statement for the same period
+func (h *LedgerHandler) Reconcile(c *gin.Context) {
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input ReconcileRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	query := requestDB(c, h.db).
+		Where("debit_account = ? OR credit_account = ?", models.AccountCash, models.AccountCash)
+	if startDate != nil {
+		query = query.Where("created_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("created_at < ?", *endDate)
+	}
+	var entries []models.LedgerEntry
+	if err := query.Order("sequence").Find(&entries).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch ledger entries", err))
+		return
+	}
+
+	// Money into cash is a provider charge, money out of it a refund
+	ledger := make(map[reconcileKey]int64)
+	unreferenced := []models.LedgerEntry{}
+	for _, entry := range entries {
+		if entry.Reference == "" {
+			unreferenced = append(unreferenced, entry)
+			continue
+		}
+		kind := "charge"
+		if entry.CreditAccount == models.AccountCash {
+			kind = "refund"
+		}
+		ledger[reconcileKey{entry.Reference, kind}] += entry.Amount
+	}
+	provider := make(map[reconcileKey]int64)
+	for _, t := range input.Transactions {
+		provider[reconcileKey{t.Reference, t.Kind}] += t.Amount
+	}
+
+	matched := 0
+	mismatched := []ReconcileMismatch{}
+	missingFromLedger := []ProviderTransaction{}
+	for key, amount := range provider {
+		ledgerAmount, ok := ledger[key]
+		switch {
+		case !ok:
+			missingFromLedger = append(missingFromLedger, ProviderTransaction{Reference: key.Reference, Kind: key.Kind, Amount: amount})
+		case ledgerAmount != amount:
+			mismatched = append(mismatched, ReconcileMismatch{Reference: key.Reference, Kind: key.Kind, LedgerAmount: ledgerAmount, ProviderAmount: amount})
+		default:
+			matched++
+		}
+	}
+	missingFromProvider := []ProviderTransaction{}
+	for key, amount := range ledger {
+		if _, ok := provider[key]; !ok {
+			missingFromProvider = append(missingFromProvider, ProviderTransaction{Reference: key.Reference, Kind: key.Kind, Amount: amount})
+		}
+	}
+	sort.Slice(mismatched, func(i, j int) bool { return mismatched[i].Reference < mismatched[j].Reference })
+	sort.Slice(missingFromLedger, func(i, j int) bool { return missingFromLedger[i].Reference < missingFromLedger[j].Reference })
+	sort.Slice(missingFromProvider, func(i, j int) bool { return missingFromProvider[i].Reference < missingFromProvider[j].Reference })
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":                  startDate,
+		"to":                    endDate,
+		"reconciled":            len(mismatched) == 0 && len(missingFromLedger) == 0 && len(missingFromProvider) == 0,
+		"matched":               matched,
+		"mismatched":            mismatched,
+		"missing_from_ledger":   missingFromLedger,
+		"missing_from_provider": missingFromProvider,
+		"unreferenced":          unreferenced, // cash entries recorded without a provider reference, e.g. cash at the desk
+	})
+}
+
+// ============ Receipt Handler ============
+var receiptSortKeys = sortKeys{
+	"number":    "number",
+	"issued_at": "issued_at",
+	"total":     "total",
+}
+
+type ReceiptHandler struct {
+	db *gorm.DB
+}
+
+func NewReceiptHandler(db *gorm.DB) *ReceiptHandler {
+	return &ReceiptHandler{db: db}
+}
+
+// receiptLines orders a receipt's lines as printed
+func receiptLines(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// loadStudio loads the studio db is scoped to
+func loadStudio(db *gorm.DB) (*models.Studio, error) {
+	studioID, ok := tenancy.StudioFrom(db.Statement.Context)
+	if !ok {
+		return nil, tenancy.ErrNoStudio
+	}
+	var studio models.Studio
+	if err := db.First(&studio, "id = ?", studioID).Error; err != nil {
+		return nil, err
+	}
+	return &studio, nil
+}
+
+// lockStudio loads the studio tx is scoped to and locks its row, which
+// serializes the numbering of its ledger entries and receipts
+func lockStudio(tx *gorm.DB) (*models.Studio, error) {
+	return loadStudio(tx.Clauses(clause.Locking{Strength: "UPDATE"}))
+}
+
+// snapshotReceipt copies the studio's and client's current details onto
+// receipt. Without a user the billing details are left as they are.
+func snapshotReceipt(receipt *models.Receipt, studio *models.Studio, user *models.User) {
+	receipt.Timezone = studio.Timezone
+	receipt.StudioName = studio.Name
+	receipt.StudioLegalName = studio.LegalName
+	receipt.StudioAddress = studio.Address
+	receipt.StudioTaxID = studio.TaxID
+	if user != nil {
+		receipt.BilledToName = user.Name
+		receipt.BilledToEmail = user.Email
+	}
+}
+
+// storeReceipt numbers receipt and stores it inside tx, billed to the user
+// it names if any. It is emailed by receipts.Deliver after the transaction
+// commits.
+func storeReceipt(tx *gorm.DB, receipt *models.Receipt) error {
+	studio, err := lockStudio(tx)
+	if err != nil {
+		return err
+	}
+	var user *models.User
+	if receipt.UserID != nil {
+		user = &models.User{}
+		if err := tx.First(user, "id = ?", *receipt.UserID).Error; err != nil {
+			return err
+		}
+	}
+	var last int64
+	if err := tx.Model(&models.Receipt{}).Select("coalesce(max(number), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	receipt.Number = last + 1
+	receipt.Revision = 1
+	receipt.IssuedAt = time.Now()
+	if receipt.Kind == "" {
+		receipt.Kind = models.ReceiptPayment
+	}
+	snapshotReceipt(receipt, studio, user)
+	return tx.Create(receipt).Error
+}
+
+// courseBilling is who is billed for enrollment and how course is described
+// to them. Whoever booked the place is billed, so a dependent's receipts go
+// to the guardian who enrolled them, naming the dependent.
+func courseBilling(tx *gorm.DB, enrollment *models.CourseEnrollment, course *models.Course) (uuid.UUID, string, error) {
+	if enrollment.BookedBy == nil || *enrollment.BookedBy == enrollment.UserID {
+		return enrollment.UserID, course.Title, nil
+	}
+	var student models.User
+	if err := tx.Select("id", "name").First(&student, "id = ?", enrollment.UserID).Error; err != nil {
+		return uuid.Nil, "", err
+	}
+	return *enrollment.BookedBy, course.Title + " for " + student.Name, nil
+}
+
+// issueReceipt issues the receipt for a course payment inside tx, once
+// enrollment has recorded the payment
+func issueReceipt(tx *gorm.DB, payment *models.CoursePayment, enrollment *models.CourseEnrollment, course *models.Course) error {
+	billed, description, err := courseBilling(tx, enrollment, course)
+	if err != nil {
+		return err
+	}
+	due := enrollment.AmountDue()
+	previouslyPaid := enrollment.AmountPaid - payment.Amount
+	label := fmt.Sprintf("installment %d", enrollment.InstallmentsPaid)
+	switch {
+	case previouslyPaid == 0 && enrollment.AmountPaid >= due:
+		label = "course fee"
+	case previouslyPaid == 0 && course.Deposit > 0:
+		label = "deposit"
+	}
+	method := payment.Note
+	if method == "" {
+		method = "Payment to studio"
+	}
+	tax := paymentTax(enrollment, previouslyPaid, enrollment.AmountPaid)
+
+	receipt := models.Receipt{
+		UserID:          &billed,
+		CoursePaymentID: &payment.ID,
+		PaymentMethod:   method,
+		PaidAt:          payment.PaidAt,
+		Currency:        enrollment.Currency,
+		Subtotal:        payment.Amount - tax,
+		Tax:             tax,
+		TaxLabel:        enrollment.TaxLabel,
+		Total:           payment.Amount,
+		BalanceDue:      max(due-enrollment.AmountPaid, 0),
+		Lines: []models.ReceiptLine{{
+			Position:    1,
+			Description: description + ": " + label,
+			Quantity:    1,
+			UnitAmount:  payment.Amount - tax,
+			Amount:      payment.Amount - tax,
+		}},
+	}
+	return storeReceipt(tx, &receipt)
+}
+
+// issueCreditNote issues the credit note for a refund of a course payment
+// inside tx, referring to the payment's receipt. tax is the part of the
+// refund that is tax given back.
+func issueCreditNote(tx *gorm.DB, refund *models.Refund, tax int64, enrollment *models.CourseEnrollment, course *models.Course) error {
+	billed, description, err := courseBilling(tx, enrollment, course)
+	if err != nil {
+		return err
+	}
+	description = "Refund: " + description
+	var original models.Receipt
+	if err := tx.Where("course_payment_id = ?", refund.CoursePaymentID).Limit(1).Find(&original).Error; err != nil {
+		return err
+	}
+	if original.ID != uuid.Nil {
+		description += " (receipt " + original.DisplayNumber() + ")"
+	}
+	method := "Refund to original payment method"
+	switch {
+	case refund.GiftCardID != nil:
+		method = "Returned to gift card"
+	case refund.HouseholdID != nil:
+		method = "Returned to household credit"
+	}
+
+	creditNote := models.Receipt{
+		Kind:          models.ReceiptCreditNote,
+		UserID:        &billed,
+		RefundID:      &refund.ID,
+		PaymentMethod: method,
+		PaidAt:        refund.CreatedAt,
+		Currency:      enrollment.Currency,
+		Subtotal:      refund.Amount - tax,
+		Tax:           tax,
+		TaxLabel:      enrollment.TaxLabel,
+		Total:         refund.Amount,
+		Lines: []models.ReceiptLine{{
+			Position:    1,
+			Description: description,
+			Quantity:    1,
+			UnitAmount:  refund.Amount - tax,
+			Amount:      refund.Amount - tax,
+		}},
+	}
+	if refund.Reason != "" {
+		creditNote.Lines[0].Description += ": " + refund.Reason
+	}
+	return storeReceipt(tx, &creditNote)
+}
+
+// GetMine - the current user's receipts, newest first
+func (h *ReceiptHandler) GetMine(c *gin.Context) {
+	params, err := parseListParams(c, receiptSortKeys, "-number")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Receipt{}).Where("user_id = ?", c.GetString("user_id"))
+
+	var receipts []models.Receipt
+	total, err := paginate(query, params, &receipts, preload("Lines", receiptLines))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch receipts", err))
+		return
+	}
+
+	if receipts == nil {
+		receipts = []models.Receipt{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(receipts, total, params))
+}
+
+// loadReceipt finds a receipt with its lines. userID limits the lookup to
+// one client's receipts when set.
+func (h *ReceiptHandler) loadReceipt(c *gin.Context, userID string) (*models.Receipt, error) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.BadRequest("Invalid receipt ID format")
+	}
+
+	query := requestDB(c, h.db).Preload("Lines", receiptLines)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var receipt models.Receipt
+	if err := query.First(&receipt, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Receipt not found")
+		}
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// sendPDF responds with receipt rendered as a PDF download
+func sendPDF(c *gin.Context, receipt *models.Receipt) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, receipts.Filename(receipt)))
+	c.Data(http.StatusOK, "application/pdf", receipts.Render(receipt))
+}
+
+// GetMyPDF - download one of the current user's receipts as a PDF
+func (h *ReceiptHandler) GetMyPDF(c *gin.Context) {
+	receipt, err := h.loadReceipt(c, c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	sendPDF(c, receipt)
+}
+
+// GetAll - Admin: receipts and credit notes, optionally for one client, kind or date range
+func (h *ReceiptHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, receiptSortKeys, "-number")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Receipt{})
+	if userID := c.Query("user_id"); userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "user_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if startDate != nil {
+		query = query.Where("issued_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("issued_at < ?", *endDate)
+	}
+
+	var receipts []models.Receipt
+	total, err := paginate(query, params, &receipts, preload("Lines", receiptLines))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch receipts", err))
+		return
+	}
+
+	if receipts == nil {
+		receipts = []models.Receipt{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(receipts, total, params))
+}
+
+// GetPDF - Admin: download any receipt as a PDF
+func (h *ReceiptHandler) GetPDF(c *gin.Context) {
+	receipt, err := h.loadReceipt(c, "")
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	sendPDF(c, receipt)
+}
+
+// Reissue - Admin: refresh a receipt with the studio's and client's current
+// details, keeping its number and amounts, and email it again, optionally
+// to a different address
+func (h *ReceiptHandler) Reissue(c *gin.Context) {
+	var input ReceiptReissueRequest
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	receipt, err := h.loadReceipt(c, "")
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		studio, err := lockStudio(tx)
+		if err != nil {
+			return err
+		}
+		var user *models.User
+		if receipt.UserID != nil {
+			user = &models.User{}
+			if err := tx.First(user, "id = ?", *receipt.UserID).Error; err != nil {
+				return err
+			}
+		}
+
+		snapshotReceipt(receipt, studio, user)
+		if input.Email != "" {
+			receipt.BilledToEmail = input.Email
+		}
+		receipt.Revision++
+		receipt.IssuedAt = time.Now()
+		receipt.EmailedAt = nil
+		receipt.EmailAttempts = 0
+		receipt.EmailError = ""
+		receipt.EmailRetryAt = nil
+		return tx.Omit(clause.Associations).Save(receipt).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Receipt re-issued: receipt=%s, revision=%d, by=%s", receipt.ID, receipt.Revision, c.GetString("user_id"))
+	c.JSON(http.StatusOK, receipt)
+}
+
+// ============ Tax Handler ============
+type TaxHandler struct {
+	db *gorm.DB
+}
+
+func NewTaxHandler(db *gorm.DB) *TaxHandler {
+	return &TaxHandler{db: db}
+}
+
+// loadTaxRates loads the rates of a location, or the studio's default rates
+// when locationID is nil, in the order they apply
+func loadTaxRates(db *gorm.DB, locationID *uuid.UUID) ([]models.TaxRate, error) {
+	query := db.Order("position")
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	} else {
+		query = query.Where("location_id IS NULL")
+	}
+	rates := []models.TaxRate{}
+	err := query.Find(&rates).Error
+	return rates, err
+}
+
+// taxRule is how a sale at a location is taxed: with the location's rates,
+// or the studio's default rates when it has none. Sales outside any
+// location, when locationID is nil, follow the studio's defaults.
+func taxRule(db *gorm.DB, locationID *uuid.UUID) (pricing.Rule, error) {
+	studio, err := loadStudio(db)
+	if err != nil {
+		return pricing.Rule{}, err
+	}
+	rule := pricing.Rule{Inclusive: studio.PricesIncludeTax}
+
+	var rates []models.TaxRate
+	if locationID != nil {
+		var location models.Location
+		if err := db.First(&location, "id = ?", *locationID).Error; err != nil {
+			return rule, err
+		}
+		rule.Inclusive = location.PricesIncludeTax
+		if rates, err = loadTaxRates(db, locationID); err != nil {
+			return rule, err
+		}
+	}
+	if len(rates) == 0 {
+		if rates, err = loadTaxRates(db, nil); err != nil {
+			return rule, err
+		}
+	}
+	for _, rate := range rates {
+		rule.Rates = append(rule.Rates, pricing.Rate{Name: rate.Name, BasisPoints: rate.BasisPoints})
+	}
+	return rule, nil
+}
+
+// coursePrice is what a student pays for course once discount is taken off,
+// taxed where the course is held
+func coursePrice(db *gorm.DB, course *models.Course, discount int64) (pricing.Breakdown, error) {
+	rule, err := taxRule(db, course.LocationID)
+	if err != nil {
+		return pricing.Breakdown{}, err
+	}
+	return pricing.Compute(course.Price-discount, course.Currency, rule), nil
+}
+
+// paymentTax is the part of an enrollment's tax carried by the money that
+// moved its amount paid from before to after, in either direction. Shares
+// are taken from the running total, so installments and refunds together
+// never move more tax than the enrollment charged.
+func paymentTax(enrollment *models.CourseEnrollment, before, after int64) int64 {
+	share := pricing.TaxShare(after, enrollment.Total, enrollment.Tax) - pricing.TaxShare(before, enrollment.Total, enrollment.Tax)
+	if share < 0 {
+		return -share
+	}
+	return share
+}
+
+// postLedgerWithTax posts entry with tax, the part of its amount that is
+// tax, split off into a second entry against tax payable instead of
+// revenue or refunds
+func postLedgerWithTax(tx *gorm.DB, entry models.LedgerEntry, tax int64) error {
+	for _, part := range splitTax(entry, tax) {
+		if err := postLedger(tx, &part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTax is entry as posted by postLedgerWithTax: the part that isn't tax,
+// unless it is all tax, followed by the tax. The amounts add up to entry's.
+func splitTax(entry models.LedgerEntry, tax int64) []models.LedgerEntry {
+	if tax <= 0 || tax > entry.Amount {
+		return []models.LedgerEntry{entry}
+	}
+	taxEntry := entry
+	taxEntry.Amount = tax
+	taxEntry.Description += " (tax)"
+	if taxEntry.DebitAccount == models.AccountRefunds {
+		taxEntry.DebitAccount = models.AccountTaxPayable
+	} else {
+		taxEntry.CreditAccount = models.AccountTaxPayable
+	}
+	entry.Amount -= tax
+	if entry.Amount == 0 {
+		return []models.LedgerEntry{taxEntry}
+	}
+	return []models.LedgerEntry{entry, taxEntry}
+}
+
+// parseLocationParam reads the optional location_id query parameter
+func parseLocationParam(c *gin.Context, db *gorm.DB) (*uuid.UUID, error) {
+	raw := c.Query("location_id")
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, apperr.Validation(apperr.FieldError{Field: "location_id", Message: "must be a valid UUID"})
+	}
+	if err := checkLocation(c, db, &id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// checkLocation fails validation unless id, when set, is one of the studio's locations
+func checkLocation(c *gin.Context, db *gorm.DB, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	if err := requestDB(c, db).Model(&models.Location{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return apperr.Internal("Failed to check location", err)
+	}
+	if count == 0 {
+		return apperr.Validation(apperr.FieldError{Field: "location_id", Message: "does not exist"})
+	}
+	return nil
+}
+
+// taxRatesResponse describes the rates of a location, or the studio's
+// defaults, along with the rule sales there are taxed by
+func taxRatesResponse(db *gorm.DB, locationID *uuid.UUID) (gin.H, error) {
+	rates, err := loadTaxRates(db, locationID)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := taxRule(db, locationID)
+	if err != nil {
+		return nil, err
+	}
+	applied := rule.Rates
+	if applied == nil {
+		applied = []pricing.Rate{}
+	}
+	return gin.H{
+		"location_id":        locationID,
+		"rates":              rates,
+		"uses_defaults":      locationID != nil && len(rates) == 0,
+		"prices_include_tax": rule.Inclusive,
+		"applied":            applied,
+	}, nil
+}
+
+// GetRates - Admin: the tax rates of the location_id given, or the studio's
+// default rates, and the rates that actually apply there
+func (h *TaxHandler) GetRates(c *gin.Context) {
+	locationID, err := parseLocationParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, err := taxRatesResponse(requestDB(c, h.db), locationID)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch tax rates", err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetRates - Admin: replace the tax rates of a location, or the studio's
+// default rates. Prices already fixed on bookings and enrollments keep the
+// tax they were sold with.
+func (h *TaxHandler) SetRates(c *gin.Context) {
+	var input TaxRatesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := checkLocation(c, h.db, input.LocationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	rates := input.Build()
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("location_id IS NULL")
+		if input.LocationID != nil {
+			query = tx.Where("location_id = ?", *input.LocationID)
+		}
+		if err := query.Delete(&models.TaxRate{}).Error; err != nil {
+			return err
+		}
+		if len(rates) == 0 {
+			return nil
+		}
+		return tx.Create(&rates).Error
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to update tax rates", err))
+		return
+	}
+
+	response, err := taxRatesResponse(requestDB(c, h.db), input.LocationID)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch tax rates", err))
+		return
+	}
+	log.Printf("INFO: Tax rates updated: location=%v, rates=%d", input.LocationID, len(rates))
+	c.JSON(http.StatusOK, response)
+}
+
+// ============ Form Handler ============
+var formSignatureSortKeys = sortKeys{
+	"signed_at": "signed_at",
+}
+
+type FormHandler struct {
+	db *gorm.DB
+}
+
+func NewFormHandler(db *gorm.DB) *FormHandler {
+	return &FormHandler{db: db}
+}
+
+// currentForms limits a form version query to the latest version of each kind
+func currentForms(db *gorm.DB) *gorm.DB {
+	return db.Where("version = (SELECT max(f.version) FROM form_versions f WHERE f.studio_id = form_versions.studio_id AND f.kind = form_versions.kind)")
+}
+
+// formQuestions orders a form's questions as asked
+func formQuestions(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// unsignedForms lists the current forms userID hasn't signed
+func unsignedForms(db *gorm.DB, userID uuid.UUID) ([]models.FormVersion, error) {
+	signed := db.Model(&models.FormSignature{}).Select("form_version_id").Where("user_id = ?", userID)
+	var forms []models.FormVersion
+	err := db.Scopes(currentForms).Where("id NOT IN (?)", signed).Order("kind").Find(&forms).Error
+	return forms, err
+}
+
+// requireSignedForms fails with 428 until userID has signed the studio's
+// current waiver and intake form, listing the forms still to sign so that
+// clients can show them
+func requireSignedForms(db *gorm.DB, userID uuid.UUID) error {
+	forms, err := unsignedForms(db, userID)
+	if err != nil {
+		return apperr.Internal("Failed to check signed forms", err)
+	}
+	if len(forms) == 0 {
+		return nil
+	}
+
+	missing := make([]gin.H, len(forms))
+	for i, form := range forms {
+		missing[i] = gin.H{"id": form.ID, "kind": form.Kind, "version": form.Version, "title": form.Title}
+	}
+	message := "Please sign the studio's " + string(forms[0].Kind) + " form before booking"
+	if forms[0].Kind == models.FormWaiver {
+		message = "Please sign the studio's waiver before booking"
+	}
+	return apperr.PreconditionRequired(message).WithDetails(map[string]interface{}{"forms": missing})
+}
+
+// CurrentForm is a current form version and whether the user has signed it
+type CurrentForm struct {
+	models.FormVersion
+	Signed   bool       `json:"signed"`
+	SignedAt *time.Time `json:"signed_at"`
+}
+
+// GetCurrent - the studio's current waiver and intake form, and whether the
+// current user, or with user_id one of their dependents, has signed them
+func (h *FormHandler) GetCurrent(c *gin.Context) {
+	userID, err := formSubjectParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var forms []models.FormVersion
+	if err := requestDB(c, h.db).Scopes(currentForms).Preload("Questions", formQuestions).
+		Order("kind").Find(&forms).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch forms", err))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(forms))
+	for i := range forms {
+		ids[i] = forms[i].ID
+	}
+	var signatures []models.FormSignature
+	if err := requestDB(c, h.db).Where("user_id = ? AND form_version_id IN ?", userID, ids).
+		Find(&signatures).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch signatures", err))
+		return
+	}
+
+	current := make([]CurrentForm, len(forms))
+	for i := range forms {
+		current[i] = CurrentForm{FormVersion: forms[i]}
+		for _, signature := range signatures {
+			if signature.FormVersionID == forms[i].ID {
+				signedAt := signature.SignedAt
+				current[i].Signed = true
+				current[i].SignedAt = &signedAt
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, current)
+}
+
+// Sign - signs the current version of a form, answering its questions. The
+// time, IP address and browser are recorded with the signature.
+func (h *FormHandler) Sign(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid form ID format"))
+		return
+	}
+
+	var input FormSignatureRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	userID, signedBy, err := formSubject(c, h.db, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var form models.FormVersion
+	if err := requestDB(c, h.db).Preload("Questions", formQuestions).First(&form, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apperr.NotFound("Form not found"))
+		} else {
+			c.Error(apperr.Internal("Failed to fetch form", err))
+		}
+		return
+	}
+
+	var current int64
+	if err := requestDB(c, h.db).Model(&models.FormVersion{}).Scopes(currentForms).
+		Where("id = ?", form.ID).Count(&current).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch form", err))
+		return
+	}
+	if current == 0 {
+		c.Error(apperr.Conflict("A newer version of this form has been published; sign that one instead"))
+		return
+	}
+
+	answers, err := input.BuildAnswers(&form)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	signature := models.FormSignature{
+		FormVersionID: form.ID,
+		UserID:        userID,
+		Kind:          form.Kind,
+		Version:       form.Version,
+		SignedName:    strings.TrimSpace(input.SignedName),
+		SignedBy:      signedBy,
+		SignedAt:      time.Now(),
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Answers:       answers,
+	}
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.FormSignature{}).Where("form_version_id = ? AND user_id = ?", form.ID, userID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return apperr.Conflict("You have already signed this form")
+		}
+		return tx.Create(&signature).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Form signed: user=%s, kind=%s, version=%d", userID, form.Kind, form.Version)
+	c.JSON(http.StatusCreated, signature)
+}
+
+// GetMine - the forms the current user, or with user_id one of their
+// dependents, has signed, newest first, with their answers
+func (h *FormHandler) GetMine(c *gin.Context) {
+	userID, err := formSubjectParam(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var signatures []models.FormSignature
+	if err := requestDB(c, h.db).Preload("FormVersion").Preload("Answers", formQuestions).
+		Where("user_id = ?", userID).
+		Order("signed_at DESC").Find(&signatures).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch signed forms", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, signatures)
+}
+
+// GetVersions - Admin: every published version of the forms, newest first,
+// optionally of one kind
+func (h *FormHandler) GetVersions(c *gin.Context) {
+	query := requestDB(c, h.db).Preload("Questions", formQuestions).Order("kind, version DESC")
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var forms []models.FormVersion
+	if err := query.Find(&forms).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch forms", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+// Publish - Admin: publish a new version of the waiver or intake form. It
+// becomes current at once, so every client has to sign it before their
+// next booking.
+func (h *FormHandler) Publish(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input FormVersionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var form models.FormVersion
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the studio so two admins can't publish the same version number
+		if _, err := lockStudio(tx); err != nil {
+			return err
+		}
+		var last int
+		if err := tx.Model(&models.FormVersion{}).Where("kind = ?", input.Kind).
+			Select("coalesce(max(version), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		form = input.Build(last+1, adminID)
+		return tx.Create(&form).Error
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to publish form", err))
+		return
+	}
+
+	log.Printf("INFO: Form published: kind=%s, version=%d, by=%s", form.Kind, form.Version, adminID)
+	c.JSON(http.StatusCreated, form)
+}
+
+// GetSignatures - Admin: who signed a form version, with the evidence of
+// each signature
+func (h *FormHandler) GetSignatures(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid form ID format"))
+		return
+	}
+	params, err := parseListParams(c, formSignatureSortKeys, "-signed_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.FormSignature{}).Where("form_version_id = ?", c.Param("id"))
+	var signatures []models.FormSignature
+	total, err := paginate(query, params, &signatures, preload("User", studentSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch signatures", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(signatures, total, params))
+}
+
+// GetUserForms - Admin: every form a client has signed, with their answers
+func (h *FormHandler) GetUserForms(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid user ID format"))
+		return
+	}
+
+	var signatures []models.FormSignature
+	if err := requestDB(c, h.db).Preload("FormVersion").Preload("Answers", formQuestions).
+		Where("user_id = ?", c.Param("id")).
+		Order("signed_at DESC").Find(&signatures).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch signed forms", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, signatures)
+}
+
+// RosterStudent is a student booked into a session with the health flags
+// from their latest intake form
+type RosterStudent struct {
+	User          models.User           `json:"user"`
+	FormsComplete bool                  `json:"forms_complete"` // has signed the current waiver and intake form
+	IntakeVersion int                   `json:"intake_version"` // 0 if they never completed an intake form
+	HealthFlags   []models.IntakeAnswer `json:"health_flags"`
+	Profile       models.Profile        `json:"profile"` // the fields the student shares with the viewer
+}
+
+// GetRoster - Instructor: the students booked into one session of a
+// schedule, with their health flags and the profile fields they share with
+// their instructors, such as an emergency contact. Only the session's instructor, an
+// approved substitute or an admin may see it.
+func (h *FormHandler) GetRoster(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid schedule ID format"))
+		return
+	}
+
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).Preload("Class").First(&schedule, "id = ?", c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apperr.NotFound("Schedule not found"))
+		} else {
+			c.Error(apperr.Internal("Failed to fetch schedule", err))
+		}
+		return
+	}
+
+	start := schedule.StartTime
+	if raw := c.Query("occurrence_start"); raw != "" {
+		if start, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "must be an RFC 3339 time"}))
+			return
+		}
+	} else if schedule.RecurrenceType != models.Once && schedule.RecurrenceType != "" {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "is required for recurring schedules"}))
+		return
+	}
+	occurrence, ok := scheduling.OccurrenceAt(schedule, start)
+	if !ok {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "is not an occurrence of this schedule"}))
+		return
+	}
+	start = occurrence.Start
+
+	if !actor.IsAdmin() {
+		var covering []models.Substitution
+		if err := requestDB(c, h.db).Scopes(approvedSubstitutions).
+			Where("schedule_id = ? AND occurrence_start = ?", schedule.ID, start).
+			Find(&covering).Error; err != nil {
+			c.Error(apperr.Internal("Failed to check instructor", err))
+			return
+		}
+		teaching := schedule.EffectiveInstructorID()
+		if len(covering) > 0 {
+			teaching = covering[0].SubstituteID
+		}
+		if teaching == nil || *teaching != actor.ID {
+			c.Error(apperr.Forbidden("Only the instructor teaching this session can see its roster"))
+			return
+		}
+	}
+
+	// Course sessions are attended by the course roster
+	var userIDs []uuid.UUID
+	query := requestDB(c, h.db).Model(&models.Enrollment{}).
+		Where("schedule_id = ? AND status = ? AND (occurrence_start IS NULL OR occurrence_start = ?)", schedule.ID, models.EnrollmentActive, start)
+	if schedule.CourseID != nil {
+		query = requestDB(c, h.db).Model(&models.CourseEnrollment{}).
+			Where("course_id = ? AND status = ?", *schedule.CourseID, models.CourseEnrolled)
+	}
+	if err := query.Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch roster", err))
+		return
+	}
+
+	audience := models.VisibleInstructor
+	if actor.IsAdmin() {
+		audience = models.VisibleAdmin
+	}
+	roster, err := rosterHealth(requestDB(c, h.db), userIDs, audience)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch roster", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedule_id":      schedule.ID,
+		"occurrence_start": start,
+		"class_title":      schedule.Class.Title,
+		"students":         roster,
+	})
+}
+
+// rosterHealth loads userIDs with whether their forms are complete, the
+// flagged answers of their latest intake form and their profile as audience
+// may see it, ordered by name
+func rosterHealth(db *gorm.DB, userIDs []uuid.UUID, audience models.Visibility) ([]RosterStudent, error) {
+	roster := []RosterStudent{}
+	if len(userIDs) == 0 {
+		return roster, nil
+	}
+
+	var users []models.User
+	if err := studentSummary(db).Where("id IN ?", userIDs).Order("name").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	var current []models.FormVersion
+	if err := db.Scopes(currentForms).Find(&current).Error; err != nil {
+		return nil, err
+	}
+	var signatures []models.FormSignature
+	if err := db.Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Where("flagged = ?", true).Order("position")
+	}).Where("user_id IN ?", userIDs).Order("version DESC").Find(&signatures).Error; err != nil {
+		return nil, err
+	}
+	var profiles []models.Profile
+	if err := db.Where("user_id IN ?", userIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		student := RosterStudent{User: user, FormsComplete: true, HealthFlags: []models.IntakeAnswer{}}
+		student.Profile = models.Profile{UserID: user.ID}.VisibleTo(audience)
+		for _, profile := range profiles {
+			if profile.UserID == user.ID {
+				student.Profile = profile.VisibleTo(audience)
+			}
+		}
+		for _, form := range current {
+			signed := false
+			for _, signature := range signatures {
+				signed = signed || (signature.UserID == user.ID && signature.FormVersionID == form.ID)
+			}
+			student.FormsComplete = student.FormsComplete && signed
+		}
+		// Signatures are newest version first, so the first intake is the latest
+		for _, signature := range signatures {
+			if signature.UserID == user.ID && signature.Kind == models.FormIntake {
+				student.IntakeVersion = signature.Version
+				student.HealthFlags = append(student.HealthFlags, signature.Answers...)
+				break
+			}
+		}
+		roster = append(roster, student)
+	}
+	return roster, nil
+}
+
+// ============ Household Handler ============
+var householdSortKeys = sortKeys{
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+var creditTransactionSortKeys = sortKeys{
+	"created_at": "created_at",
+}
+
+// householdInviteTTL is how long an invite code to join a household lasts
+const householdInviteTTL = 7 * 24 * time.Hour
+
+type HouseholdHandler struct {
+	db *gorm.DB
+}
+
+func NewHouseholdHandler(db *gorm.DB) *HouseholdHandler {
+	return &HouseholdHandler{db: db}
+}
+
+// bookingFor resolves whom a booking is for: the current user, or the
+// member of their household named by requested, in which case bookedBy is
+// the current user
+func bookingFor(c *gin.Context, db *gorm.DB, requested *uuid.UUID) (attendee uuid.UUID, bookedBy *uuid.UUID, err error) {
+	actor, err := currentUser(c, db)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if requested == nil || *requested == actor.ID {
+		return actor.ID, nil, nil
+	}
+
+	notMember := apperr.Validation(apperr.FieldError{Field: "user_id", Message: "is not a member of your household"})
+	if actor.HouseholdID == nil {
+		return uuid.Nil, nil, notMember
+	}
+	var member models.User
+	if err := requestDB(c, db).Select("id").Where("id = ? AND household_id = ?", *requested, *actor.HouseholdID).
+		Limit(1).Find(&member).Error; err != nil {
+		return uuid.Nil, nil, apperr.Internal("Failed to check household", err)
+	}
+	if member.ID == uuid.Nil {
+		return uuid.Nil, nil, notMember
+	}
+	return member.ID, &actor.ID, nil
+}
+
+// managedUsers lists the users whose bookings the current user manages:
+// themselves and the other members of their household
+func managedUsers(c *gin.Context, db *gorm.DB) ([]uuid.UUID, error) {
+	actor, err := currentUser(c, db)
+	if err != nil {
+		return nil, err
+	}
+	if actor.HouseholdID == nil {
+		return []uuid.UUID{actor.ID}, nil
+	}
+	var ids []uuid.UUID
+	if err := requestDB(c, db).Model(&models.User{}).Where("household_id = ?", *actor.HouseholdID).Pluck("id", &ids).Error; err != nil {
+		return nil, apperr.Internal("Failed to load household", err)
+	}
+	return ids, nil
+}
+
+// memberParam resolves the optional user_id query parameter of the
+// current user's lists to them or a member of their household
+func memberParam(c *gin.Context, db *gorm.DB) (uuid.UUID, error) {
+	id, err := userIDParam(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	attendee, _, err := bookingFor(c, db, id)
+	return attendee, err
+}
+
+// formSubjectParam resolves the optional user_id query parameter of the
+// current user's forms to them or one of their dependents
+func formSubjectParam(c *gin.Context, db *gorm.DB) (uuid.UUID, error) {
+	id, err := userIDParam(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	subject, _, err := formSubject(c, db, id)
+	return subject, err
+}
+
+// userIDParam reads the optional user_id query parameter
+func userIDParam(c *gin.Context) (*uuid.UUID, error) {
+	raw := c.Query("user_id")
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, apperr.Validation(apperr.FieldError{Field: "user_id", Message: "must be a valid UUID"})
+	}
+	return &id, nil
+}
+
+// formSubject resolves whose forms the current user is acting on: their
+// own, or with requested one of their dependents, who signedBy is then set
+// for. Guardians sign for their dependents; adults sign their own forms, so
+// other adults in the household are refused.
+func formSubject(c *gin.Context, db *gorm.DB, requested *uuid.UUID) (subject uuid.UUID, signedBy *uuid.UUID, err error) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return uuid.Nil, nil, apperr.Unauthorized("Unauthorized")
+	}
+	if requested == nil || *requested == userID {
+		return userID, nil, nil
+	}
+	if subject, signedBy, err = bookingFor(c, db, requested); err != nil {
+		return uuid.Nil, nil, err
+	}
+	var dependents int64
+	if err := requestDB(c, db).Model(&models.User{}).Where("id = ? AND auth_provider = ?", subject, models.AuthDependent).
+		Count(&dependents).Error; err != nil {
+		return uuid.Nil, nil, apperr.Internal("Failed to check household", err)
+	}
+	if dependents == 0 {
+		return uuid.Nil, nil, apperr.Validation(apperr.FieldError{Field: "user_id", Message: "must be one of your dependents; adults sign their own forms"})
+	}
+	return subject, signedBy, nil
+}
+
+// householdMembers orders a household's members and keeps to their summary
+func householdMembers(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "household_id", "name", "email", "avatar_url", "auth_provider").Order("name")
+}
+
+// myHousehold loads the current user's household, locked for update when
+// lock is set
+func myHousehold(c *gin.Context, tx *gorm.DB, lock bool) (*models.User, *models.Household, error) {
+	actor, err := currentUser(c, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor.HouseholdID == nil {
+		return nil, nil, apperr.NotFound("You don't belong to a household")
+	}
+	query := tx
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var household models.Household
+	if err := query.First(&household, "id = ?", *actor.HouseholdID).Error; err != nil {
+		return nil, nil, err
+	}
+	return actor, &household, nil
+}
+
+// loadDependent loads a dependent of household for update inside tx
+func loadDependent(tx *gorm.DB, household *models.Household, id string) (*models.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.BadRequest("Invalid user ID format")
+	}
+	var dependent models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&dependent, "id = ? AND household_id = ? AND auth_provider = ?", id, household.ID, models.AuthDependent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Dependent not found")
+		}
+		return nil, err
+	}
+	return &dependent, nil
+}
+
+// Create - starts a household with the current user as its first guardian
+func (h *HouseholdHandler) Create(c *gin.Context) {
+	var input HouseholdRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var household models.Household
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		actor, err := currentUser(c, tx)
+		if err != nil {
+			return err
+		}
+		if actor.IsDependent() {
+			return apperr.Forbidden("Dependents can't start a household")
+		}
+		if actor.HouseholdID != nil {
+			return apperr.Conflict("You already belong to a household")
+		}
+		studio, err := loadStudio(tx)
+		if err != nil {
+			return err
+		}
+
+		household = models.Household{Name: strings.TrimSpace(input.Name), Currency: studio.Currency, CreatedBy: actor.ID}
+		if err := tx.Create(&household).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ? AND household_id IS NULL", actor.ID).
+			UpdateColumn("household_id", household.ID).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Household created: household=%s, by=%s", household.ID, household.CreatedBy)
+	c.JSON(http.StatusCreated, household)
+}
+
+// GetMine - the current user's household, its members and shared credit
+func (h *HouseholdHandler) GetMine(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if actor.HouseholdID == nil {
+		c.Error(apperr.NotFound("You don't belong to a household"))
+		return
+	}
+
+	var household models.Household
+	if err := requestDB(c, h.db).Preload("Members", householdMembers).
+		First(&household, "id = ?", *actor.HouseholdID).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch household", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+// AddDependent - adds a dependent, such as a child, who books through the
+// household's guardians and doesn't sign in
+func (h *HouseholdHandler) AddDependent(c *gin.Context) {
+	var input DependentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	now := time.Now()
+	if err := input.Validate(now); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var dependent models.User
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		actor, household, err := myHousehold(c, tx, true)
+		if err != nil {
+			return err
+		}
+		dependent = models.User{
+			Name:           strings.TrimSpace(*input.Name),
+			Role:           models.RoleClient,
+			AuthProvider:   models.AuthDependent,
+			AuthProviderID: "",
+			HouseholdID:    &household.ID,
+		}
+		if err := tx.Create(&dependent).Error; err != nil {
+			return err
+		}
+		log.Printf("INFO: Dependent added: household=%s, user=%s, by=%s", household.ID, dependent.ID, actor.ID)
+		return saveProfile(tx, dependent.ID, &input.ProfileRequest, now)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	response, err := profileResponse(c, requestDB(c, h.db), dependent.ID.String(), models.VisibleSelf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetDependent - a dependent's full profile, for their guardians
+func (h *HouseholdHandler) GetDependent(c *gin.Context) {
+	_, household, err := myHousehold(c, requestDB(c, h.db), false)
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+	dependent, err := loadDependent(requestDB(c, h.db), household, c.Param("id"))
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	response, err := profileResponse(c, requestDB(c, h.db), dependent.ID.String(), models.VisibleSelf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateDependent - a guardian changes a dependent's name and profile,
+// including who may see each field
+func (h *HouseholdHandler) UpdateDependent(c *gin.Context) {
+	var input ProfileRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	now := time.Now()
+	if err := input.Validate(now); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var dependent *models.User
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		_, household, err := myHousehold(c, tx, false)
+		if err != nil {
+			return err
+		}
+		if dependent, err = loadDependent(tx, household, c.Param("id")); err != nil {
+			return err
+		}
+		return saveProfile(tx, dependent.ID, &input, now)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	response, err := profileResponse(c, requestDB(c, h.db), dependent.ID.String(), models.VisibleSelf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Invite - creates a code another adult, such as a partner, can use to
+// join the household. A new code replaces the last one.
+func (h *HouseholdHandler) Invite(c *gin.Context) {
+	code, err := newGiftCardCode()
+	if err != nil {
+		c.Error(apperr.Internal("Failed to create invite code", err))
+		return
+	}
+	expiresAt := time.Now().Add(householdInviteTTL)
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		_, household, err := myHousehold(c, tx, true)
+		if err != nil {
+			return err
+		}
+		return tx.Model(household).Updates(map[string]interface{}{"invite_code": code, "invite_expires_at": expiresAt}).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": code, "expires_at": expiresAt})
+}
+
+// Join - joins the household whose invite code is given, as a guardian.
+// Codes can be used once.
+func (h *HouseholdHandler) Join(c *gin.Context) {
+	var input JoinHouseholdRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var household models.Household
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		actor, err := currentUser(c, tx)
+		if err != nil {
+			return err
+		}
+		if actor.HouseholdID != nil {
+			return apperr.Conflict("You already belong to a household; leave it first")
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("invite_code = ?", normalizeGiftCardCode(input.Code)).First(&household).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.Validation(apperr.FieldError{Field: "code", Message: "is not a valid invite code"})
+			}
+			return err
+		}
+		if !household.InviteValidAt(time.Now()) {
+			return apperr.Validation(apperr.FieldError{Field: "code", Message: "has expired"})
+		}
+
+		if err := tx.Model(&household).Updates(map[string]interface{}{"invite_code": "", "invite_expires_at": nil}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", actor.ID).UpdateColumn("household_id", household.ID).Error; err != nil {
+			return err
+		}
+		var guardians []uuid.UUID
+		if err := tx.Model(&models.User{}).Where("household_id = ? AND id <> ? AND auth_provider <> ?", household.ID, actor.ID, models.AuthDependent).
+			Pluck("id", &guardians).Error; err != nil {
+			return err
+		}
+		return notifyUsers(tx, guardians, models.NotificationHousehold, household.Name, actor.Name+" has joined your household")
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Household joined: household=%s, user=%s", household.ID, c.GetString("user_id"))
+	c.JSON(http.StatusOK, household)
+}
+
+// Leave - the current user leaves their household. The last guardian
+// can't leave while it has dependents or credit.
+func (h *HouseholdHandler) Leave(c *gin.Context) {
+	err := requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		actor, household, err := myHousehold(c, tx, true)
+		if err != nil {
+			return err
+		}
+		var others, dependents int64
+		if err := tx.Model(&models.User{}).Where("household_id = ? AND id <> ? AND auth_provider <> ?", household.ID, actor.ID, models.AuthDependent).
+			Count(&others).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("household_id = ? AND auth_provider = ?", household.ID, models.AuthDependent).
+			Count(&dependents).Error; err != nil {
+			return err
+		}
+		if others == 0 && (dependents > 0 || household.CreditBalance > 0) {
+			return apperr.Conflict("The last guardian can't leave a household that has dependents or credit")
+		}
+		return tx.Model(&models.User{}).Where("id = ?", actor.ID).UpdateColumn("household_id", nil).Error
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have left the household"})
+}
+
+// GetCredit - the changes to the household's shared credit, newest first
+func (h *HouseholdHandler) GetCredit(c *gin.Context) {
+	params, err := parseListParams(c, creditTransactionSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	_, household, err := myHousehold(c, requestDB(c, h.db), false)
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.CreditTransaction{}).Where("household_id = ?", household.ID)
+	var transactions []models.CreditTransaction
+	total, err := paginate(query, params, &transactions)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch credit", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(transactions, total, params))
+}
+
+// GetAll - Admin: households with their members, optionally searched by name
+func (h *HouseholdHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, householdSortKeys, "name")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Household{})
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name ILIKE ?", likePattern(search))
+	}
+
+	var households []models.Household
+	total, err := paginate(query, params, &households, preload("Members", householdMembers))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch households", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(households, total, params))
+}
+
+// AddCredit - Admin: record credit bought for a household at the studio
+func (h *HouseholdHandler) AddCredit(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid household ID format"))
+		return
+	}
+
+	var input HouseholdCreditRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var household models.Household
+	var transaction models.CreditTransaction
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&household, "id = ?", c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperr.NotFound("Household not found")
+			}
+			return err
+		}
+
+		payer := household.CreatedBy
+		if input.UserID != nil {
+			var member models.User
+			if err := tx.Select("id").Where("id = ? AND household_id = ? AND auth_provider <> ?", *input.UserID, household.ID, models.AuthDependent).
+				Limit(1).Find(&member).Error; err != nil {
+				return err
+			}
+			if member.ID == uuid.Nil {
+				return apperr.Validation(apperr.FieldError{Field: "user_id", Message: "must be a guardian of the household"})
+			}
+			payer = member.ID
+		}
+
+		household.CreditBalance += input.Amount
+		if err := tx.Model(&household).UpdateColumn("credit_balance", household.CreditBalance).Error; err != nil {
+			return err
+		}
+		transaction = models.CreditTransaction{
+			HouseholdID: household.ID,
+			Kind:        models.CreditAdded,
+			Amount:      input.Amount,
+			Balance:     household.CreditBalance,
+			UserID:      &payer,
+			Note:        input.Note,
+			RecordedBy:  adminID,
+		}
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		if err := postLedger(tx, &models.LedgerEntry{
+			Kind:          models.LedgerCreditAdded,
+			DebitAccount:  models.AccountCash,
+			CreditAccount: models.AccountHouseholdCredit,
+			Amount:        input.Amount,
+			Currency:      household.Currency,
+			UserID:        &payer,
+			HouseholdID:   &household.ID,
+			Reference:     input.Reference,
+			Description:   "Credit for household " + household.Name,
+			RecordedBy:    adminID,
+		}); err != nil {
+			return err
+		}
+		return storeReceipt(tx, &models.Receipt{
+			UserID:              &payer,
+			CreditTransactionID: &transaction.ID,
+			PaymentMethod:       "Payment to studio",
+			PaidAt:              transaction.CreatedAt,
+			Currency:            household.Currency,
+			Subtotal:            input.Amount,
+			Total:               input.Amount,
+			Lines: []models.ReceiptLine{{
+				Position:    1,
+				Description: "Household credit: " + household.Name,
+				Quantity:    1,
+				UnitAmount:  input.Amount,
+				Amount:      input.Amount,
+			}},
+		})
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
+		return
+	}
+
+	log.Printf("INFO: Household credit added: household=%s, amount=%d, by=%s", household.ID, input.Amount, adminID)
+	c.JSON(http.StatusCreated, gin.H{
+		"household":   household,
+		"transaction": transaction,
+	})
+}
+
+// redeemCredit pays as much of enrollment's balance as household's credit
+// covers, inside tx. Both should be locked.
+func redeemCredit(tx *gorm.DB, household *models.Household, course *models.Course, enrollment *models.CourseEnrollment, actorID uuid.UUID, now time.Time) (*models.CoursePayment, error) {
+	if household.CreditBalance <= 0 {
+		return nil, apperr.Conflict("Your household has no credit")
+	}
+	if household.Currency != enrollment.Currency {
+		return nil, apperr.Conflict("Your household's credit is in a different currency from this course")
+	}
+	amount := enrollment.AmountDue() - enrollment.AmountPaid
+	if amount <= 0 {
+		return nil, apperr.Conflict("Nothing is left to pay for this course")
+	}
+	amount = min(amount, household.CreditBalance)
+
+	payment := models.CoursePayment{
+		CourseEnrollmentID: enrollment.ID,
+		Amount:             amount,
+		Note:               "Household credit",
+		HouseholdID:        &household.ID,
+		RecordedBy:         actorID,
+		PaidAt:             now,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+	paidBefore := enrollment.AmountPaid
+	enrollment.RecordPayment(course, amount)
+	if err := tx.Omit(clause.Associations).Save(enrollment).Error; err != nil {
+		return nil, err
+	}
+	if err := issueReceipt(tx, &payment, enrollment, course); err != nil {
+		return nil, err
+	}
+
+	household.CreditBalance -= amount
+	if err := tx.Model(household).UpdateColumn("credit_balance", household.CreditBalance).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&models.CreditTransaction{
+		HouseholdID:        household.ID,
+		Kind:               models.CreditSpent,
+		Amount:             -amount,
+		Balance:            household.CreditBalance,
+		UserID:             &enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		CoursePaymentID:    &payment.ID,
+		Note:               course.Title,
+		RecordedBy:         actorID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := postLedgerWithTax(tx, models.LedgerEntry{
+		Kind:               models.LedgerCreditRedemption,
+		DebitAccount:       models.AccountHouseholdCredit,
+		CreditAccount:      models.AccountCourseRevenue,
+		Amount:             amount,
+		Currency:           enrollment.Currency,
+		UserID:             &enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		CoursePaymentID:    &payment.ID,
+		HouseholdID:        &household.ID,
+		Description:        "Household credit payment for " + course.Title,
+		RecordedBy:         actorID,
+	}, paymentTax(enrollment, paidBefore, enrollment.AmountPaid)); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// refundCredit returns amount of a household credit payment to the
+// household's balance inside tx
+func refundCredit(tx *gorm.DB, householdID uuid.UUID, enrollment *models.CourseEnrollment, payment *models.CoursePayment, amount int64, adminID uuid.UUID) error {
+	var household models.Household
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&household, "id = ?", householdID).Error; err != nil {
+		return err
+	}
+	household.CreditBalance += amount
+	if err := tx.Model(&household).UpdateColumn("credit_balance", household.CreditBalance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.CreditTransaction{
+		HouseholdID:        household.ID,
+		Kind:               models.CreditRefunded,
+		Amount:             amount,
+		Balance:            household.CreditBalance,
+		UserID:             &enrollment.UserID,
+		CourseEnrollmentID: &enrollment.ID,
+		CoursePaymentID:    &payment.ID,
+		RecordedBy:         adminID,
+	}).Error
+}
+
+// ============ User Handler ============
+var userSortKeys = sortKeys{
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+}
+
+type UserHandler struct {
+	db *gorm.DB
+}
+
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+// ProfileResponse is a user with their extended profile and the fields
+// they still have to fill in before booking
+type ProfileResponse struct {
+	models.User
+	MissingProfileFields []string `json:"missing_profile_fields"`
+}
+
+// loadProfile returns userID's extended profile, or an empty one if they
+// haven't filled it in yet
+func loadProfile(db *gorm.DB, userID uuid.UUID) (*models.Profile, error) {
+	var profile models.Profile
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&profile).Error; err != nil {
+		return nil, err
+	}
+	if profile.ID == uuid.Nil {
+		profile = models.Profile{UserID: userID, Visibility: map[string]models.Visibility{}}
+	}
+	return &profile, nil
+}
+
+// requiredProfileFields lists the profile fields the studio requires
+// before booking
+func requiredProfileFields(c *gin.Context) []string {
+	if studio := middleware.CurrentStudio(c); studio != nil {
+		return studio.RequiredProfileFields
+	}
+	return nil
+}
+
+// requireCompleteProfile fails with 428 until userID has filled in the
+// profile fields the studio requires, listing the missing ones
+func requireCompleteProfile(c *gin.Context, db *gorm.DB, userID uuid.UUID) error {
+	required := requiredProfileFields(c)
+	if len(required) == 0 {
+		return nil
+	}
+	profile, err := loadProfile(db, userID)
+	if err != nil {
+		return apperr.Internal("Failed to check profile", err)
+	}
+	if missing := profile.Missing(required); len(missing) > 0 {
+		return apperr.PreconditionRequired("Please complete your profile before booking").
+			WithDetails(map[string]interface{}{"profile_fields": missing})
+	}
+	return nil
+}
+
+// requireBookable checks everything a client must do before their first
+// booking: sign the studio's current forms and complete their profile
+func requireBookable(c *gin.Context, db *gorm.DB, userID uuid.UUID) error {
+	if err := requireSignedForms(db, userID); err != nil {
+		return err
+	}
+	return requireCompleteProfile(c, db, userID)
+}
+
+// profileResponse loads a user with their profile as audience may see it
+func profileResponse(c *gin.Context, db *gorm.DB, userID string, audience models.Visibility) (*ProfileResponse, error) {
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("User not found")
+		}
+		return nil, apperr.Internal("Failed to fetch user", err)
+	}
+	profile, err := loadProfile(db, user.ID)
+	if err != nil {
+		return nil, apperr.Internal("Failed to fetch profile", err)
+	}
+	visible := profile.VisibleTo(audience)
+	user.Profile = &visible
+	return &ProfileResponse{User: user, MissingProfileFields: profile.Missing(requiredProfileFields(c))}, nil
+}
+
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	response, err := profileResponse(c, requestDB(c, h.db), c.GetString("user_id"), models.VisibleSelf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateProfile - change the current user's name and extended profile,
+// including who may see each field
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var input ProfileRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	now := time.Now()
+	if err := input.Validate(now); err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		return saveProfile(tx, userID, &input, now)
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to update profile", err))
+		return
+	}
+
+	response, err := profileResponse(c, requestDB(c, h.db), userID.String(), models.VisibleSelf)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// saveProfile applies input to userID's name and profile inside tx
+func saveProfile(tx *gorm.DB, userID uuid.UUID, input *ProfileRequest, now time.Time) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("name", name).Error; err != nil {
+			return err
+		}
+		// Keep the denormalized instructor name on linked classes in step
+		if err := tx.Model(&models.Class{}).Where("instructor_id = ?", userID).Update("instructor_name", name).Error; err != nil {
+			return err
+		}
+	}
+
+	// Lock the user so two saves can't both create a profile
+	var locked models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	profile, err := loadProfile(tx, userID)
+	if err != nil {
+		return err
+	}
+	input.Apply(profile, now)
+	return tx.Save(profile).Error
+}
+
+// GetUserProfile - Admin: a client's profile, without the fields they keep
+// to themselves
+func (h *UserHandler) GetUserProfile(c *gin.Context) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.Error(apperr.BadRequest("Invalid user ID format"))
+		return
+	}
+
+	response, err := profileResponse(c, requestDB(c, h.db), c.Param("id"), models.VisibleAdmin)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProfileFields - the extended profile fields, who sees each by default,
+// and which the studio requires before booking
+func (h *UserHandler) GetProfileFields(c *gin.Context) {
+	required := requiredProfileFields(c)
+	if required == nil {
+		required = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": models.ProfileFields, "required": required})
+}
+
+func (h *UserHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, userSortKeys, "name")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.User{})
+
+	// Optional filters
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	isInstructor, err := parseBoolParam(c, "instructor")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if isInstructor != nil {
+		query = query.Where("is_instructor = ?", *isInstructor)
+	}
+	if search := c.Query("search"); search != "" {
+		pattern := likePattern(search)
+		query = query.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+
+	var users []models.User
+	total, err := paginate(query, params, &users)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch users", err))
+		return
+	}
+
+	if users == nil {
+		users = []models.User{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(users, total, params))
+}
+
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	userID := c.Param("id")
+
+	var input struct {
+		Role string `json:"role" binding:"required,oneof=CLIENT ADMIN"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	// Studio admins can't demote a super admin
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("id = ? AND role <> ?", userID, models.RoleSuperAdmin).Update("role", input.Role).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update role", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
+}
+
+// UploadAvatar - Upload profile picture
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	// Get file from request
+	file, err := c.FormFile("profile_picture")
+	if err != nil {
+		c.Error(apperr.BadRequest("No file uploaded"))
+		return
+	}
+
+	// Validate file size (max 5MB)
+	if file.Size > 5*1024*1024 {
+		c.Error(apperr.BadRequest("File too large (max 5MB)"))
+		return
+	}
+
+	// Validate file type
+	contentType := file.Header.Get("Content-Type")
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
+		c.Error(apperr.BadRequest("Only JPEG, PNG, and WebP images allowed"))
+		return
+	}
+
+	// Create uploads directory if it doesn't exist
+	uploadsDir := "./uploads/avatars"
+	os.MkdirAll(uploadsDir, os.ModePerm)
+
+	// Generate unique filename
+	ext := ".jpg"
+	if contentType == "image/png" {
+		ext = ".png"
+	} else if contentType == "image/webp" {
+		ext = ".webp"
+	}
+	filename := fmt.Sprintf("%s_%d%s", userID, time.Now().Unix(), ext)
+	filepath := fmt.Sprintf("%s/%s", uploadsDir, filename)
+
+	// Save file
+	if err := c.SaveUploadedFile(file, filepath); err != nil {
+		c.Error(apperr.Internal("Failed to save file", err))
+		return
+	}
+
+	// Update user avatar_url
+	avatarURL := fmt.Sprintf("/uploads/avatars/%s", filename)
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("id = ?", userID).Update("avatar_url", avatarURL).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update avatar", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"avatar_url": avatarURL})
+}
+
+// UpdateInstructorBio - Update instructor bio (for instructors)
+func (h *UserHandler) UpdateInstructorBio(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var user models.User
+	if err := requestDB(c, h.db).First(&user, "id = ?", userID).Error; err != nil {
+		c.Error(apperr.NotFound("User not found"))
+		return
+	}
+
+	if !user.IsInstructor {
+		c.Error(apperr.Forbidden("Only instructors can update bio"))
+		return
+	}
+
+	var input struct {
+		InstructorBio         string   `json:"instructor_bio"`
+		InstructorSpecialties []string `json:"instructor_specialties"`
+		YearsExperience       int      `json:"years_experience"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	updates := map[string]interface{}{
+		"instructor_bio":         input.InstructorBio,
+		"instructor_specialties": input.InstructorSpecialties,
+		"years_experience":       input.YearsExperience,
+	}
+
+	if err := requestDB(c, h.db).Model(&user).Updates(updates).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update bio", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ============ Content Handler ============
+type ContentHandler struct {
+	db *gorm.DB
+}
+
+func NewContentHandler(db *gorm.DB) *ContentHandler {
+	return &ContentHandler{db: db}
+}
+
+func (h *ContentHandler) GetByPage(c *gin.Context) {
+	pageName := c.Param("page")
+
+	var contents []models.Content
+	if err := requestDB(c, h.db).Where("page_name = ?", pageName).Find(&contents).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch content", err))
+		return
+	}
+
+	// Convert to map for easier frontend use
+	contentMap := make(map[string]string)
+	for _, content := range contents {
+		contentMap[content.SectionKey] = content.Content
+	}
+
+	c.JSON(http.StatusOK, contentMap)
+}
+
+func (h *ContentHandler) Update(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var input struct {
+		PageName   string `json:"page_name" binding:"required"`
+		SectionKey string `json:"section_key" binding:"required"`
+		Content    string `json:"content" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	// Check if content exists
+	var content models.Content
+	result := requestDB(c, h.db).Where("page_name = ? AND section_key = ?", input.PageName, input.SectionKey).First(&content)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		// Create new content
+		content = models.Content{
+			PageName:   input.PageName,
+			SectionKey: input.SectionKey,
+			Content:    input.Content,
+			UpdatedBy:  uuid.MustParse(userID),
+		}
+		if err := requestDB(c, h.db).Create(&content).Error; err != nil {
+			c.Error(apperr.Internal("Failed to create content", err))
+			return
+		}
+	} else {
+		// Update existing content
+		content.Content = input.Content
+		content.UpdatedBy = uuid.MustParse(userID)
+		if err := requestDB(c, h.db).Save(&content).Error; err != nil {
+			c.Error(apperr.Internal("Failed to update content", err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, content)
+}
+
+// ============ Analytics Handler ============
+type AnalyticsHandler struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+func (h *AnalyticsHandler) GetOverview(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "Analytics overview",
+		"data": gin.H{
+			"total_students":    0,
+			"active_classes":    0,
+			"total_enrollments": 0,
+			"instructors":       0,
+		},
+	})
+}
+
+// ============ Instructor Handler ============
+
+// instructorSummary limits preloaded instructors to public profile columns
+func instructorSummary(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "name", "avatar_url", "is_instructor")
+}
+
+// loadInstructor resolves an optional instructor reference from a request body,
+// failing validation on field unless it refers to a user marked as instructor
+func loadInstructor(c *gin.Context, db *gorm.DB, id *uuid.UUID, field string) (*models.User, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	var instructor models.User
+	if err := requestDB(c, db).First(&instructor, "id = ?", *id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.Validation(apperr.FieldError{Field: field, Message: "does not exist"})
+		}
+		return nil, apperr.Internal("Failed to load instructor", err)
+	}
+
+	if !instructor.IsInstructor {
+		return nil, apperr.Validation(apperr.FieldError{Field: field, Message: "is not an instructor"})
+	}
+
+	return &instructor, nil
+}
+
+type InstructorHandler struct {
+	db *gorm.DB
+}
+
+func NewInstructorHandler(db *gorm.DB) *InstructorHandler {
+	return &InstructorHandler{db: db}
+}
+
+// GetAll - Public endpoint: get active instructors with avatars
+func (h *InstructorHandler) GetAll(c *gin.Context) {
+	var instructors []models.User
+
+	if err := requestDB(c, h.db).Where("is_instructor = ? AND avatar_url != ''", true).
+		Order("instructor_order ASC, name ASC").
+		Find(&instructors).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch instructors", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, instructors)
+}
+
+// GetAllAdmin - Admin endpoint: get all instructors
+func (h *InstructorHandler) GetAllAdmin(c *gin.Context) {
+	var instructors []models.User
+
+	if err := requestDB(c, h.db).Where("is_instructor = ?", true).
+		Order("instructor_order ASC, name ASC").
+		Find(&instructors).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch instructors", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, instructors)
+}
+
+// Update - Update instructor profile
+func (h *InstructorHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var instructor models.User
+	if err := requestDB(c, h.db).First(&instructor, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Instructor not found"))
+		return
+	}
+
+	// Check if user is admin or updating their own profile
+	var currentUser models.User
+	requestDB(c, h.db).First(&currentUser, "id = ?", userID)
+	if !currentUser.IsAdmin() && instructor.ID.String() != userID {
+		c.Error(apperr.Forbidden("Not authorized"))
+		return
+	}
+
+	var input struct {
+		InstructorBio         string   `json:"instructor_bio"`
+		InstructorSpecialties []string `json:"instructor_specialties"`
+		YearsExperience       int      `json:"years_experience"`
+		IsFeatured            bool     `json:"is_featured"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
 		return
 	}
 
-	log.Printf("INFO: Enrollment cancelled successfully: enrollment=%s, user=%s", enrollmentID, userID)
-	c.JSON(http.StatusOK, gin.H{"message": "Enrollment cancelled successfully"})
+	updates := map[string]interface{}{
+		"instructor_bio":         input.InstructorBio,
+		"instructor_specialties": input.InstructorSpecialties,
+		"years_experience":       input.YearsExperience,
+	}
+
+	// Only admin can set featured
+	if currentUser.IsAdmin() {
+		updates["is_featured"] = input.IsFeatured
+	}
+
+	if err := requestDB(c, h.db).Model(&instructor).Updates(updates).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update instructor", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, instructor)
 }
 
-// ============ User Handler ============
-type UserHandler struct {
+// UpdateOrder - Reorder instructors
+func (h *InstructorHandler) UpdateOrder(c *gin.Context) {
+	id := c.Param("id")
+
+	var input struct {
+		Order int `json:"order" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("id = ?", id).Update("instructor_order", input.Order).Error; err != nil {
+		c.Error(apperr.Internal("Failed to update order", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order updated"})
+}
+
+// PromoteToInstructor - Promote a user to instructor
+func (h *InstructorHandler) PromoteToInstructor(c *gin.Context) {
+	id := c.Param("id")
+
+	var user models.User
+	if err := requestDB(c, h.db).First(&user, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("User not found"))
+		return
+	}
+
+	if err := requestDB(c, h.db).Model(&user).Update("is_instructor", true).Error; err != nil {
+		c.Error(apperr.Internal("Failed to promote user", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RemoveInstructor - Remove instructor status
+func (h *InstructorHandler) RemoveInstructor(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := requestDB(c, h.db).Model(&models.User{}).Where("id = ?", id).Update("is_instructor", false).Error; err != nil {
+		c.Error(apperr.Internal("Failed to remove instructor", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Instructor removed"})
+}
+
+// ============ Substitution Handler ============
+var substitutionSortKeys = sortKeys{
+	"occurrence_start": "occurrence_start",
+	"created_at":       "created_at",
+}
+
+// notificationTimeFormat renders occurrence times in notification messages,
+// which should be given in the schedule's or studio's zone
+const notificationTimeFormat = "Mon 2 Jan 15:04 MST"
+
+type SubstitutionHandler struct {
 	db *gorm.DB
 }
 
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{db: db}
+func NewSubstitutionHandler(db *gorm.DB) *SubstitutionHandler {
+	return &SubstitutionHandler{db: db}
 }
 
-func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID := c.GetString("user_id")
+// approvedSubstitutions limits a preload to substitutions that replace the instructor
+func approvedSubstitutions(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.SubstitutionApproved).Order("occurrence_start")
+}
 
+// currentUser loads the authenticated user. Super admins acting in another
+// studio belong to their home studio, so they are looked up across studios.
+func currentUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
 	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	query := requestDB(c, db)
+	if c.GetString("user_role") == string(models.RoleSuperAdmin) {
+		query = query.WithContext(tenancy.AllStudios(c.Request.Context()))
+	}
+	if err := query.First(&user, "id = ?", c.GetString("user_id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.Unauthorized("User not found")
+		}
+		return nil, apperr.Internal("Failed to load user", err)
+	}
+	return &user, nil
+}
+
+// lockSubstitution loads a substitution for update inside tx
+func lockSubstitution(tx *gorm.DB, id string) (*models.Substitution, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperr.BadRequest("Invalid substitution ID format")
+	}
+
+	var sub models.Substitution
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperr.NotFound("Substitution not found")
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// recordSubstitutionEvent appends the substitution's current state to its history
+func recordSubstitutionEvent(tx *gorm.DB, sub *models.Substitution, actorID uuid.UUID, note string) error {
+	return tx.Create(&models.SubstitutionEvent{
+		SubstitutionID: sub.ID,
+		Status:         sub.Status,
+		ActorID:        actorID,
+		SubstituteID:   sub.SubstituteID,
+		Note:           note,
+	}).Error
+}
+
+// notifyUsers creates one in-app notification per distinct user.
+// Dependents can't sign in, so their household's guardians are told
+// instead, with the dependent's name in the title.
+func notifyUsers(tx *gorm.DB, userIDs []uuid.UUID, kind models.NotificationType, title, message string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var dependents []models.User
+	if err := tx.Select("id", "name", "household_id").
+		Where("id IN ? AND auth_provider = ?", userIDs, models.AuthDependent).Find(&dependents).Error; err != nil {
+		return err
+	}
+	var guardians []models.User
+	if len(dependents) > 0 {
+		households := make([]uuid.UUID, 0, len(dependents))
+		for _, dependent := range dependents {
+			if dependent.HouseholdID != nil {
+				households = append(households, *dependent.HouseholdID)
+			}
+		}
+		if err := tx.Select("id", "household_id").
+			Where("household_id IN ? AND auth_provider <> ?", households, models.AuthDependent).Find(&guardians).Error; err != nil {
+			return err
+		}
+	}
+
+	type recipient struct {
+		userID uuid.UUID
+		title  string
+	}
+	seen := make(map[recipient]bool, len(userIDs))
+	var notifications []models.Notification
+	add := func(r recipient) {
+		if !seen[r] {
+			seen[r] = true
+			notifications = append(notifications, models.Notification{UserID: r.userID, Type: kind, Title: r.title, Message: message})
+		}
+	}
+	for _, id := range userIDs {
+		dependent := false
+		for _, d := range dependents {
+			if d.ID != id {
+				continue
+			}
+			dependent = true
+			for _, g := range guardians {
+				if d.HouseholdID != nil && g.HouseholdID != nil && *g.HouseholdID == *d.HouseholdID {
+					add(recipient{g.ID, d.Name + ": " + title})
+				}
+			}
+		}
+		if !dependent {
+			add(recipient{id, title})
+		}
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(notifications, 100).Error
+}
+
+// commitments loads everything that can keep an instructor busy during occurrence
+func (h *SubstitutionHandler) commitments(c *gin.Context, occurrence scheduling.Occurrence) ([]models.Schedule, []models.Substitution, error) {
+	schedules, err := loadSchedulesInWindow(c, h.db, occurrence.Start, occurrence.End)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var substitutions []models.Substitution
+	err = requestDB(c, h.db).
+		Where("status = ? AND occurrence_start < ? AND occurrence_end > ?", models.SubstitutionApproved, occurrence.End, occurrence.Start).
+		Find(&substitutions).Error
+	return schedules, substitutions, err
+}
+
+// eligibleInstructors returns the instructors, other than the one being
+// replaced, who are free for the substitution's occurrence
+func (h *SubstitutionHandler) eligibleInstructors(c *gin.Context, sub *models.Substitution) ([]models.User, error) {
+	query := requestDB(c, h.db).Where("is_instructor = ?", true)
+	if sub.OriginalInstructorID != nil {
+		query = query.Where("id <> ?", *sub.OriginalInstructorID)
+	}
+
+	var instructors []models.User
+	if err := query.Order("instructor_order ASC, name ASC").Find(&instructors).Error; err != nil {
+		return nil, err
+	}
+
+	occurrence := scheduling.Occurrence{Start: sub.OccurrenceStart, End: sub.OccurrenceEnd}
+	schedules, substitutions, err := h.commitments(c, occurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := []models.User{}
+	for _, instructor := range instructors {
+		if !scheduling.InstructorBusy(instructor.ID, occurrence, schedules, substitutions) {
+			eligible = append(eligible, instructor)
+		}
+	}
+	return eligible, nil
+}
+
+// checkAvailable fails with a conflict if instructorID already teaches during the substitution
+func (h *SubstitutionHandler) checkAvailable(c *gin.Context, instructorID uuid.UUID, sub *models.Substitution) error {
+	occurrence := scheduling.Occurrence{Start: sub.OccurrenceStart, End: sub.OccurrenceEnd}
+	schedules, substitutions, err := h.commitments(c, occurrence)
+	if err != nil {
+		return apperr.Internal("Failed to check instructor availability", err)
+	}
+	if scheduling.InstructorBusy(instructorID, occurrence, schedules, substitutions) {
+		return apperr.Conflict("Substitute already teaches at this time")
+	}
+	return nil
+}
+
+// Request - Instructor asks for cover of a single occurrence
+func (h *SubstitutionHandler) Request(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	var input SubstitutionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+
+	var schedule models.Schedule
+	if err := requestDB(c, h.db).Preload("Class").First(&schedule, "id = ?", input.ScheduleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "schedule_id", Message: "does not exist"}))
+		} else {
+			c.Error(apperr.Internal("Failed to fetch schedule", err))
+		}
+		return
+	}
+
+	occurrence, ok := scheduling.OccurrenceAt(schedule, input.OccurrenceStart)
+	if !ok {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "is not an occurrence of this schedule"}))
+		return
+	}
+	if !occurrence.Start.After(time.Now()) {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "occurrence_start", Message: "must be in the future"}))
+		return
+	}
+
+	// Only the instructor teaching the occurrence, or an admin, may ask for cover
+	original := schedule.EffectiveInstructorID()
+	if !actor.IsAdmin() && (original == nil || *original != actor.ID) {
+		c.Error(apperr.Forbidden("Only the scheduled instructor can request cover"))
+		return
+	}
+
+	var active int64
+	if err := requestDB(c, h.db).Model(&models.Substitution{}).
+		Where("schedule_id = ? AND occurrence_start = ? AND status IN ?", schedule.ID, occurrence.Start,
+			[]models.SubstitutionStatus{models.SubstitutionRequested, models.SubstitutionAccepted, models.SubstitutionApproved}).
+		Count(&active).Error; err != nil {
+		c.Error(apperr.Internal("Failed to check existing substitutions", err))
+		return
+	}
+	if active > 0 {
+		c.Error(apperr.Conflict("Cover has already been requested for this occurrence"))
+		return
+	}
+
+	sub := models.Substitution{
+		ScheduleID:           schedule.ID,
+		OccurrenceStart:      occurrence.Start,
+		OccurrenceEnd:        occurrence.End,
+		OriginalInstructorID: original,
+		Status:               models.SubstitutionRequested,
+		Reason:               input.Reason,
+		RequestedBy:          actor.ID,
+	}
+
+	// Offer the slot to every instructor who is free at that time
+	candidates, err := h.eligibleInstructors(c, &sub)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to find eligible instructors", err))
+		return
+	}
+	candidateIDs := make([]uuid.UUID, len(candidates))
+	for i, candidate := range candidates {
+		candidateIDs[i] = candidate.ID
+	}
+
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sub).Error; err != nil {
+			return err
+		}
+		if err := recordSubstitutionEvent(tx, &sub, actor.ID, input.Reason); err != nil {
+			return err
+		}
+		return notifyUsers(tx, candidateIDs, models.NotificationSubstitution, "Cover needed",
+			fmt.Sprintf("%s on %s needs a substitute instructor", schedule.Class.Title, occurrence.Start.Format(notificationTimeFormat)))
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to create substitution request: %v", err)
+		c.Error(apperr.Internal("Failed to create substitution request", err))
+		return
+	}
+
+	log.Printf("INFO: Cover requested: schedule=%s, occurrence=%s, offered_to=%d", schedule.ID, occurrence.Start.Format(time.RFC3339), len(candidates))
+	c.JSON(http.StatusCreated, sub)
 }
 
-func (h *UserHandler) UpdateProfile(c *gin.Context) {
+// GetOpen - Instructor: requests still looking for cover that the caller could take
+func (h *SubstitutionHandler) GetOpen(c *gin.Context) {
 	userID := c.GetString("user_id")
 
-	var input struct {
-		Name string `json:"name"`
+	params, err := parseListParams(c, substitutionSortKeys, "occurrence_start")
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	query := requestDB(c, h.db).Model(&models.Substitution{}).
+		Where("status = ? AND occurrence_start > ?", models.SubstitutionRequested, time.Now()).
+		Where("original_instructor_id IS NULL OR original_instructor_id <> ?", userID)
+
+	var subs []models.Substitution
+	total, err := paginate(query, params, &subs, preload("Schedule.Class"), preload("OriginalInstructor", instructorSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch substitutions", err))
+		return
+	}
+
+	if subs == nil {
+		subs = []models.Substitution{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(subs, total, params))
+}
+
+// GetMine - Instructor: substitutions the caller requested, is replaced in, or covers
+func (h *SubstitutionHandler) GetMine(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	params, err := parseListParams(c, substitutionSortKeys, "-occurrence_start")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query := requestDB(c, h.db).Model(&models.Substitution{}).
+		Where("requested_by = ? OR original_instructor_id = ? OR substitute_id = ?", userID, userID, userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var subs []models.Substitution
+	total, err := paginate(query, params, &subs,
+		preload("Schedule.Class"), preload("OriginalInstructor", instructorSummary), preload("Substitute", instructorSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch substitutions", err))
+		return
+	}
+
+	if subs == nil {
+		subs = []models.Substitution{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(subs, total, params))
+}
+
+// Accept - Instructor offers to cover an open request; an admin still has to approve it
+func (h *SubstitutionHandler) Accept(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !actor.IsInstructor {
+		c.Error(apperr.Forbidden("Only instructors can offer cover"))
 		return
 	}
 
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("name", input.Name).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+	var sub *models.Substitution
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		sub, err = lockSubstitution(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		if sub.Status != models.SubstitutionRequested {
+			return apperr.Conflict("This request is no longer looking for cover")
+		}
+		if sub.OriginalInstructorID != nil && *sub.OriginalInstructorID == actor.ID {
+			return apperr.BadRequest("You cannot cover your own class")
+		}
+		if err := h.checkAvailable(c, actor.ID, sub); err != nil {
+			return err
+		}
+
+		sub.Status = models.SubstitutionAccepted
+		sub.SubstituteID = &actor.ID
+		if err := tx.Omit(clause.Associations).Save(sub).Error; err != nil {
+			return err
+		}
+		return recordSubstitutionEvent(tx, sub, actor.ID, "")
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
+	log.Printf("INFO: Cover offered: substitution=%s, substitute=%s", sub.ID, actor.ID)
+	c.JSON(http.StatusOK, sub)
 }
 
-func (h *UserHandler) GetAll(c *gin.Context) {
-	var users []models.User
-	if err := h.db.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+// Cancel - Requester or admin withdraws a request that hasn't been decided yet
+func (h *SubstitutionHandler) Cancel(c *gin.Context) {
+	actor, err := currentUser(c, h.db)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var sub *models.Substitution
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		sub, err = lockSubstitution(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		if sub.RequestedBy != actor.ID && !actor.IsAdmin() {
+			return apperr.NotFound("Substitution not found")
+		}
+		if !sub.IsOpen() {
+			return apperr.Conflict("Only open requests can be cancelled")
+		}
+
+		volunteer := sub.SubstituteID
+		sub.Status = models.SubstitutionCancelled
+		if err := tx.Omit(clause.Associations).Save(sub).Error; err != nil {
+			return err
+		}
+		if err := recordSubstitutionEvent(tx, sub, actor.ID, ""); err != nil {
+			return err
+		}
+		if volunteer == nil {
+			return nil
+		}
+		return notifyUsers(tx, []uuid.UUID{*volunteer}, models.NotificationSubstitution, "Cover no longer needed",
+			fmt.Sprintf("The cover request for %s has been withdrawn", sub.OccurrenceStart.In(studioZone(c)).Format(notificationTimeFormat)))
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, sub)
 }
 
-func (h *UserHandler) UpdateRole(c *gin.Context) {
-	userID := c.Param("id")
+// GetAll - Admin: substitution history, filterable for payroll
+func (h *SubstitutionHandler) GetAll(c *gin.Context) {
+	params, err := parseListParams(c, substitutionSortKeys, "-occurrence_start")
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	var input struct {
-		Role string `json:"role"`
+	query := requestDB(c, h.db).Model(&models.Substitution{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if scheduleID := c.Query("schedule_id"); scheduleID != "" {
+		if _, err := uuid.Parse(scheduleID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "schedule_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("schedule_id = ?", scheduleID)
+	}
+	if instructorID := c.Query("instructor_id"); instructorID != "" {
+		if _, err := uuid.Parse(instructorID); err != nil {
+			c.Error(apperr.Validation(apperr.FieldError{Field: "instructor_id", Message: "must be a valid UUID"}))
+			return
+		}
+		query = query.Where("original_instructor_id = ? OR substitute_id = ?", instructorID, instructorID)
+	}
+
+	// Filter by occurrence date range
+	startDate, endDate, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if startDate != nil {
+		query = query.Where("occurrence_start >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("occurrence_start < ?", *endDate)
+	}
+
+	var subs []models.Substitution
+	total, err := paginate(query, params, &subs,
+		preload("Schedule.Class"), preload("OriginalInstructor", instructorSummary), preload("Substitute", instructorSummary))
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch substitutions", err))
+		return
+	}
+
+	if subs == nil {
+		subs = []models.Substitution{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(subs, total, params))
+}
+
+// GetByID - Admin: a substitution with its full history
+func (h *SubstitutionHandler) GetByID(c *gin.Context) {
+	var sub models.Substitution
+	err := requestDB(c, h.db).
+		Preload("Schedule.Class").
+		Preload("OriginalInstructor", instructorSummary).
+		Preload("Substitute", instructorSummary).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&sub, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.Error(apperr.NotFound("Substitution not found"))
+		return
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, sub)
+}
+
+// GetCandidates - Admin: instructors free to cover the occurrence
+func (h *SubstitutionHandler) GetCandidates(c *gin.Context) {
+	var sub models.Substitution
+	if err := requestDB(c, h.db).First(&sub, "id = ?", c.Param("id")).Error; err != nil {
+		c.Error(apperr.NotFound("Substitution not found"))
 		return
 	}
 
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("role", input.Role).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+	candidates, err := h.eligibleInstructors(c, &sub)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to find eligible instructors", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
+	c.JSON(http.StatusOK, candidates)
 }
 
-// UploadAvatar - Upload profile picture
-func (h *UserHandler) UploadAvatar(c *gin.Context) {
-	userID := c.GetString("user_id")
-
-	// Get file from request
-	file, err := c.FormFile("profile_picture")
+// Approve - Admin confirms the substitute; booked students are notified
+func (h *SubstitutionHandler) Approve(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.Error(apperr.Unauthorized("Unauthorized"))
 		return
 	}
 
-	// Validate file size (max 5MB)
-	if file.Size > 5*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 5MB)"})
+	// The body is optional; approving a volunteer needs no input
+	var input SubstitutionDecision
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.FromBind(err))
 		return
 	}
 
-	// Validate file type
-	contentType := file.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, and WebP images allowed"})
+	// An admin may assign cover directly instead of the volunteer
+	assigned, err := loadInstructor(c, h.db, input.SubstituteID, "substitute_id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Create uploads directory if it doesn't exist
-	uploadsDir := "./uploads/avatars"
-	os.MkdirAll(uploadsDir, os.ModePerm)
+	var sub *models.Substitution
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		sub, err = lockSubstitution(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		if !sub.IsOpen() {
+			return apperr.Conflict("Only open requests can be approved")
+		}
 
-	// Generate unique filename
-	ext := ".jpg"
-	if contentType == "image/png" {
-		ext = ".png"
-	} else if contentType == "image/webp" {
-		ext = ".webp"
-	}
-	filename := fmt.Sprintf("%s_%d%s", userID, time.Now().Unix(), ext)
-	filepath := fmt.Sprintf("%s/%s", uploadsDir, filename)
+		if assigned != nil {
+			if sub.OriginalInstructorID != nil && *sub.OriginalInstructorID == assigned.ID {
+				return apperr.Validation(apperr.FieldError{Field: "substitute_id", Message: "is the instructor being replaced"})
+			}
+			sub.SubstituteID = &assigned.ID
+		}
+		if sub.SubstituteID == nil {
+			return apperr.Validation(apperr.FieldError{Field: "substitute_id", Message: "is required until an instructor offers cover"})
+		}
+		if err := h.checkAvailable(c, *sub.SubstituteID, sub); err != nil {
+			return err
+		}
 
-	// Save file
-	if err := c.SaveUploadedFile(file, filepath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-		return
-	}
+		now := time.Now()
+		sub.Status = models.SubstitutionApproved
+		sub.DecidedBy = &adminID
+		sub.DecidedAt = &now
+		if err := tx.Omit(clause.Associations).Save(sub).Error; err != nil {
+			return err
+		}
+		if err := recordSubstitutionEvent(tx, sub, adminID, input.Note); err != nil {
+			return err
+		}
 
-	// Update user avatar_url
-	avatarURL := fmt.Sprintf("/uploads/avatars/%s", filename)
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("avatar_url", avatarURL).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
+		var schedule models.Schedule
+		if err := tx.Preload("Class").First(&schedule, "id = ?", sub.ScheduleID).Error; err != nil {
+			return err
+		}
+		var substitute models.User
+		if err := tx.First(&substitute, "id = ?", *sub.SubstituteID).Error; err != nil {
+			return err
+		}
+		when := sub.OccurrenceStart.In(schedule.Zone()).Format(notificationTimeFormat)
+
+		var studentIDs []uuid.UUID
+		if err := tx.Model(&models.Enrollment{}).
+			Where("schedule_id = ? AND status = ? AND (occurrence_start IS NULL OR occurrence_start = ?)", sub.ScheduleID, models.EnrollmentActive, sub.OccurrenceStart).
+			Pluck("user_id", &studentIDs).Error; err != nil {
+			return err
+		}
+		if err := notifyUsers(tx, studentIDs, models.NotificationSubstitution, "Instructor change",
+			fmt.Sprintf("%s on %s will be taught by %s", schedule.Class.Title, when, substitute.Name)); err != nil {
+			return err
+		}
+
+		instructorIDs := []uuid.UUID{substitute.ID}
+		if sub.OriginalInstructorID != nil {
+			instructorIDs = append(instructorIDs, *sub.OriginalInstructorID)
+		}
+		return notifyUsers(tx, instructorIDs, models.NotificationSubstitution, "Cover approved",
+			fmt.Sprintf("%s will cover %s on %s", substitute.Name, schedule.Class.Title, when))
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"avatar_url": avatarURL})
+	log.Printf("INFO: Substitution approved: substitution=%s, substitute=%s", sub.ID, *sub.SubstituteID)
+	c.JSON(http.StatusOK, sub)
 }
 
-// UpdateInstructorBio - Update instructor bio (for instructors)
-func (h *UserHandler) UpdateInstructorBio(c *gin.Context) {
-	userID := c.GetString("user_id")
-
-	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+// Reject - Admin declines the request; the scheduled instructor keeps the occurrence
+func (h *SubstitutionHandler) Reject(c *gin.Context) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.Error(apperr.Unauthorized("Unauthorized"))
 		return
 	}
 
-	if !user.IsInstructor {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only instructors can update bio"})
+	// The body is optional; a note explaining the rejection may be given
+	var input SubstitutionDecision
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.FromBind(err))
 		return
 	}
 
-	var input struct {
-		InstructorBio         string   `json:"instructor_bio"`
-		InstructorSpecialties []string `json:"instructor_specialties"`
-		YearsExperience       int      `json:"years_experience"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	var sub *models.Substitution
+	err = requestDB(c, h.db).Transaction(func(tx *gorm.DB) error {
+		sub, err = lockSubstitution(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		if !sub.IsOpen() {
+			return apperr.Conflict("Only open requests can be rejected")
+		}
 
-	updates := map[string]interface{}{
-		"instructor_bio":         input.InstructorBio,
-		"instructor_specialties": input.InstructorSpecialties,
-		"years_experience":       input.YearsExperience,
-	}
+		now := time.Now()
+		sub.Status = models.SubstitutionRejected
+		sub.DecidedBy = &adminID
+		sub.DecidedAt = &now
+		if err := tx.Omit(clause.Associations).Save(sub).Error; err != nil {
+			return err
+		}
+		if err := recordSubstitutionEvent(tx, sub, adminID, input.Note); err != nil {
+			return err
+		}
 
-	if err := h.db.Model(&user).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bio"})
+		recipients := []uuid.UUID{sub.RequestedBy}
+		if sub.SubstituteID != nil {
+			recipients = append(recipients, *sub.SubstituteID)
+		}
+		message := fmt.Sprintf("The cover request for %s was declined", sub.OccurrenceStart.In(studioZone(c)).Format(notificationTimeFormat))
+		if input.Note != "" {
+			message += ": " + input.Note
+		}
+		return notifyUsers(tx, recipients, models.NotificationSubstitution, "Cover declined", message)
+	})
+	if err != nil {
+		c.Error(apperr.From(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, sub)
 }
 
-// ============ Content Handler ============
-type ContentHandler struct {
+// ============ Notification Handler ============
+var notificationSortKeys = sortKeys{
+	"created_at": "created_at",
+}
+
+type NotificationHandler struct {
 	db *gorm.DB
 }
 
-func NewContentHandler(db *gorm.DB) *ContentHandler {
-	return &ContentHandler{db: db}
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
 }
 
-func (h *ContentHandler) GetByPage(c *gin.Context) {
-	pageName := c.Param("page")
+// GetMine - Notifications for the current user, newest first
+func (h *NotificationHandler) GetMine(c *gin.Context) {
+	userID := c.GetString("user_id")
 
-	var contents []models.Content
-	if err := h.db.Where("page_name = ?", pageName).Find(&contents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch content"})
+	params, err := parseListParams(c, notificationSortKeys, "-created_at")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Convert to map for easier frontend use
-	contentMap := make(map[string]string)
-	for _, content := range contents {
-		contentMap[content.SectionKey] = content.Content
+	query := requestDB(c, h.db).Model(&models.Notification{}).Where("user_id = ?", userID)
+
+	unread, err := parseBoolParam(c, "unread")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if unread != nil {
+		if *unread {
+			query = query.Where("read_at IS NULL")
+		} else {
+			query = query.Where("read_at IS NOT NULL")
+		}
 	}
 
-	c.JSON(http.StatusOK, contentMap)
+	var notifications []models.Notification
+	total, err := paginate(query, params, &notifications)
+	if err != nil {
+		c.Error(apperr.Internal("Failed to fetch notifications", err))
+		return
+	}
+
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	c.JSON(http.StatusOK, newListResponse(notifications, total, params))
 }
 
-func (h *ContentHandler) Update(c *gin.Context) {
+// MarkRead - Mark one of the current user's notifications as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
 	userID := c.GetString("user_id")
 
-	var input struct {
-		PageName   string `json:"page_name" binding:"required"`
-		SectionKey string `json:"section_key" binding:"required"`
-		Content    string `json:"content" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var notification models.Notification
+	if err := requestDB(c, h.db).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+		c.Error(apperr.NotFound("Notification not found"))
 		return
 	}
 
-	// Check if content exists
-	var content models.Content
-	result := h.db.Where("page_name = ? AND section_key = ?", input.PageName, input.SectionKey).First(&content)
-
-	if result.Error == gorm.ErrRecordNotFound {
-		// Create new content
-		content = models.Content{
-			PageName:   input.PageName,
-			SectionKey: input.SectionKey,
-			Content:    input.Content,
-			UpdatedBy:  uuid.MustParse(userID),
-		}
-		if err := h.db.Create(&content).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create content"})
-			return
-		}
-	} else {
-		// Update existing content
-		content.Content = input.Content
-		content.UpdatedBy = uuid.MustParse(userID)
-		if err := h.db.Save(&content).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update content"})
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := requestDB(c, h.db).Model(&notification).Update("read_at", now).Error; err != nil {
+			c.Error(apperr.Internal("Failed to update notification", err))
 			return
 		}
 	}
 
-	c.JSON(http.StatusOK, content)
-}
-
-// ============ Analytics Handler ============
-type AnalyticsHandler struct {
-	db *gorm.DB
-}
-
-func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
-	return &AnalyticsHandler{db: db}
-}
-
-func (h *AnalyticsHandler) GetOverview(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"message": "Analytics overview",
-		"data": gin.H{
-			"total_students":    0,
-			"active_classes":    0,
-			"total_enrollments": 0,
-			"instructors":       0,
-		},
-	})
+	c.JSON(http.StatusOK, notification)
 }
 
-// ============ Instructor Handler ============
-type InstructorHandler struct {
+// ============ Studio Handler ============
+type StudioHandler struct {
 	db *gorm.DB
 }
 
-func NewInstructorHandler(db *gorm.DB) *InstructorHandler {
-	return &InstructorHandler{db: db}
+func NewStudioHandler(db *gorm.DB) *StudioHandler {
+	return &StudioHandler{db: db}
 }
 
-// GetAll - Public endpoint: get active instructors with avatars
-func (h *InstructorHandler) GetAll(c *gin.Context) {
-	var instructors []models.User
-
-	if err := h.db.Where("is_instructor = ? AND avatar_url != ''", true).
-		Order("instructor_order ASC, name ASC").
-		Find(&instructors).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch instructors"})
+// GetAll - Super admin: list every studio
+func (h *StudioHandler) GetAll(c *gin.Context) {
+	var studios []models.Studio
+	if err := requestDB(c, h.db).Order("name").Find(&studios).Error; err != nil {
+		c.Error(apperr.Internal("Failed to fetch studios", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, instructors)
+	if studios == nil {
+		studios = []models.Studio{}
+	}
+	c.JSON(http.StatusOK, studios)
 }
 
-// GetAllAdmin - Admin endpoint: get all instructors
-func (h *InstructorHandler) GetAllAdmin(c *gin.Context) {
-	var instructors []models.User
+// Create - Super admin: open a new studio
+func (h *StudioHandler) Create(c *gin.Context) {
+	var input StudioRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperr.FromBind(err))
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := h.db.Where("is_instructor = ?", true).
-		Order("instructor_order ASC, name ASC").
-		Find(&instructors).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch instructors"})
+	studio := models.Studio{IsActive: true, PrivateBufferMinutes: 15, PrivateNoticeHours: 24, RequiredProfileFields: []string{}}
+	input.Apply(&studio)
+	if err := h.checkUnique(c, studio); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := requestDB(c, h.db).Create(&studio).Error; err != nil {
+		c.Error(apperr.Internal("Failed to create studio", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, instructors)
+	log.Printf("INFO: Studio created: %s", studio.Slug)
+	c.JSON(http.StatusCreated, studio)
 }
 
-// Update - Update instructor profile
-func (h *InstructorHandler) Update(c *gin.Context) {
+// Update - Super admin: edit a studio, or deactivate it with is_active=false
+func (h *StudioHandler) Update(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.GetString("user_id")
-
-	var instructor models.User
-	if err := h.db.First(&instructor, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Instructor not found"})
+	if _, err := uuid.Parse(id); err != nil {
+		c.Error(apperr.BadRequest("Invalid studio ID format"))
 		return
 	}
 
-	// Check if user is admin or updating their own profile
-	var currentUser models.User
-	h.db.First(&currentUser, "id = ?", userID)
-	if !currentUser.IsAdmin() && instructor.ID.String() != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+	var studio models.Studio
+	if err := requestDB(c, h.db).First(&studio, "id = ?", id).Error; err != nil {
+		c.Error(apperr.NotFound("Studio not found"))
 		return
 	}
 
-	var input struct {
-		InstructorBio         string   `json:"instructor_bio"`
-		InstructorSpecialties []string `json:"instructor_specialties"`
-		YearsExperience       int      `json:"years_experience"`
-		IsFeatured            bool     `json:"is_featured"`
-	}
-
+	var input StudioRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperr.FromBind(err))
 		return
 	}
-
-	updates := map[string]interface{}{
-		"instructor_bio":         input.InstructorBio,
-		"instructor_specialties": input.InstructorSpecialties,
-		"years_experience":       input.YearsExperience,
+	if err := input.Validate(); err != nil {
+		c.Error(err)
+		return
 	}
 
-	// Only admin can set featured
-	if currentUser.IsAdmin() {
-		updates["is_featured"] = input.IsFeatured
+	previousTimezone := studio.Timezone
+	previousCurrency := studio.Currency
+	input.Apply(&studio)
+	if err := h.checkUnique(c, studio); err != nil {
+		c.Error(err)
+		return
 	}
 
-	if err := h.db.Model(&instructor).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instructor"})
+	// The studio edited need not be the one serving the request
+	db := h.db.WithContext(tenancy.WithStudio(c.Request.Context(), studio.ID))
+	if studio.Currency != previousCurrency {
+		if err := checkCurrencyChange(db); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&studio).Error; err != nil {
+			return err
+		}
+		if studio.Currency != previousCurrency {
+			// Prices keep their amounts and are now in the new currency
+			for _, model := range []interface{}{&models.Course{}, &models.PromoCode{}, &models.Household{}} {
+				if err := tx.Model(model).Where("currency <> ?", studio.Currency).
+					UpdateColumn("currency", studio.Currency).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if studio.Timezone == previousTimezone {
+			return nil
+		}
+		// Schedules that aren't in a location with its own zone follow the studio's
+		zonedRooms := tx.Model(&models.Room{}).Select("rooms.id").
+			Joins("JOIN locations ON locations.id = rooms.location_id").
+			Where("locations.timezone <> ''")
+		return tx.Model(&models.Schedule{}).Where("room_id IS NULL OR room_id NOT IN (?)", zonedRooms).
+			UpdateColumn("timezone", studio.Timezone).Error
+	})
+	if err != nil {
+		c.Error(apperr.Internal("Failed to update studio", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, instructor)
+	c.JSON(http.StatusOK, studio)
 }
 
-// UpdateOrder - Reorder instructors
-func (h *InstructorHandler) UpdateOrder(c *gin.Context) {
-	id := c.Param("id")
-
-	var input struct {
-		Order int `json:"order" binding:"required"`
+// checkCurrencyChange refuses to change the currency of a studio that has
+// sold anything, since what was charged, paid and owed stays in the old one
+func checkCurrencyChange(db *gorm.DB) error {
+	sales := []struct {
+		model interface{}
+		where string
+	}{
+		{&models.LedgerEntry{}, "true"},
+		{&models.CourseEnrollment{}, "true"},
+		{&models.GiftCard{}, "true"},
 	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	for _, sale := range sales {
+		var count int64
+		if err := db.Model(sale.model).Where(sale.where).Count(&count).Error; err != nil {
+			return apperr.Internal("Failed to check studio sales", err)
+		}
+		if count > 0 {
+			return apperr.Conflict("The currency can't be changed once the studio has sold courses or gift cards")
+		}
 	}
+	return nil
+}
 
-	if err := h.db.Model(&models.User{}).Where("id = ?", id).Update("instructor_order", input.Order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
-		return
+// checkUnique rejects a slug or hostname already used by another studio,
+// since either would make requests ambiguous
+func (h *StudioHandler) checkUnique(c *gin.Context, studio models.Studio) error {
+	var taken []models.Studio
+	query := requestDB(c, h.db).Where("id <> ?", studio.ID)
+	if len(studio.Hostnames) > 0 {
+		query = query.Where("slug = ? OR hostnames && ARRAY[?]::text[]", studio.Slug, studio.Hostnames)
+	} else {
+		query = query.Where("slug = ?", studio.Slug)
 	}
+	if err := query.Find(&taken).Error; err != nil {
+		return apperr.Internal("Failed to check studio", err)
+	}
+	if len(taken) > 0 {
+		return apperr.Conflict(fmt.Sprintf("Slug or hostname already used by studio %q", taken[0].Slug))
+	}
+	return nil
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order updated"})
+// ============ Health Handler ============
+
+// draining is set once the server starts shutting down so readiness fails
+// and the load balancer stops routing new requests to this pod
+var draining atomic.Bool
+
+// MarkDraining flags the server as shutting down
+func MarkDraining() {
+	draining.Store(true)
 }
 
-// PromoteToInstructor - Promote a user to instructor
-func (h *InstructorHandler) PromoteToInstructor(c *gin.Context) {
-	id := c.Param("id")
+type HealthHandler struct {
+	db *gorm.DB
+}
 
-	var user models.User
-	if err := h.db.First(&user, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Live - Liveness probe: the process is up and serving requests
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
 
-	if err := h.db.Model(&user).Update("is_instructor", true).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote user"})
+// Ready - Readiness probe: the database is reachable and migrated
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
-}
-
-// RemoveInstructor - Remove instructor status
-func (h *InstructorHandler) RemoveInstructor(c *gin.Context) {
-	id := c.Param("id")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
 
-	if err := h.db.Model(&models.User{}).Where("id = ?", id).Update("is_instructor", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove instructor"})
+	if err := database.CheckReady(ctx, h.db); err != nil {
+		log.Printf("WARN: Readiness check failed: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Instructor removed"})
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
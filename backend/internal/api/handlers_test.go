@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yoga-studio-app/internal/apperr"
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/tenancy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeUsers opens a database that never reaches Postgres: statements are
+// built with the tenancy plugin in place, then answered from users by the
+// first bound value. Statements that fail or aren't limited to a studio
+// fail the test.
+func fakeUsers(t *testing.T, users ...models.User) *gorm.DB {
+	t.Helper()
+	conn, err := sql.Open("pgx", "postgres://test@127.0.0.1:1/test")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+	if err := db.Use(tenancy.Plugin{}); err != nil {
+		t.Fatalf("tenancy: %v", err)
+	}
+
+	err = db.Callback().Query().After("gorm:query").Register("test:rows", func(tx *gorm.DB) {
+		if tx.Error != nil {
+			t.Errorf("%s: %v", tx.Statement.SQL.String(), tx.Error)
+			return
+		}
+		if !strings.Contains(tx.Statement.SQL.String(), "studio_id") {
+			t.Errorf("not limited to a studio: %s", tx.Statement.SQL.String())
+		}
+		key := fmt.Sprint(tx.Statement.Vars[0])
+		switch dest := tx.Statement.Dest.(type) {
+		case *models.User:
+			for _, user := range users {
+				if user.ID.String() == key {
+					*dest = user
+				}
+			}
+		case *[]uuid.UUID:
+			for _, user := range users {
+				if user.HouseholdID != nil && user.HouseholdID.String() == key {
+					*dest = append(*dest, user.ID)
+				}
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return db
+}
+
+// requestAs builds a request context for user in studioID, as the studio
+// and auth middleware leave it
+func requestAs(studioID uuid.UUID, user models.User, target string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil).
+		WithContext(tenancy.WithStudio(context.Background(), studioID))
+	c.Set("user_id", user.ID.String())
+	c.Set("user_role", string(user.Role))
+	return c
+}
+
+func TestHouseholdBookingUsesRequestStudio(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	studioID := uuid.New()
+	householdID := uuid.New()
+	guardian := models.User{ID: uuid.New(), Role: models.RoleClient, HouseholdID: &householdID}
+	dependent := models.User{ID: uuid.New(), Role: models.RoleClient, HouseholdID: &householdID, AuthProvider: models.AuthDependent}
+	stranger := uuid.New()
+	// handlers pass their unscoped handle, as EnrollmentHandler.Create does
+	db := fakeUsers(t, guardian, dependent)
+
+	t.Run("book for a dependent", func(t *testing.T) {
+		attendee, bookedBy, err := bookingFor(requestAs(studioID, guardian, "/"), db, &dependent.ID)
+		if err != nil {
+			t.Fatalf("bookingFor: %v", err)
+		}
+		if attendee != dependent.ID || bookedBy == nil || *bookedBy != guardian.ID {
+			t.Fatalf("got attendee %s booked by %v, want %s booked by %s", attendee, bookedBy, dependent.ID, guardian.ID)
+		}
+	})
+
+	t.Run("book for oneself", func(t *testing.T) {
+		attendee, bookedBy, err := bookingFor(requestAs(studioID, guardian, "/"), db, &guardian.ID)
+		if err != nil {
+			t.Fatalf("bookingFor: %v", err)
+		}
+		if attendee != guardian.ID || bookedBy != nil {
+			t.Fatalf("got attendee %s booked by %v, want %s", attendee, bookedBy, guardian.ID)
+		}
+	})
+
+	t.Run("book for someone outside the household", func(t *testing.T) {
+		_, _, err := bookingFor(requestAs(studioID, guardian, "/"), db, &stranger)
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) || appErr.Code != apperr.CodeValidation {
+			t.Fatalf("got %v, want a validation error", err)
+		}
+	})
+
+	t.Run("cancel as a household member", func(t *testing.T) {
+		managed, err := managedUsers(requestAs(studioID, guardian, "/"), db)
+		if err != nil {
+			t.Fatalf("managedUsers: %v", err)
+		}
+		if len(managed) != 2 || managed[0] != guardian.ID || managed[1] != dependent.ID {
+			t.Fatalf("got %v, want [%s %s]", managed, guardian.ID, dependent.ID)
+		}
+	})
+
+	t.Run("list a dependent's bookings", func(t *testing.T) {
+		userID, err := memberParam(requestAs(studioID, guardian, "/?user_id="+dependent.ID.String()), db)
+		if err != nil {
+			t.Fatalf("memberParam: %v", err)
+		}
+		if userID != dependent.ID {
+			t.Fatalf("got %s, want %s", userID, dependent.ID)
+		}
+	})
+}
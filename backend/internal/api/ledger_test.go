@@ -0,0 +1,189 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"yoga-studio-app/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// postedLedger builds a chain of n entries the way postLedger does
+func postedLedger(n int) []models.LedgerEntry {
+	studioID, adminID := uuid.New(), uuid.New()
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	prevHash := ledgerGenesisHash
+	entries := make([]models.LedgerEntry, n)
+	for i := range entries {
+		entry := &entries[i]
+		entry.StudioID = studioID
+		entry.ID = uuid.New()
+		entry.Sequence = int64(i + 1)
+		entry.Kind = models.LedgerCharge
+		entry.DebitAccount = models.AccountCash
+		entry.CreditAccount = models.AccountCourseRevenue
+		entry.Amount = int64(1000 * (i + 1))
+		entry.RecordedBy = adminID
+		entry.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+		entry.PrevHash = prevHash
+		entry.Hash = entry.ComputeHash()
+		prevHash = entry.Hash
+	}
+	return entries
+}
+
+// verifyLedger runs entries through a ledgerChain as Verify does, returning
+// the sequence of the first broken entry (0 if none) and the problem
+func verifyLedger(entries []models.LedgerEntry) (int64, string, *ledgerChain) {
+	chain := newLedgerChain()
+	for i := range entries {
+		if problem := chain.next(&entries[i]); problem != "" {
+			return entries[i].Sequence, problem, chain
+		}
+	}
+	return 0, "", chain
+}
+
+func TestLedgerChainIntact(t *testing.T) {
+	entries := postedLedger(3)
+	broken, problem, chain := verifyLedger(entries)
+	if broken != 0 {
+		t.Fatalf("intact ledger broken at %d: %s", broken, problem)
+	}
+	if chain.checked() != 3 || chain.prevHash != entries[2].Hash {
+		t.Errorf("got %d entries checked with head %s, want 3 with %s", chain.checked(), chain.prevHash, entries[2].Hash)
+	}
+}
+
+func TestLedgerChainDetectsChangedAmount(t *testing.T) {
+	entries := postedLedger(3)
+	entries[1].Amount = 1
+
+	broken, problem, chain := verifyLedger(entries)
+	if broken != 2 || problem != "hash does not match the entry's contents" {
+		t.Errorf("got break at %d (%q), want 2 with a hash mismatch", broken, problem)
+	}
+	if chain.checked() != 1 {
+		t.Errorf("got %d entries checked, want 1", chain.checked())
+	}
+}
+
+func TestLedgerChainDetectsChangedAmountWithRecomputedHash(t *testing.T) {
+	// Rehashing the edited entry moves the break to the entry after it
+	entries := postedLedger(3)
+	entries[1].Amount = 1
+	entries[1].Hash = entries[1].ComputeHash()
+
+	broken, problem, _ := verifyLedger(entries)
+	if broken != 3 || problem != "previous hash does not match the entry before it" {
+		t.Errorf("got break at %d (%q), want 3 with a previous hash mismatch", broken, problem)
+	}
+}
+
+func TestLedgerChainDetectsRemovedEntry(t *testing.T) {
+	entries := postedLedger(3)
+	withoutMiddle := []models.LedgerEntry{entries[0], entries[2]}
+
+	broken, problem, _ := verifyLedger(withoutMiddle)
+	if broken != 3 || problem != "expected sequence 2" {
+		t.Errorf("got break at %d (%q), want 3 with a sequence gap", broken, problem)
+	}
+
+	// Renumbering to close the gap still leaves the chain broken
+	withoutMiddle[1].Sequence = 2
+	withoutMiddle[1].Hash = withoutMiddle[1].ComputeHash()
+	broken, problem, _ = verifyLedger(withoutMiddle)
+	if broken != 2 || problem != "previous hash does not match the entry before it" {
+		t.Errorf("renumbered: got break at %d (%q), want 2 with a previous hash mismatch", broken, problem)
+	}
+}
+
+// ledgerTotal adds up what entries move into and out of account
+func ledgerTotal(entries []models.LedgerEntry, account models.LedgerAccount) int64 {
+	var total int64
+	for _, entry := range entries {
+		if entry.CreditAccount == account {
+			total += entry.Amount
+		}
+		if entry.DebitAccount == account {
+			total -= entry.Amount
+		}
+	}
+	return total
+}
+
+func TestSplitTaxAddsUpToEntry(t *testing.T) {
+	charge := models.LedgerEntry{Kind: models.LedgerCharge, DebitAccount: models.AccountCash, CreditAccount: models.AccountCourseRevenue, Amount: 1190, Description: "Course payment"}
+	refund := models.LedgerEntry{Kind: models.LedgerRefund, DebitAccount: models.AccountRefunds, CreditAccount: models.AccountCash, Amount: 1190, Description: "Refund"}
+
+	tests := []struct {
+		name  string
+		entry models.LedgerEntry
+		tax   int64
+		want  []int64
+	}{
+		{"charge", charge, 190, []int64{1000, 190}},
+		{"refund", refund, 190, []int64{1000, 190}},
+		{"no tax", charge, 0, []int64{1190}},
+		{"all tax", charge, 1190, []int64{1190}},
+		{"more tax than amount", charge, 2000, []int64{1190}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitTax(tt.entry, tt.tax)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(parts), len(tt.want))
+			}
+			var sum int64
+			for i, part := range parts {
+				if part.Amount != tt.want[i] {
+					t.Errorf("entry %d: got %d, want %d", i, part.Amount, tt.want[i])
+				}
+				sum += part.Amount
+			}
+			if sum != tt.entry.Amount {
+				t.Errorf("entries add up to %d, want %d", sum, tt.entry.Amount)
+			}
+		})
+	}
+
+	parts := splitTax(refund, 190)
+	if parts[1].DebitAccount != models.AccountTaxPayable || parts[1].CreditAccount != models.AccountCash {
+		t.Errorf("refunded tax moves %s -> %s, want tax payable -> cash", parts[1].DebitAccount, parts[1].CreditAccount)
+	}
+}
+
+func TestTaxSplitAcrossInstallmentsAndRefunds(t *testing.T) {
+	// 100.00 including 19% VAT, paid in three installments, then partly
+	// refunded twice and finally in full
+	enrollment := &models.CourseEnrollment{Total: 10000, Tax: 1597}
+	charge := models.LedgerEntry{DebitAccount: models.AccountCash, CreditAccount: models.AccountCourseRevenue}
+	refund := models.LedgerEntry{DebitAccount: models.AccountRefunds, CreditAccount: models.AccountCash}
+
+	var posted []models.LedgerEntry
+	paid := int64(0)
+	for _, amount := range []int64{3333, 3333, 3334} {
+		charge.Amount = amount
+		posted = append(posted, splitTax(charge, paymentTax(enrollment, paid, paid+amount))...)
+		paid += amount
+	}
+	if got := ledgerTotal(posted, models.AccountTaxPayable); got != enrollment.Tax {
+		t.Errorf("installments carry %d tax, want %d", got, enrollment.Tax)
+	}
+	if got := ledgerTotal(posted, models.AccountCourseRevenue) + ledgerTotal(posted, models.AccountTaxPayable); got != enrollment.Total {
+		t.Errorf("installments add up to %d, want %d", got, enrollment.Total)
+	}
+
+	for _, amount := range []int64{1001, 2999, 6000} {
+		refund.Amount = amount
+		posted = append(posted, splitTax(refund, paymentTax(enrollment, paid, paid-amount))...)
+		paid -= amount
+		if got, want := ledgerTotal(posted, models.AccountTaxPayable), paymentTax(enrollment, 0, paid); got != want {
+			t.Errorf("after refunding down to %d: %d tax payable, want %d", paid, got, want)
+		}
+	}
+	if got := ledgerTotal(posted, models.AccountCourseRevenue) + ledgerTotal(posted, models.AccountTaxPayable) + ledgerTotal(posted, models.AccountRefunds); got != 0 {
+		t.Errorf("fully refunded enrollment leaves %d", got)
+	}
+}
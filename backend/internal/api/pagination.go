@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yoga-studio-app/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// ListParams holds the shared limit/offset/sort query parameters of list endpoints:
+//
+//	?limit=20&offset=40&sort=-start_time
+//
+// A leading "-" on sort means descending. Only keys in the endpoint's sort
+// whitelist are accepted, so clients can never inject arbitrary ORDER BY clauses.
+type ListParams struct {
+	Limit  int
+	Offset int
+	order  string
+}
+
+// ListResponse is the envelope returned by every paginated endpoint
+type ListResponse struct {
+	Items  interface{} `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// sortKeys maps public sort keys to the column they order by
+type sortKeys map[string]string
+
+// parseListParams reads limit, offset and sort from the query string.
+// defaultSort uses the same syntax as the sort parameter.
+func parseListParams(c *gin.Context, allowed sortKeys, defaultSort string) (ListParams, error) {
+	params := ListParams{Limit: defaultPageLimit}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, apperr.Validation(apperr.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxPageLimit)})
+		}
+		params.Limit = limit
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return params, apperr.Validation(apperr.FieldError{Field: "offset", Message: "must be a non-negative integer"})
+		}
+		params.Offset = offset
+	}
+
+	sortParam := c.DefaultQuery("sort", defaultSort)
+	desc := strings.HasPrefix(sortParam, "-")
+	column, ok := allowed[strings.TrimPrefix(sortParam, "-")]
+	if !ok {
+		keys := make([]string, 0, len(allowed))
+		for key := range allowed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return params, apperr.Validation(apperr.FieldError{
+			Field:   "sort",
+			Message: fmt.Sprintf("must be one of: %s (prefix with - for descending)", strings.Join(keys, ", ")),
+		})
+	}
+	params.order = column + " ASC"
+	if desc {
+		params.order = column + " DESC"
+	}
+
+	return params, nil
+}
+
+// Preload names an association to load alongside a page of results, with
+// optional conditions such as a column-limiting scope
+type Preload struct {
+	Association string
+	Conds       []interface{}
+}
+
+// preload builds a Preload for paginate
+func preload(association string, conds ...interface{}) Preload {
+	return Preload{Association: association, Conds: conds}
+}
+
+// paginate counts the rows matched by query, then loads one page of them into
+// dest. Associations in preloads are only loaded for the page, not the count.
+// Rows that tie on the sort key are ordered by primary key, so they don't
+// move between pages.
+func paginate(query *gorm.DB, params ListParams, dest interface{}, preloads ...Preload) (int64, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(dest); err != nil {
+		return 0, err
+	}
+	page := query.Order(params.order)
+	if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+		page = page.Order(clause.OrderByColumn{Column: clause.Column{Table: stmt.Schema.Table, Name: pk.DBName}})
+	}
+	page = page.Limit(params.Limit).Offset(params.Offset)
+	for _, p := range preloads {
+		page = page.Preload(p.Association, p.Conds...)
+	}
+	err := page.Find(dest).Error
+	return total, err
+}
+
+// newListResponse wraps a page of items with its pagination metadata
+func newListResponse(items interface{}, total int64, params ListParams) ListResponse {
+	return ListResponse{Items: items, Total: total, Limit: params.Limit, Offset: params.Offset}
+}
+
+// parseDateParam accepts either RFC 3339 timestamps or plain YYYY-MM-DD dates.
+// Plain dates are midnight in the studio's time zone, or the following
+// midnight when endOfDay is set so that an end date includes its whole day.
+func parseDateParam(c *gin.Context, key string, endOfDay bool) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, studioZone(c)); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, nil
+	}
+	return nil, apperr.Validation(apperr.FieldError{Field: key, Message: "must be a date (YYYY-MM-DD) or RFC 3339 timestamp"})
+}
+
+// parseDateRange reads the start_date and end_date query parameters. A plain
+// end date runs to the end of that day, so end is exclusive.
+func parseDateRange(c *gin.Context) (start, end *time.Time, err error) {
+	if start, err = parseDateParam(c, "start_date", false); err != nil {
+		return nil, nil, err
+	}
+	if end, err = parseDateParam(c, "end_date", true); err != nil {
+		return nil, nil, err
+	}
+	return start, end, nil
+}
+
+// parseBoolParam reads an optional true/false query parameter
+func parseBoolParam(c *gin.Context, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, apperr.Validation(apperr.FieldError{Field: key, Message: "must be true or false"})
+	}
+	return &b, nil
+}
+
+// likePattern escapes LIKE wildcards in user input and wraps it for a substring match
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
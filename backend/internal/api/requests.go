@@ -0,0 +1,879 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"yoga-studio-app/internal/apperr"
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/pricing"
+
+	"github.com/google/uuid"
+)
+
+// Request DTOs decouple the JSON accepted from clients from the GORM models,
+// so server-owned fields such as ID, CreatedBy and IsActive can't be set.
+// Struct tags cover single-field rules; Validate methods cover the rest.
+
+const maxScheduleLength = 24 * time.Hour
+
+// ClassRequest is the body of POST and PUT /admin/classes
+type ClassRequest struct {
+	Title           string                 `json:"title" binding:"required,max=200"`
+	Description     string                 `json:"description" binding:"max=5000"`
+	InstructorID    *uuid.UUID             `json:"instructor_id"`
+	InstructorName  string                 `json:"instructor_name" binding:"required_without=InstructorID,max=200"`
+	Duration        int                    `json:"duration" binding:"gt=0,lte=480"`
+	Capacity        int                    `json:"capacity" binding:"gt=0,lte=500"`
+	DifficultyLevel models.DifficultyLevel `json:"difficulty_level" binding:"required,oneof=beginner intermediate advanced"`
+	ImageURL        string                 `json:"image_url" binding:"omitempty,url,max=2048"`
+}
+
+// Apply copies the request onto a class model. instructor is the user
+// referenced by InstructorID, or nil for a name-only instructor.
+func (r *ClassRequest) Apply(class *models.Class, instructor *models.User) {
+	class.Title = r.Title
+	class.Description = r.Description
+	class.InstructorID = nil
+	class.InstructorName = r.InstructorName
+	if instructor != nil {
+		class.InstructorID = &instructor.ID
+		class.InstructorName = instructor.Name
+	}
+	class.Duration = r.Duration
+	class.Capacity = r.Capacity
+	class.DifficultyLevel = r.DifficultyLevel
+	class.ImageURL = r.ImageURL
+}
+
+// ScheduleRequest is the body of POST and PUT /admin/schedules
+type ScheduleRequest struct {
+	ClassID           uuid.UUID             `json:"class_id" binding:"required"`
+	StartTime         time.Time             `json:"start_time" binding:"required"`
+	EndTime           time.Time             `json:"end_time" binding:"required"`
+	RecurrenceType    models.RecurrenceType `json:"recurrence_type" binding:"omitempty,oneof=once daily weekly monthly"`
+	RecurrenceEndDate *time.Time            `json:"recurrence_end_date"`
+	DayOfWeek         *int                  `json:"day_of_week" binding:"omitempty,min=0,max=6"`
+	DayOfMonth        *int                  `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+	InstructorID      *uuid.UUID            `json:"instructor_id"`
+	RoomID            *uuid.UUID            `json:"room_id"`
+	CourseID          *uuid.UUID            `json:"course_id"` // make the schedule sessions of a course
+
+	// OverrideConflicts saves the schedule even if it overlaps existing ones
+	OverrideConflicts bool `json:"override_conflicts"`
+}
+
+// Validate checks the rules that span several fields
+func (r *ScheduleRequest) Validate() error {
+	var fields []apperr.FieldError
+	invalid := func(field, message string) {
+		fields = append(fields, apperr.FieldError{Field: field, Message: message})
+	}
+
+	if r.RecurrenceType == "" {
+		r.RecurrenceType = models.Once
+	}
+
+	if !r.EndTime.After(r.StartTime) {
+		invalid("end_time", "must be after start_time")
+	} else if r.EndTime.Sub(r.StartTime) > maxScheduleLength {
+		invalid("end_time", "must be within 24 hours of start_time")
+	}
+
+	if r.RecurrenceEndDate != nil {
+		if r.RecurrenceType == models.Once {
+			invalid("recurrence_end_date", "is only allowed for recurring schedules")
+		} else if !r.RecurrenceEndDate.After(r.StartTime) {
+			invalid("recurrence_end_date", "must be after start_time")
+		}
+	}
+
+	if r.DayOfWeek != nil && r.RecurrenceType != models.Weekly {
+		invalid("day_of_week", "is only allowed for weekly schedules")
+	}
+	if r.DayOfMonth != nil && r.RecurrenceType != models.Monthly {
+		invalid("day_of_month", "is only allowed for monthly schedules")
+	}
+
+	// A course has a fixed set of sessions
+	if r.CourseID != nil && r.RecurrenceType != models.Once && r.RecurrenceEndDate == nil {
+		invalid("recurrence_end_date", "is required for course sessions")
+	}
+
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// Apply copies the request onto a schedule model recurring in timezone.
+// A missing day of week or month defaults to the start time's local day.
+func (r *ScheduleRequest) Apply(schedule *models.Schedule, timezone string) {
+	zone := models.LoadZone(timezone)
+	localStart := r.StartTime.In(zone)
+
+	schedule.ClassID = r.ClassID
+	schedule.StartTime = localStart
+	schedule.EndTime = r.EndTime.In(zone)
+	schedule.RecurrenceType = r.RecurrenceType
+	schedule.RecurrenceEndDate = r.RecurrenceEndDate
+	schedule.DayOfWeek = r.DayOfWeek
+	schedule.DayOfMonth = r.DayOfMonth
+	schedule.InstructorID = r.InstructorID
+	schedule.RoomID = r.RoomID
+	schedule.CourseID = r.CourseID
+	schedule.Timezone = timezone
+
+	if r.RecurrenceType == models.Weekly && r.DayOfWeek == nil {
+		day := int(localStart.Weekday())
+		schedule.DayOfWeek = &day
+	}
+	if r.RecurrenceType == models.Monthly && r.DayOfMonth == nil {
+		day := localStart.Day()
+		schedule.DayOfMonth = &day
+	}
+}
+
+// maxSeriesLength bounds how far ahead a series can be booked
+const maxSeriesLength = 183 * 24 * time.Hour
+
+// SeriesBookingRequest is the body of POST /enrollments/series
+type SeriesBookingRequest struct {
+	ScheduleID uuid.UUID `json:"schedule_id" binding:"required"`
+	Until      time.Time `json:"until" binding:"required"` // last session start to book, inclusive
+	// JoinWaitlist waitlists full sessions instead of skipping them; defaults to true
+	JoinWaitlist *bool      `json:"join_waitlist"`
+	UserID       *uuid.UUID `json:"user_id"` // a household member to book for; defaults to the current user
+}
+
+// Validate checks the booking period
+func (r *SeriesBookingRequest) Validate(now time.Time) error {
+	if !r.Until.After(now) {
+		return apperr.Validation(apperr.FieldError{Field: "until", Message: "must be in the future"})
+	}
+	if r.Until.Sub(now) > maxSeriesLength {
+		return apperr.Validation(apperr.FieldError{Field: "until", Message: "must be within 6 months"})
+	}
+	return nil
+}
+
+// Waitlist reports whether full sessions should be waitlisted
+func (r *SeriesBookingRequest) Waitlist() bool {
+	return r.JoinWaitlist == nil || *r.JoinWaitlist
+}
+
+// SubstitutionRequest is the body of POST /substitutions
+type SubstitutionRequest struct {
+	ScheduleID      uuid.UUID `json:"schedule_id" binding:"required"`
+	OccurrenceStart time.Time `json:"occurrence_start" binding:"required"`
+	Reason          string    `json:"reason" binding:"max=1000"`
+}
+
+// SubstitutionDecision is the body of the admin approve and reject actions.
+// SubstituteID lets an admin assign cover directly instead of waiting for a volunteer.
+type SubstitutionDecision struct {
+	SubstituteID *uuid.UUID `json:"substitute_id"`
+	Note         string     `json:"note" binding:"max=1000"`
+}
+
+// OccurrenceCancellationRequest is the body of POST /admin/schedules/:id/cancel-occurrence
+type OccurrenceCancellationRequest struct {
+	OccurrenceStart time.Time `json:"occurrence_start" binding:"required"`
+	Reason          string    `json:"reason" binding:"max=1000"`
+}
+
+// maxClosureLength bounds a single closure so a typo can't cancel a year of classes
+const maxClosureLength = 31 * 24 * time.Hour
+
+// ClosureRequest is the body of POST /admin/closures. A full-day closure runs
+// from midnight to midnight; anything shorter is a partial closure.
+type ClosureRequest struct {
+	Title    string     `json:"title" binding:"required,max=200"`
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   time.Time  `json:"ends_at" binding:"required"`
+	RoomID   *uuid.UUID `json:"room_id"` // close a single room instead of the whole studio
+}
+
+// Validate checks the closure period
+func (r *ClosureRequest) Validate() error {
+	if !r.EndsAt.After(r.StartsAt) {
+		return apperr.Validation(apperr.FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	}
+	if r.EndsAt.Sub(r.StartsAt) > maxClosureLength {
+		return apperr.Validation(apperr.FieldError{Field: "ends_at", Message: "must be within 31 days of starts_at"})
+	}
+	return nil
+}
+
+// LocationRequest is the body of POST and PUT /admin/locations
+type LocationRequest struct {
+	Name     string `json:"name" binding:"required,max=200"`
+	Address  string `json:"address" binding:"max=500"`
+	Notes    string `json:"notes" binding:"max=2000"`
+	Timezone string `json:"timezone" binding:"max=64"` // empty to use the studio's
+
+	PricesIncludeTax bool `json:"prices_include_tax"`
+}
+
+// Validate checks the time zone name
+func (r *LocationRequest) Validate() error {
+	if r.Timezone != "" && !models.ValidTimezone(r.Timezone) {
+		return apperr.Validation(apperr.FieldError{Field: "timezone", Message: "must be an IANA time zone such as Europe/London"})
+	}
+	return nil
+}
+
+// Apply copies the request onto a location model
+func (r *LocationRequest) Apply(location *models.Location) {
+	location.Name = r.Name
+	location.Address = r.Address
+	location.Notes = r.Notes
+	location.Timezone = r.Timezone
+	location.PricesIncludeTax = r.PricesIncludeTax
+}
+
+// RoomRequest is the body of POST /admin/locations/:id/rooms and PUT /admin/rooms/:id
+type RoomRequest struct {
+	Name      string   `json:"name" binding:"required,max=200"`
+	Capacity  int      `json:"capacity" binding:"gt=0,lte=500"`
+	Amenities []string `json:"amenities" binding:"max=50,dive,required,max=100"`
+}
+
+// Apply copies the request onto a room model
+func (r *RoomRequest) Apply(room *models.Room) {
+	room.Name = r.Name
+	room.Capacity = r.Capacity
+	room.Amenities = r.Amenities
+	if room.Amenities == nil {
+		room.Amenities = []string{}
+	}
+}
+
+// TaxRatesRequest is the body of PUT /admin/tax-rates. It replaces the rates
+// of a location, or the studio's default rates when LocationID is omitted.
+type TaxRatesRequest struct {
+	LocationID *uuid.UUID       `json:"location_id"`
+	Rates      []TaxRateRequest `json:"rates" binding:"max=10,dive"`
+}
+
+// TaxRateRequest is one tax, applied in the order given
+type TaxRateRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	BasisPoints int    `json:"basis_points" binding:"gt=0,lte=10000"` // 2000 is 20%
+}
+
+// Build creates the rates in the order given
+func (r *TaxRatesRequest) Build() []models.TaxRate {
+	rates := make([]models.TaxRate, len(r.Rates))
+	for i, rate := range r.Rates {
+		rates[i] = models.TaxRate{
+			LocationID:  r.LocationID,
+			Name:        strings.TrimSpace(rate.Name),
+			BasisPoints: rate.BasisPoints,
+			Position:    i,
+		}
+	}
+	return rates
+}
+
+// slugPattern keeps studio slugs usable in headers and URLs
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// StudioRequest is the body of POST and PUT /super/studios
+type StudioRequest struct {
+	Slug        string   `json:"slug" binding:"required,max=63"`
+	Name        string   `json:"name" binding:"required,max=200"`
+	Hostnames   []string `json:"hostnames" binding:"max=20,dive,hostname_rfc1123,max=253"`
+	FrontendURL string   `json:"frontend_url" binding:"omitempty,url,max=2048"`
+	Timezone    string   `json:"timezone" binding:"max=64"` // defaults to UTC
+	IsActive    *bool    `json:"is_active"`
+
+	PrivateBufferMinutes *int `json:"private_buffer_minutes" binding:"omitempty,min=0,max=240"`
+	PrivateNoticeHours   *int `json:"private_notice_hours" binding:"omitempty,min=0,max=720"`
+
+	RequiredProfileFields []string `json:"required_profile_fields" binding:"max=10"` // omit to keep, [] for none
+
+	LegalName string `json:"legal_name" binding:"max=200"`
+	Address   string `json:"address" binding:"max=500"`
+	TaxID     string `json:"tax_id" binding:"max=50"`
+
+	Currency         string `json:"currency" binding:"max=3"` // ISO 4217; defaults to USD
+	PricesIncludeTax bool   `json:"prices_include_tax"`
+}
+
+// Validate checks the slug format, time zone and currency and normalizes hostnames
+func (r *StudioRequest) Validate() error {
+	if !slugPattern.MatchString(r.Slug) {
+		return apperr.Validation(apperr.FieldError{Field: "slug", Message: "must be lowercase letters, digits and hyphens"})
+	}
+	if r.Timezone == "" {
+		r.Timezone = models.DefaultTimezone
+	} else if !models.ValidTimezone(r.Timezone) {
+		return apperr.Validation(apperr.FieldError{Field: "timezone", Message: "must be an IANA time zone such as Europe/London"})
+	}
+	r.Currency = strings.ToUpper(r.Currency)
+	if r.Currency == "" {
+		r.Currency = pricing.DefaultCurrency
+	} else if !pricing.ValidCurrency(r.Currency) {
+		return apperr.Validation(apperr.FieldError{Field: "currency", Message: "must be a supported ISO 4217 currency code such as EUR"})
+	}
+	for i, host := range r.Hostnames {
+		r.Hostnames[i] = strings.ToLower(host)
+	}
+	for _, name := range r.RequiredProfileFields {
+		if field, ok := models.LookupProfileField(name); !ok || !field.Bookable {
+			return apperr.Validation(apperr.FieldError{Field: "required_profile_fields", Message: fmt.Sprintf("%q can't be required before booking", name)})
+		}
+	}
+	return nil
+}
+
+// Apply copies the request onto a studio model
+func (r *StudioRequest) Apply(studio *models.Studio) {
+	studio.Slug = r.Slug
+	studio.Name = r.Name
+	studio.Hostnames = r.Hostnames
+	if studio.Hostnames == nil {
+		studio.Hostnames = []string{}
+	}
+	studio.FrontendURL = strings.TrimRight(r.FrontendURL, "/")
+	studio.Timezone = r.Timezone
+	if r.IsActive != nil {
+		studio.IsActive = *r.IsActive
+	}
+	if r.PrivateBufferMinutes != nil {
+		studio.PrivateBufferMinutes = *r.PrivateBufferMinutes
+	}
+	if r.PrivateNoticeHours != nil {
+		studio.PrivateNoticeHours = *r.PrivateNoticeHours
+	}
+	if r.RequiredProfileFields != nil {
+		studio.RequiredProfileFields = r.RequiredProfileFields
+	}
+	studio.LegalName = r.LegalName
+	studio.Address = r.Address
+	studio.TaxID = r.TaxID
+	studio.Currency = r.Currency
+	studio.PricesIncludeTax = r.PricesIncludeTax
+}
+
+// CourseRequest is the body of POST and PUT /admin/courses. Amounts are in
+// minor units of the studio's currency.
+type CourseRequest struct {
+	Title                string     `json:"title" binding:"required,max=200"`
+	Description          string     `json:"description" binding:"max=10000"`
+	Capacity             int        `json:"capacity" binding:"gt=0,lte=500"`
+	RequiresApplication  bool       `json:"requires_application"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	Price                int64      `json:"price" binding:"gte=0"`
+	Deposit              int64      `json:"deposit" binding:"gte=0"`
+	Installments         int        `json:"installments" binding:"omitempty,min=1,max=24"` // defaults to 1
+	CertificationHours   float64    `json:"certification_hours" binding:"gte=0,lte=2000"`
+	LocationID           *uuid.UUID `json:"location_id"` // where the course is taxed; omit for the studio's default rates
+	IsActive             *bool      `json:"is_active"`
+}
+
+// Validate checks the registration window and payment plan
+func (r *CourseRequest) Validate() error {
+	var fields []apperr.FieldError
+	if r.RegistrationOpensAt != nil && r.RegistrationClosesAt != nil && !r.RegistrationClosesAt.After(*r.RegistrationOpensAt) {
+		fields = append(fields, apperr.FieldError{Field: "registration_closes_at", Message: "must be after registration_opens_at"})
+	}
+	if r.Deposit > r.Price {
+		fields = append(fields, apperr.FieldError{Field: "deposit", Message: "must not exceed price"})
+	}
+	if r.Installments == 0 {
+		r.Installments = 1
+	}
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// Apply copies the request onto a course model
+func (r *CourseRequest) Apply(course *models.Course) {
+	course.Title = r.Title
+	course.Description = r.Description
+	course.Capacity = r.Capacity
+	course.RequiresApplication = r.RequiresApplication
+	course.RegistrationOpensAt = r.RegistrationOpensAt
+	course.RegistrationClosesAt = r.RegistrationClosesAt
+	course.Price = r.Price
+	course.Deposit = r.Deposit
+	course.Installments = r.Installments
+	course.CertificationHours = r.CertificationHours
+	course.LocationID = r.LocationID
+	if r.IsActive != nil {
+		course.IsActive = *r.IsActive
+	}
+}
+
+// CourseApplicationRequest is the body of POST /courses/:id/enroll
+type CourseApplicationRequest struct {
+	Application  string     `json:"application" binding:"max=5000"` // why the student wants to join, for courses that require an application
+	PromoCode    string     `json:"promo_code" binding:"max=32"`
+	GiftCardCode string     `json:"gift_card_code" binding:"max=32"` // pays as much of the course as the card's balance covers
+	UserID       *uuid.UUID `json:"user_id"`                         // a household member to enroll; defaults to the current user
+}
+
+// CourseDecisionRequest is the body of PUT /admin/courses/:id/enrollments/:enrollmentId
+type CourseDecisionRequest struct {
+	Status models.CourseEnrollmentStatus `json:"status" binding:"required,oneof=enrolled rejected withdrawn"`
+	Note   string                        `json:"note" binding:"max=1000"`
+}
+
+// CoursePaymentRequest is the body of POST /admin/courses/:id/enrollments/:enrollmentId/payments
+type CoursePaymentRequest struct {
+	Amount    int64      `json:"amount" binding:"gt=0"` // in cents
+	Note      string     `json:"note" binding:"max=500"`
+	Reference string     `json:"reference" binding:"max=200"` // payment provider's transaction ID
+	PaidAt    *time.Time `json:"paid_at"`                     // defaults to now
+}
+
+// RefundRequest is the body of POST /admin/courses/:id/enrollments/:enrollmentId/payments/:paymentId/refunds
+type RefundRequest struct {
+	Amount    int64  `json:"amount" binding:"gt=0"` // in cents, at most what is left of the payment
+	Reason    string `json:"reason" binding:"required,max=500"`
+	Reference string `json:"reference" binding:"max=200"` // payment provider's refund ID
+}
+
+// AttendanceRequest is the body of PUT /admin/courses/:id/attendance: the
+// register for one session of the course
+type AttendanceRequest struct {
+	ScheduleID      uuid.UUID      `json:"schedule_id" binding:"required"`
+	OccurrenceStart time.Time      `json:"occurrence_start" binding:"required"`
+	Attendees       []AttendeeMark `json:"attendees" binding:"required,max=500,dive"`
+}
+
+// AttendeeMark records one student on a session register
+type AttendeeMark struct {
+	EnrollmentID uuid.UUID `json:"enrollment_id" binding:"required"`
+	Present      bool      `json:"present"`
+}
+
+// clockPattern matches a 24-hour wall-clock time such as 09:30
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// AvailabilityRequest is the body of PUT /private-sessions/availability. It
+// replaces the instructor's weekly windows.
+type AvailabilityRequest struct {
+	InstructorID *uuid.UUID                  `json:"instructor_id"` // admins only; defaults to the current user
+	Windows      []AvailabilityWindowRequest `json:"windows" binding:"max=100,dive"`
+}
+
+// AvailabilityWindowRequest is one weekly window, in the studio's local time
+type AvailabilityWindowRequest struct {
+	DayOfWeek  *int       `json:"day_of_week" binding:"required,min=0,max=6"`
+	Opens      string     `json:"opens" binding:"required"`  // HH:MM
+	Closes     string     `json:"closes" binding:"required"` // HH:MM
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// Validate checks the times of every window
+func (r *AvailabilityRequest) Validate() error {
+	var fields []apperr.FieldError
+	invalid := func(i int, field, message string) {
+		fields = append(fields, apperr.FieldError{Field: fmt.Sprintf("windows[%d].%s", i, field), Message: message})
+	}
+
+	for i, w := range r.Windows {
+		if !clockPattern.MatchString(w.Opens) {
+			invalid(i, "opens", "must be a time such as 09:00")
+		}
+		if !clockPattern.MatchString(w.Closes) {
+			invalid(i, "closes", "must be a time such as 17:30")
+		} else if clockPattern.MatchString(w.Opens) && w.Closes <= w.Opens {
+			invalid(i, "closes", "must be after opens")
+		}
+		if w.ValidFrom != nil && w.ValidUntil != nil && !w.ValidUntil.After(*w.ValidFrom) {
+			invalid(i, "valid_until", "must be after valid_from")
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// Build creates the weekly windows of instructorID
+func (r *AvailabilityRequest) Build(instructorID uuid.UUID) []models.AvailabilityWindow {
+	windows := make([]models.AvailabilityWindow, len(r.Windows))
+	for i, w := range r.Windows {
+		windows[i] = models.AvailabilityWindow{
+			InstructorID: instructorID,
+			DayOfWeek:    *w.DayOfWeek,
+			Opens:        w.Opens,
+			Closes:       w.Closes,
+			ValidFrom:    w.ValidFrom,
+			ValidUntil:   w.ValidUntil,
+		}
+	}
+	return windows
+}
+
+// maxExceptionLength bounds a single availability exception
+const maxExceptionLength = 31 * 24 * time.Hour
+
+// AvailabilityExceptionRequest is the body of POST /private-sessions/availability/exceptions
+type AvailabilityExceptionRequest struct {
+	InstructorID *uuid.UUID `json:"instructor_id"` // admins only; defaults to the current user
+	StartsAt     time.Time  `json:"starts_at" binding:"required"`
+	EndsAt       time.Time  `json:"ends_at" binding:"required"`
+	Available    bool       `json:"available"` // true adds time outside the weekly windows
+	Reason       string     `json:"reason" binding:"max=500"`
+}
+
+// Validate checks the exception period
+func (r *AvailabilityExceptionRequest) Validate() error {
+	if !r.EndsAt.After(r.StartsAt) {
+		return apperr.Validation(apperr.FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	}
+	if r.EndsAt.Sub(r.StartsAt) > maxExceptionLength {
+		return apperr.Validation(apperr.FieldError{Field: "ends_at", Message: "must be within 31 days of starts_at"})
+	}
+	return nil
+}
+
+// PrivateSessionRequest is the body of POST /private-sessions
+type PrivateSessionRequest struct {
+	InstructorID uuid.UUID  `json:"instructor_id" binding:"required"`
+	ClassID      uuid.UUID  `json:"class_id" binding:"required"`
+	StartTime    time.Time  `json:"start_time" binding:"required"`
+	Duration     int        `json:"duration" binding:"required,min=15,max=480"` // in minutes
+	Capacity     int        `json:"capacity" binding:"omitempty,oneof=1 2"`     // 2 for a semi-private session; defaults to 1
+	UserID       *uuid.UUID `json:"user_id"`                                    // a household member to book for
+}
+
+// JoinPrivateSessionRequest is the body of POST /private-sessions/join
+type JoinPrivateSessionRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// promoCodePattern keeps promo codes easy to type and read out
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// PromoCodeRequest is the body of POST and PUT /admin/promo-codes
+type PromoCodeRequest struct {
+	Code           string              `json:"code" binding:"required,max=32"`
+	Description    string              `json:"description" binding:"max=500"`
+	DiscountType   models.DiscountType `json:"discount_type" binding:"required,oneof=percent fixed"`
+	Amount         int64               `json:"amount" binding:"gt=0"` // percent off, or minor units of the studio's currency off
+	MaxRedemptions *int                `json:"max_redemptions" binding:"omitempty,min=1"`
+	PerUserLimit   *int                `json:"per_user_limit" binding:"omitempty,min=1"`
+	ValidFrom      *time.Time          `json:"valid_from"`
+	ValidUntil     *time.Time          `json:"valid_until"`
+	CourseIDs      []uuid.UUID         `json:"course_ids" binding:"max=100"`
+	IsActive       *bool               `json:"is_active"`
+}
+
+// Validate normalizes the code and checks the discount and validity window
+func (r *PromoCodeRequest) Validate() error {
+	var fields []apperr.FieldError
+	r.Code = strings.ToUpper(strings.TrimSpace(r.Code))
+	if !promoCodePattern.MatchString(r.Code) {
+		fields = append(fields, apperr.FieldError{Field: "code", Message: "must be 3-32 letters, digits, hyphens or underscores"})
+	}
+	if r.DiscountType == models.DiscountPercent && r.Amount > 100 {
+		fields = append(fields, apperr.FieldError{Field: "amount", Message: "must be at most 100 for percent discounts"})
+	}
+	if r.ValidFrom != nil && r.ValidUntil != nil && !r.ValidUntil.After(*r.ValidFrom) {
+		fields = append(fields, apperr.FieldError{Field: "valid_until", Message: "must be after valid_from"})
+	}
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// Apply copies the request onto a promo code model
+func (r *PromoCodeRequest) Apply(promo *models.PromoCode) {
+	promo.Code = r.Code
+	promo.Description = r.Description
+	promo.DiscountType = r.DiscountType
+	promo.Amount = r.Amount
+	promo.MaxRedemptions = r.MaxRedemptions
+	promo.PerUserLimit = r.PerUserLimit
+	promo.ValidFrom = r.ValidFrom
+	promo.ValidUntil = r.ValidUntil
+	promo.CourseIDs = make([]string, len(r.CourseIDs))
+	for i, id := range r.CourseIDs {
+		promo.CourseIDs[i] = id.String()
+	}
+	if r.IsActive != nil {
+		promo.IsActive = *r.IsActive
+	}
+}
+
+// GiftCardRequest is the body of POST /admin/gift-cards
+type GiftCardRequest struct {
+	InitialBalance int64      `json:"initial_balance" binding:"gt=0,lte=10000000"` // in minor units of the studio's currency
+	IssuedTo       string     `json:"issued_to" binding:"max=200"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Reference      string     `json:"reference" binding:"max=200"` // payment provider's transaction ID for the sale
+	PurchaserID    *uuid.UUID `json:"purchaser_id"`                // the client who bought it, who is emailed the receipt
+}
+
+// GiftCardUpdateRequest is the body of PUT /admin/gift-cards/:id. The
+// balance only changes through redemptions.
+type GiftCardUpdateRequest struct {
+	IssuedTo  string     `json:"issued_to" binding:"max=200"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	IsActive  *bool      `json:"is_active"`
+}
+
+// GiftCardRedemptionRequest is the body of POST /courses/:id/enroll/gift-card
+type GiftCardRedemptionRequest struct {
+	Code   string     `json:"code" binding:"required,max=32"`
+	UserID *uuid.UUID `json:"user_id"` // the household member whose place is paid for
+}
+
+// ReconcileRequest is the body of POST /admin/ledger/reconcile: the payment
+// provider's statement for the period being reconciled
+type ReconcileRequest struct {
+	Transactions []ProviderTransaction `json:"transactions" binding:"required,max=10000,dive"`
+}
+
+// ProviderTransaction is one line of a payment provider's statement
+type ProviderTransaction struct {
+	Reference string `json:"reference" binding:"required,max=200"`
+	Kind      string `json:"kind" binding:"required,oneof=charge refund"`
+	Amount    int64  `json:"amount" binding:"gt=0"` // in cents
+}
+
+// ReceiptReissueRequest is the optional body of POST /admin/receipts/:id/reissue
+type ReceiptReissueRequest struct {
+	Email string `json:"email" binding:"omitempty,email,max=254"` // send to this address instead of the client's
+}
+
+// FormVersionRequest is the body of POST /admin/forms: a new version of the
+// studio's waiver or intake form, which replaces the current one
+type FormVersionRequest struct {
+	Kind      models.FormKind         `json:"kind" binding:"required,oneof=waiver intake"`
+	Title     string                  `json:"title" binding:"required,max=200"`
+	Body      string                  `json:"body" binding:"required,max=50000"`
+	Questions []IntakeQuestionRequest `json:"questions" binding:"max=100,dive"`
+}
+
+// IntakeQuestionRequest is one question on an intake form, asked in the order given
+type IntakeQuestionRequest struct {
+	Prompt     string            `json:"prompt" binding:"required,max=500"`
+	AnswerType models.AnswerType `json:"answer_type" binding:"required,oneof=yes_no text"`
+	Required   bool              `json:"required"`
+	HealthFlag bool              `json:"health_flag"` // show a yes, or any text, to the client's instructors
+}
+
+// Validate checks that only intake forms ask questions
+func (r *FormVersionRequest) Validate() error {
+	if r.Kind == models.FormWaiver && len(r.Questions) > 0 {
+		return apperr.Validation(apperr.FieldError{Field: "questions", Message: "are only asked on intake forms"})
+	}
+	if r.Kind == models.FormIntake && len(r.Questions) == 0 {
+		return apperr.Validation(apperr.FieldError{Field: "questions", Message: "must include at least one question"})
+	}
+	return nil
+}
+
+// Build creates the form as version of its kind
+func (r *FormVersionRequest) Build(version int, publishedBy uuid.UUID) models.FormVersion {
+	form := models.FormVersion{
+		Kind:        r.Kind,
+		Version:     version,
+		Title:       r.Title,
+		Body:        r.Body,
+		PublishedBy: publishedBy,
+		Questions:   make([]models.IntakeQuestion, len(r.Questions)),
+	}
+	for i, q := range r.Questions {
+		form.Questions[i] = models.IntakeQuestion{
+			Position:   i + 1,
+			Prompt:     strings.TrimSpace(q.Prompt),
+			AnswerType: q.AnswerType,
+			Required:   q.Required,
+			HealthFlag: q.HealthFlag,
+		}
+	}
+	return form
+}
+
+// FormSignatureRequest is the body of POST /forms/:id/sign
+type FormSignatureRequest struct {
+	SignedName string                `json:"signed_name" binding:"required,max=200"` // the client's full name, typed as their signature
+	Agree      bool                  `json:"agree"`                                  // must be true
+	Answers    []IntakeAnswerRequest `json:"answers" binding:"max=100,dive"`
+	UserID     *uuid.UUID            `json:"user_id"` // a household dependent to sign for
+}
+
+// IntakeAnswerRequest answers one intake question
+type IntakeAnswerRequest struct {
+	QuestionID uuid.UUID `json:"question_id" binding:"required"`
+	Answer     string    `json:"answer" binding:"max=2000"`
+}
+
+// BuildAnswers checks the answers against the questions of form and creates
+// them in question order. Yes/no answers must be "yes" or "no".
+func (r *FormSignatureRequest) BuildAnswers(form *models.FormVersion) ([]models.IntakeAnswer, error) {
+	var fields []apperr.FieldError
+	if !r.Agree {
+		fields = append(fields, apperr.FieldError{Field: "agree", Message: "must be true to sign"})
+	}
+	if strings.TrimSpace(r.SignedName) == "" {
+		fields = append(fields, apperr.FieldError{Field: "signed_name", Message: "is required"})
+	}
+
+	given := make(map[uuid.UUID]string, len(r.Answers))
+	for i, a := range r.Answers {
+		given[a.QuestionID] = strings.TrimSpace(a.Answer)
+		known := false
+		for _, q := range form.Questions {
+			known = known || q.ID == a.QuestionID
+		}
+		if !known {
+			fields = append(fields, apperr.FieldError{Field: fmt.Sprintf("answers[%d].question_id", i), Message: "is not a question on this form"})
+		}
+	}
+
+	answers := []models.IntakeAnswer{}
+	for _, q := range form.Questions {
+		answer := given[q.ID]
+		field := fmt.Sprintf("answers[%s]", q.ID)
+		switch {
+		case answer == "" && q.Required:
+			fields = append(fields, apperr.FieldError{Field: field, Message: "is required"})
+			continue
+		case answer == "":
+			continue // optional and left blank
+		case q.AnswerType == models.AnswerYesNo && answer != "yes" && answer != "no":
+			fields = append(fields, apperr.FieldError{Field: field, Message: "must be yes or no"})
+			continue
+		}
+		answers = append(answers, models.IntakeAnswer{
+			QuestionID: q.ID,
+			Position:   q.Position,
+			Prompt:     q.Prompt,
+			Answer:     answer,
+			Flagged:    q.Flags(answer),
+		})
+	}
+
+	if len(fields) > 0 {
+		return nil, apperr.Validation(fields...)
+	}
+	return answers, nil
+}
+
+// ProfileRequest is the body of PUT /users/me. Fields left out are kept;
+// send "" to clear one.
+type ProfileRequest struct {
+	Name                         *string                      `json:"name" binding:"omitempty,min=1,max=200"`
+	Phone                        *string                      `json:"phone" binding:"omitempty,max=32"`
+	EmergencyContactName         *string                      `json:"emergency_contact_name" binding:"omitempty,max=200"`
+	EmergencyContactPhone        *string                      `json:"emergency_contact_phone" binding:"omitempty,max=32"`
+	EmergencyContactRelationship *string                      `json:"emergency_contact_relationship" binding:"omitempty,max=100"`
+	DateOfBirth                  *string                      `json:"date_of_birth"` // YYYY-MM-DD
+	Pronouns                     *string                      `json:"pronouns" binding:"omitempty,max=50"`
+	Injuries                     *string                      `json:"injuries" binding:"omitempty,max=2000"`
+	Notes                        *string                      `json:"notes" binding:"omitempty,max=2000"`
+	MarketingConsent             *bool                        `json:"marketing_consent"`
+	Visibility                   map[string]models.Visibility `json:"visibility" binding:"omitempty,dive,oneof=self admin instructor"`
+}
+
+// Validate checks the date of birth and the visibility of each field
+// against the least it may be shared with
+func (r *ProfileRequest) Validate(now time.Time) error {
+	if r.DateOfBirth != nil && *r.DateOfBirth != "" {
+		born, err := time.Parse("2006-01-02", *r.DateOfBirth)
+		if err != nil || born.After(now) || born.Year() < 1900 {
+			return apperr.Validation(apperr.FieldError{Field: "date_of_birth", Message: "must be a past date formatted YYYY-MM-DD"})
+		}
+	}
+	for name, visibility := range r.Visibility {
+		field, ok := models.LookupProfileField(name)
+		if !ok {
+			return apperr.Validation(apperr.FieldError{Field: "visibility." + name, Message: "is not a profile field"})
+		}
+		if !visibility.Includes(field.Minimum) {
+			return apperr.Validation(apperr.FieldError{Field: "visibility." + name, Message: fmt.Sprintf("must be visible to at least %s", field.Minimum)})
+		}
+	}
+	return nil
+}
+
+// Apply copies the fields sent onto profile. Consent changes are timestamped.
+func (r *ProfileRequest) Apply(profile *models.Profile, now time.Time) {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = strings.TrimSpace(*src)
+		}
+	}
+	set(&profile.Phone, r.Phone)
+	set(&profile.EmergencyContactName, r.EmergencyContactName)
+	set(&profile.EmergencyContactPhone, r.EmergencyContactPhone)
+	set(&profile.EmergencyContactRelationship, r.EmergencyContactRelationship)
+	set(&profile.Pronouns, r.Pronouns)
+	set(&profile.Injuries, r.Injuries)
+	set(&profile.Notes, r.Notes)
+	if r.DateOfBirth != nil {
+		profile.DateOfBirth = nil
+		if born, err := time.Parse("2006-01-02", *r.DateOfBirth); err == nil {
+			profile.DateOfBirth = &born
+		}
+	}
+	if r.MarketingConsent != nil && *r.MarketingConsent != profile.MarketingConsent {
+		profile.MarketingConsent = *r.MarketingConsent
+		profile.MarketingConsentAt = &now
+	}
+	if len(r.Visibility) > 0 {
+		visibility := map[string]models.Visibility{}
+		for name, v := range profile.Visibility {
+			visibility[name] = v
+		}
+		for name, v := range r.Visibility {
+			visibility[name] = v
+		}
+		profile.Visibility = visibility
+	}
+}
+
+// HouseholdRequest is the body of POST /households
+type HouseholdRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+// DependentRequest is the body of POST /households/me/dependents: the
+// dependent's name and, optionally, the rest of their profile
+type DependentRequest struct {
+	ProfileRequest
+}
+
+// Validate requires a name, then checks the profile
+func (r *DependentRequest) Validate(now time.Time) error {
+	if r.Name == nil {
+		return apperr.Validation(apperr.FieldError{Field: "name", Message: "is required"})
+	}
+	return r.ProfileRequest.Validate(now)
+}
+
+// JoinHouseholdRequest is the body of POST /households/join
+type JoinHouseholdRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// CreditPaymentRequest is the body of POST /courses/:id/enroll/credit
+type CreditPaymentRequest struct {
+	UserID *uuid.UUID `json:"user_id"` // the household member whose place is paid for
+}
+
+// HouseholdCreditRequest is the body of POST /admin/households/:id/credit.
+// Amounts are in minor units of the studio's currency.
+type HouseholdCreditRequest struct {
+	Amount    int64      `json:"amount" binding:"gt=0,lte=10000000"`
+	Note      string     `json:"note" binding:"max=500"`
+	Reference string     `json:"reference" binding:"max=200"` // payment provider's transaction ID
+	UserID    *uuid.UUID `json:"user_id"`                     // the member who paid; defaults to whoever started the household
+}
@@ -1,20 +1,34 @@
 package api
 
 import (
+	"yoga-studio-app/internal/auth"
+	"yoga-studio-app/internal/config"
+	"yoga-studio-app/internal/metrics"
 	"yoga-studio-app/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	tokens := auth.NewTokenManager(cfg.JWT)
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "message": "Yoga Studio API is running"})
 	})
 
-	// API v1 group
+	// Kubernetes probes
+	healthHandler := NewHealthHandler(db)
+	router.GET("/livez", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+
+	// Prometheus scrape endpoint
+	router.GET("/metrics", metrics.Handler())
+
+	// API v1 group, scoped to the studio named by the X-Studio header or hostname
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.StudioMiddleware(db, cfg.DefaultStudio))
 	{
 		// Public routes
 		public := v1.Group("")
@@ -22,7 +36,7 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 			// Auth routes
 			auth := public.Group("/auth")
 			{
-				authHandler := NewAuthHandler(db)
+				authHandler := NewAuthHandler(db, tokens, cfg.FrontendURL)
 				auth.GET("/google", authHandler.GoogleLogin)
 				auth.GET("/google/callback", authHandler.GoogleCallback)
 				auth.GET("/facebook", authHandler.FacebookLogin)
@@ -43,9 +57,41 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 			{
 				scheduleHandler := NewScheduleHandler(db)
 				schedules.GET("", scheduleHandler.GetAll)
+				schedules.GET("/occurrences", scheduleHandler.GetOccurrences)
+				schedules.GET("/occurrences.ics", scheduleHandler.GetCalendar)
 				schedules.GET("/:id", scheduleHandler.GetByID)
 			}
 
+			// Locations and rooms (public read)
+			locations := public.Group("/locations")
+			{
+				locationHandler := NewLocationHandler(db)
+				locations.GET("", locationHandler.GetAll)
+			}
+
+			// Studio closures (public read)
+			closures := public.Group("/closures")
+			{
+				closureHandler := NewClosureHandler(db)
+				closures.GET("", closureHandler.GetAll)
+			}
+
+			// Courses and workshops (public read)
+			courses := public.Group("/courses")
+			{
+				courseHandler := NewCourseHandler(db)
+				courses.GET("", courseHandler.GetAll)
+				courses.GET("/:id", courseHandler.GetByID)
+			}
+
+			// Private sessions: instructor availability and open slots (public read)
+			privateSessions := public.Group("/private-sessions")
+			{
+				privateSessionHandler := NewPrivateSessionHandler(db)
+				privateSessions.GET("/availability", privateSessionHandler.GetAvailability)
+				privateSessions.GET("/slots", privateSessionHandler.GetSlots)
+			}
+
 			// Content (public read)
 			content := public.Group("/content")
 			{
@@ -63,18 +109,37 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 
 		// Protected routes (require authentication)
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(tokens))
 		{
+			formHandler := NewFormHandler(db)
+
 			// User routes
 			users := protected.Group("/users")
 			{
 				userHandler := NewUserHandler(db)
 				users.GET("/me", userHandler.GetProfile)
 				users.PUT("/me", userHandler.UpdateProfile)
+				users.GET("/me/profile-fields", userHandler.GetProfileFields)
 				users.POST("/me/avatar", userHandler.UploadAvatar)
 				users.PUT("/me/instructor-bio", userHandler.UpdateInstructorBio)
+
+				receiptHandler := NewReceiptHandler(db)
+				users.GET("/me/receipts", receiptHandler.GetMine)
+				users.GET("/me/receipts/:id/pdf", receiptHandler.GetMyPDF)
+
+				users.GET("/me/forms", formHandler.GetMine)
+			}
+
+			// Waivers and intake forms to sign before booking
+			forms := protected.Group("/forms")
+			{
+				forms.GET("/current", formHandler.GetCurrent)
+				forms.POST("/:id/sign", formHandler.Sign)
 			}
 
+			// Session rosters with health flags, for the instructor teaching
+			protected.GET("/schedules/:id/roster", formHandler.GetRoster)
+
 			// Enrollments
 			enrollments := protected.Group("/enrollments")
 			{
@@ -82,12 +147,94 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 				enrollments.POST("", enrollmentHandler.Create)
 				enrollments.GET("/my", enrollmentHandler.GetMyEnrollments)
 				enrollments.DELETE("/:id", enrollmentHandler.Cancel)
+				enrollments.POST("/series", enrollmentHandler.BookSeries)
+				enrollments.GET("/series/:id", enrollmentHandler.GetSeries)
+				enrollments.DELETE("/series/:id", enrollmentHandler.CancelSeries)
+			}
+
+			// Course enrollments
+			myCourses := protected.Group("/courses")
+			{
+				courseHandler := NewCourseHandler(db)
+				myCourses.GET("/my", courseHandler.GetMine)
+				myCourses.POST("/:id/enroll", courseHandler.Enroll)
+				myCourses.DELETE("/:id/enroll", courseHandler.Withdraw)
+				myCourses.POST("/:id/enroll/gift-card", courseHandler.RedeemGiftCard)
+				myCourses.POST("/:id/enroll/credit", courseHandler.PayWithCredit)
+			}
+
+			// Households: guardians, dependents and shared credit
+			households := protected.Group("/households")
+			{
+				householdHandler := NewHouseholdHandler(db)
+				households.POST("", householdHandler.Create)
+				households.POST("/join", householdHandler.Join)
+				households.GET("/me", householdHandler.GetMine)
+				households.POST("/me/invite", householdHandler.Invite)
+				households.POST("/me/leave", householdHandler.Leave)
+				households.GET("/me/credit", householdHandler.GetCredit)
+				households.POST("/me/dependents", householdHandler.AddDependent)
+				households.GET("/me/dependents/:id", householdHandler.GetDependent)
+				households.PUT("/me/dependents/:id", householdHandler.UpdateDependent)
+			}
+
+			// Promo code previews and gift card balances
+			promotions := protected.Group("")
+			{
+				promotionHandler := NewPromotionHandler(db)
+				promotions.GET("/promo-codes/check", promotionHandler.CheckPromoCode)
+				promotions.GET("/gift-cards/balance", promotionHandler.GetGiftCardBalance)
+			}
+
+			// Private session booking and instructor availability
+			privateSessions := protected.Group("/private-sessions")
+			{
+				privateSessionHandler := NewPrivateSessionHandler(db)
+				privateSessions.POST("", privateSessionHandler.Book)
+				privateSessions.GET("/my", privateSessionHandler.GetMine)
+				privateSessions.POST("/join", privateSessionHandler.Join)
+				privateSessions.GET("/:id", privateSessionHandler.GetByID)
+				privateSessions.PUT("/availability", privateSessionHandler.SetAvailability)
+				privateSessions.POST("/availability/exceptions", privateSessionHandler.AddException)
+				privateSessions.DELETE("/availability/exceptions/:id", privateSessionHandler.DeleteException)
+			}
+
+			// Substitute instructor requests
+			substitutions := protected.Group("/substitutions")
+			{
+				substitutionHandler := NewSubstitutionHandler(db)
+				substitutions.POST("", substitutionHandler.Request)
+				substitutions.GET("/open", substitutionHandler.GetOpen)
+				substitutions.GET("/my", substitutionHandler.GetMine)
+				substitutions.POST("/:id/accept", substitutionHandler.Accept)
+				substitutions.POST("/:id/cancel", substitutionHandler.Cancel)
+			}
+
+			// In-app notifications
+			notifications := protected.Group("/notifications")
+			{
+				notificationHandler := NewNotificationHandler(db)
+				notifications.GET("", notificationHandler.GetMine)
+				notifications.PUT("/:id/read", notificationHandler.MarkRead)
+			}
+		}
+
+		// Super admin routes (manage the studios themselves)
+		super := v1.Group("/super")
+		super.Use(middleware.AuthMiddleware(tokens), middleware.SuperAdminMiddleware())
+		{
+			studios := super.Group("/studios")
+			{
+				studioHandler := NewStudioHandler(db)
+				studios.GET("", studioHandler.GetAll)
+				studios.POST("", studioHandler.Create)
+				studios.PUT("/:id", studioHandler.Update)
 			}
 		}
 
 		// Admin routes (require admin role)
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+		admin.Use(middleware.AuthMiddleware(tokens), middleware.AdminMiddleware())
 		{
 			// Classes management
 			classes := admin.Group("/classes")
@@ -105,8 +252,86 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 				schedules.POST("", scheduleHandler.Create)
 				schedules.PUT("/:id", scheduleHandler.Update)
 				schedules.DELETE("/:id", scheduleHandler.Delete)
+				schedules.POST("/:id/cancel-occurrence", scheduleHandler.CancelOccurrence)
+			}
+
+			// Courses, rosters, payments and attendance
+			courses := admin.Group("/courses")
+			{
+				courseHandler := NewCourseHandler(db)
+				courses.POST("", courseHandler.Create)
+				courses.PUT("/:id", courseHandler.Update)
+				courses.GET("/:id/enrollments", courseHandler.GetRoster)
+				courses.PUT("/:id/enrollments/:enrollmentId", courseHandler.Decide)
+				courses.POST("/:id/enrollments/:enrollmentId/payments", courseHandler.RecordPayment)
+				courses.POST("/:id/enrollments/:enrollmentId/payments/:paymentId/refunds", courseHandler.RefundPayment)
+				courses.PUT("/:id/attendance", courseHandler.RecordAttendance)
+			}
+
+			// Promo codes, gift cards and their redemptions
+			promotionHandler := NewPromotionHandler(db)
+			promoCodes := admin.Group("/promo-codes")
+			{
+				promoCodes.GET("", promotionHandler.GetPromoCodes)
+				promoCodes.POST("", promotionHandler.CreatePromoCode)
+				promoCodes.PUT("/:id", promotionHandler.UpdatePromoCode)
+				promoCodes.DELETE("/:id", promotionHandler.DeletePromoCode)
+				promoCodes.GET("/:id/redemptions", promotionHandler.GetPromoRedemptions)
+			}
+			giftCards := admin.Group("/gift-cards")
+			{
+				giftCards.GET("", promotionHandler.GetGiftCards)
+				giftCards.POST("", promotionHandler.CreateGiftCard)
+				giftCards.PUT("/:id", promotionHandler.UpdateGiftCard)
+				giftCards.GET("/:id/redemptions", promotionHandler.GetGiftCardRedemptions)
+			}
+			admin.GET("/promotions/report", promotionHandler.GetReport)
+
+			// Receipts
+			adminReceipts := admin.Group("/receipts")
+			{
+				receiptHandler := NewReceiptHandler(db)
+				adminReceipts.GET("", receiptHandler.GetAll)
+				adminReceipts.GET("/:id/pdf", receiptHandler.GetPDF)
+				adminReceipts.POST("/:id/reissue", receiptHandler.Reissue)
 			}
 
+			// Ledger of money movements, verification and reconciliation
+			ledger := admin.Group("/ledger")
+			{
+				ledgerHandler := NewLedgerHandler(db)
+				ledger.GET("", ledgerHandler.GetEntries)
+				ledger.GET("/balances", ledgerHandler.GetBalances)
+				ledger.GET("/verify", ledgerHandler.Verify)
+				ledger.POST("/reconcile", ledgerHandler.Reconcile)
+			}
+
+			// Tax rates of each location and the studio's defaults
+			taxRates := admin.Group("/tax-rates")
+			{
+				taxHandler := NewTaxHandler(db)
+				taxRates.GET("", taxHandler.GetRates)
+				taxRates.PUT("", taxHandler.SetRates)
+			}
+
+			// Households and their credit
+			adminHouseholds := admin.Group("/households")
+			{
+				householdHandler := NewHouseholdHandler(db)
+				adminHouseholds.GET("", householdHandler.GetAll)
+				adminHouseholds.POST("/:id/credit", householdHandler.AddCredit)
+			}
+
+			// Waiver and intake form versions and their signatures
+			formHandler := NewFormHandler(db)
+			forms := admin.Group("/forms")
+			{
+				forms.GET("", formHandler.GetVersions)
+				forms.POST("", formHandler.Publish)
+				forms.GET("/:id/signatures", formHandler.GetSignatures)
+			}
+			admin.GET("/users/:id/forms", formHandler.GetUserForms)
+
 			// Content management
 			content := admin.Group("/content")
 			{
@@ -120,6 +345,7 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 				userHandler := NewUserHandler(db)
 				users.GET("", userHandler.GetAll)
 				users.PUT("/:id/role", userHandler.UpdateRole)
+				users.GET("/:id/profile", userHandler.GetUserProfile)
 			}
 
 			// Instructor management
@@ -133,6 +359,40 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 				instructors.DELETE("/:id", instructorHandler.RemoveInstructor)
 			}
 
+			// Locations and rooms management
+			locationHandler := NewLocationHandler(db)
+			locations := admin.Group("/locations")
+			{
+				locations.POST("", locationHandler.Create)
+				locations.PUT("/:id", locationHandler.Update)
+				locations.DELETE("/:id", locationHandler.Delete)
+				locations.POST("/:id/rooms", locationHandler.CreateRoom)
+			}
+			rooms := admin.Group("/rooms")
+			{
+				rooms.PUT("/:id", locationHandler.UpdateRoom)
+				rooms.DELETE("/:id", locationHandler.DeleteRoom)
+			}
+
+			// Studio closures and holidays
+			closures := admin.Group("/closures")
+			{
+				closureHandler := NewClosureHandler(db)
+				closures.POST("", closureHandler.Create)
+				closures.DELETE("/:id", closureHandler.Delete)
+			}
+
+			// Substitution approvals and history
+			substitutions := admin.Group("/substitutions")
+			{
+				substitutionHandler := NewSubstitutionHandler(db)
+				substitutions.GET("", substitutionHandler.GetAll)
+				substitutions.GET("/:id", substitutionHandler.GetByID)
+				substitutions.GET("/:id/candidates", substitutionHandler.GetCandidates)
+				substitutions.POST("/:id/approve", substitutionHandler.Approve)
+				substitutions.POST("/:id/reject", substitutionHandler.Reject)
+			}
+
 			// Analytics (placeholder for now)
 			// analytics := admin.Group("/analytics")
 			// {
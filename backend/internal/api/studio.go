@@ -0,0 +1,26 @@
+package api
+
+import (
+	"time"
+
+	"yoga-studio-app/internal/middleware"
+	"yoga-studio-app/internal/pricing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// studioZone is the time zone of the studio serving the request
+func studioZone(c *gin.Context) *time.Location {
+	if studio := middleware.CurrentStudio(c); studio != nil {
+		return studio.Zone()
+	}
+	return time.UTC
+}
+
+// studioCurrency is the currency the studio serving the request prices in
+func studioCurrency(c *gin.Context) string {
+	if studio := middleware.CurrentStudio(c); studio != nil && studio.Currency != "" {
+		return studio.Currency
+	}
+	return pricing.DefaultCurrency
+}
@@ -0,0 +1,127 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should branch
+// on the code; the message is for humans and may change.
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodePrecondition Code = "precondition_required"
+	CodeUnavailable  Code = "service_unavailable"
+	CodeInternal     Code = "internal_error"
+)
+
+// FieldError describes a single invalid input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the error type handlers report. It renders as:
+//
+//	{"error": "Class not found", "code": "not_found"}
+//
+// with optional "fields" for validation failures and "details" for extra
+// machine-readable context. The wrapped cause is logged, never sent to clients.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Details map[string]interface{}
+	cause   error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithDetails attaches extra context to the response body
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// Wrap attaches the underlying cause for logging
+func (e *Error) Wrap(cause error) *Error {
+	e.cause = cause
+	return e
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// PreconditionRequired reports something the user must do first, such as
+// signing a waiver, before the request can succeed. Details say what.
+func PreconditionRequired(message string) *Error {
+	return New(http.StatusPreconditionRequired, CodePrecondition, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Validation reports one or more invalid fields. A single field error is
+// also spelled out in the message, since clients often show only that.
+func Validation(fields ...FieldError) *Error {
+	message := "Validation failed"
+	if len(fields) == 1 {
+		message = fields[0].Field + " " + fields[0].Message
+	}
+	e := New(http.StatusBadRequest, CodeValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// Internal hides cause from the client behind a generic message
+func Internal(message string, cause error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message).Wrap(cause)
+}
+
+// From converts any error into an *Error. Unknown errors become internal
+// errors so their text is never exposed.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("Internal server error", err)
+}
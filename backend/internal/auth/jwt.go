@@ -2,55 +2,59 @@ package auth
 
 import (
 	"errors"
-	"os"
 	"time"
 
+	"yoga-studio-app/internal/config"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-var jwtSecret []byte
+type Claims struct {
+	UserID   uuid.UUID `json:"user_id"`
+	StudioID uuid.UUID `json:"studio_id"` // the studio the user signed in to
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	jwt.RegisteredClaims
+}
 
-func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "default-secret-change-in-production"
-	}
-	jwtSecret = []byte(secret)
+// TokenManager issues and validates JWTs with the configured secret
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
 }
 
-type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
-	jwt.RegisteredClaims
+func NewTokenManager(cfg config.JWTConfig) *TokenManager {
+	return &TokenManager{secret: []byte(cfg.Secret), ttl: cfg.TTL}
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(userID uuid.UUID, email, role string) (string, error) {
+// GenerateToken creates a new JWT token for a user of a studio
+func (m *TokenManager) GenerateToken(userID, studioID uuid.UUID, email, role string) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:   userID,
+		StudioID: studioID,
+		Email:    email,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hour expiry
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(m.secret)
 }
 
 // ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string) (*Claims, error) {
+func (m *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// Verify signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
-		return jwtSecret, nil
+		return m.secret, nil
 	})
 
 	if err != nil {
@@ -65,12 +69,12 @@ func ValidateToken(tokenString string) (*Claims, error) {
 }
 
 // RefreshToken generates a new token with extended expiry
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ValidateToken(tokenString)
+func (m *TokenManager) RefreshToken(tokenString string) (string, error) {
+	claims, err := m.ValidateToken(tokenString)
 	if err != nil {
 		return "", err
 	}
 
 	// Generate new token with same claims but new expiry
-	return GenerateToken(claims.UserID, claims.Email, claims.Role)
+	return m.GenerateToken(claims.UserID, claims.StudioID, claims.Email, claims.Role)
 }
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"fmt"
-	"os"
+
+	"yoga-studio-app/internal/config"
+	"yoga-studio-app/internal/telemetry"
 
 	"github.com/gorilla/sessions"
 	"github.com/markbates/goth"
@@ -16,22 +18,16 @@ const (
 )
 
 // InitOAuth initializes OAuth providers (Google, Facebook)
-func InitOAuth() {
-	key := os.Getenv("JWT_SECRET")
-	if key == "" {
-		key = "default-secret-key-change-in-production"
-	}
-
-	store := sessions.NewCookieStore([]byte(key))
+func InitOAuth(cfg *config.Config) {
+	store := sessions.NewCookieStore([]byte(cfg.JWT.Secret))
 	store.MaxAge(maxAge)
 	store.Options.Path = "/"
 	store.Options.HttpOnly = true
 	
 	// Check if we're in production (HTTPS)
-	env := os.Getenv("ENV")
-	isProduction := env == "production"
+	isProduction := cfg.IsProduction()
 	store.Options.Secure = isProduction
-	
+
 	if isProduction {
 		store.Options.SameSite = 2 // Lax for production
 	} else {
@@ -40,23 +36,24 @@ func InitOAuth() {
 
 	gothic.Store = store
 
-	backendURL := os.Getenv("BACKEND_URL")
-	if backendURL == "" {
-		backendURL = "http://localhost:8080"
-	}
+	backendURL := cfg.BackendURL
 
-	goth.UseProviders(
-		google.New(
-			os.Getenv("GOOGLE_CLIENT_ID"),
-			os.Getenv("GOOGLE_CLIENT_SECRET"),
-			fmt.Sprintf("%s/api/v1/auth/google/callback", backendURL),
-			"email", "profile",
-		),
-		facebook.New(
-			os.Getenv("FACEBOOK_CLIENT_ID"),
-			os.Getenv("FACEBOOK_CLIENT_SECRET"),
-			fmt.Sprintf("%s/api/v1/auth/facebook/callback", backendURL),
-			"email",
-		),
+	googleProvider := google.New(
+		cfg.OAuth.Google.ClientID,
+		cfg.OAuth.Google.ClientSecret,
+		fmt.Sprintf("%s/api/v1/auth/google/callback", backendURL),
+		"email", "profile",
+	)
+	facebookProvider := facebook.New(
+		cfg.OAuth.Facebook.ClientID,
+		cfg.OAuth.Facebook.ClientSecret,
+		fmt.Sprintf("%s/api/v1/auth/facebook/callback", backendURL),
+		"email",
 	)
+
+	// Trace token exchange and profile fetches against the providers
+	googleProvider.HTTPClient = telemetry.HTTPClient()
+	facebookProvider.HTTPClient = telemetry.HTTPClient()
+
+	goth.UseProviders(googleProvider, facebookProvider)
 }
@@ -0,0 +1,100 @@
+// Package calendar writes iCalendar (RFC 5545) feeds that calendar apps can
+// subscribe to.
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of a feed written by Write
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+const utcFormat = "20060102T150405Z"
+
+// Event is one VEVENT in a feed
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Cancelled   bool
+	// Free marks time that shouldn't block the subscriber's calendar, such as
+	// a studio closure
+	Free bool
+}
+
+// Write renders events as a VCALENDAR named name. stamp is used as DTSTAMP on
+// every event.
+func Write(w io.Writer, name string, stamp time.Time, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(property, value string) {
+		writeFolded(bw, property+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Yoga Studio App//Schedule//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escape(name))
+	for _, e := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(e.UID))
+		line("DTSTAMP", stamp.UTC().Format(utcFormat))
+		line("DTSTART", e.Start.UTC().Format(utcFormat))
+		line("DTEND", e.End.UTC().Format(utcFormat))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.Cancelled {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		if e.Free {
+			line("TRANSP", "TRANSPARENT")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// escape quotes the characters that are special in TEXT values
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded writes one content line, folding it onto continuation lines so
+// none exceeds 75 octets. Multi-byte characters are never split.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		fmt.Fprintf(w, "%s\r\n ", s[:cut])
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	fmt.Fprintf(w, "%s\r\n", s)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteEvents(t *testing.T) {
+	stamp := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{
+		{
+			UID:         "class-1@yoga",
+			Summary:     "Vinyasa, Flow; Level 1",
+			Description: "Instructor: Sam\nBring a mat",
+			Start:       time.Date(2026, 3, 2, 9, 0, 0, 0, berlin),
+			End:         time.Date(2026, 3, 2, 10, 0, 0, 0, berlin),
+			Cancelled:   true,
+		},
+		{
+			UID:     "closure-1@yoga",
+			Summary: "Studio closed",
+			Start:   time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+			Free:    true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "Schedule", stamp, events); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Fatalf("feed is not a CRLF-delimited VCALENDAR:\n%s", out)
+	}
+	for _, want := range []string{
+		"DTSTAMP:20260301T120000Z\r\n",
+		"DTSTART:20260302T080000Z\r\n",
+		"DTEND:20260302T090000Z\r\n",
+		`SUMMARY:Vinyasa\, Flow\; Level 1` + "\r\n",
+		`DESCRIPTION:Instructor: Sam\nBring a mat` + "\r\n",
+		"STATUS:CANCELLED\r\n",
+		"STATUS:CONFIRMED\r\nTRANSP:TRANSPARENT\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("feed is missing %q:\n%s", want, out)
+		}
+	}
+	if got := strings.Count(out, "BEGIN:VEVENT"); got != 2 {
+		t.Errorf("got %d events, want 2", got)
+	}
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("ü", 60) // 120 octets
+	var buf bytes.Buffer
+	err := Write(&buf, "Schedule", time.Now(), []Event{{UID: "x", Summary: summary, Start: time.Now(), End: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets: %q", i, len(line), line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	if !strings.Contains(unfolded.String(), "\nSUMMARY:"+summary+"\n") {
+		t.Errorf("summary did not survive folding:\n%s", unfolded.String())
+	}
+}
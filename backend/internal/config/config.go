@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+
+	// defaultJWTSecret is only acceptable outside production
+	defaultJWTSecret   = "default-secret-change-in-production"
+	minJWTSecretLength = 32
+)
+
+// Config holds every setting the API needs, loaded once at startup
+type Config struct {
+	Env                string
+	Port               string
+	FrontendURL        string
+	BackendURL         string
+	CORSAllowedOrigins []string
+	// TrustedProxies are the addresses (IPs or CIDRs) of reverse proxies
+	// whose X-Forwarded-For header is believed; none are by default, so the
+	// client IP is the peer's
+	TrustedProxies  []string
+	ShutdownTimeout time.Duration
+	// DefaultStudio is the slug of the studio serving requests that name no
+	// studio by header or hostname
+	DefaultStudio string
+
+	Database DatabaseConfig
+	JWT      JWTConfig
+	OAuth    OAuthConfig
+	Tracing  TracingConfig
+	Mail     MailConfig
+}
+
+type DatabaseConfig struct {
+	URL             string
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnectRetries  int
+}
+
+type JWTConfig struct {
+	Secret string
+	TTL    time.Duration
+}
+
+type OAuthCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type OAuthConfig struct {
+	Google   OAuthCredentials
+	Facebook OAuthCredentials
+}
+
+type TracingConfig struct {
+	Enabled     bool
+	ServiceName string
+}
+
+// MailConfig is the SMTP server outgoing email is sent through. Email is
+// disabled when Host is empty.
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether an SMTP server is configured
+func (m MailConfig) Enabled() bool {
+	return m.Host != ""
+}
+
+// IsProduction reports whether the API runs with production safeguards
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Load reads configuration from the process environment, an optional file
+// named by CONFIG_FILE and a local .env file, in that order of precedence,
+// then validates the result.
+func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := godotenv.Load(path); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	p := &parser{}
+	cfg := &Config{
+		Env:             p.str("ENV", EnvDevelopment),
+		Port:            p.str("PORT", "8080"),
+		FrontendURL:     p.str("FRONTEND_URL", "http://localhost:5173"),
+		BackendURL:      p.str("BACKEND_URL", "http://localhost:8080"),
+		ShutdownTimeout: p.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DefaultStudio:   p.str("DEFAULT_STUDIO", "default"),
+		Database: DatabaseConfig{
+			URL:             p.str("DATABASE_URL", ""),
+			MaxIdleConns:    p.integer("DB_MAX_IDLE_CONNS", 10),
+			MaxOpenConns:    p.integer("DB_MAX_OPEN_CONNS", 100),
+			ConnMaxLifetime: p.duration("DB_CONN_MAX_LIFETIME", time.Hour),
+			ConnectRetries:  p.integer("DB_CONNECT_RETRIES", 3),
+		},
+		JWT: JWTConfig{
+			Secret: p.str("JWT_SECRET", defaultJWTSecret),
+			TTL:    p.duration("JWT_TTL", 24*time.Hour),
+		},
+		OAuth: OAuthConfig{
+			Google: OAuthCredentials{
+				ClientID:     p.str("GOOGLE_CLIENT_ID", ""),
+				ClientSecret: p.str("GOOGLE_CLIENT_SECRET", ""),
+			},
+			Facebook: OAuthCredentials{
+				ClientID:     p.str("FACEBOOK_CLIENT_ID", ""),
+				ClientSecret: p.str("FACEBOOK_CLIENT_SECRET", ""),
+			},
+		},
+		Tracing: TracingConfig{
+			Enabled:     p.boolean("OTEL_ENABLED", false),
+			ServiceName: p.str("OTEL_SERVICE_NAME", "yoga-studio-api"),
+		},
+		Mail: MailConfig{
+			Host:     p.str("SMTP_HOST", ""),
+			Port:     p.integer("SMTP_PORT", 587),
+			Username: p.str("SMTP_USERNAME", ""),
+			Password: p.str("SMTP_PASSWORD", ""),
+			From:     p.str("MAIL_FROM", ""),
+		},
+	}
+	cfg.CORSAllowedOrigins = p.list("CORS_ALLOWED_ORIGINS", []string{cfg.FrontendURL})
+	cfg.TrustedProxies = p.list("TRUSTED_PROXIES", nil)
+
+	// Report parse and validation problems together so they can be fixed in one pass
+	if err := errors.Join(append(p.errs, cfg.Validate())...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks cross-field rules and production requirements
+func (c *Config) Validate() error {
+	var errs []error
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		addErr("ENV must be %q or %q, got %q", EnvDevelopment, EnvProduction, c.Env)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		addErr("PORT must be a number between 1 and 65535, got %q", c.Port)
+	}
+	if c.Database.URL == "" {
+		addErr("DATABASE_URL is required")
+	}
+	if c.Database.MaxOpenConns < 1 {
+		addErr("DB_MAX_OPEN_CONNS must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addErr("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (%d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnectRetries < 1 {
+		addErr("DB_CONNECT_RETRIES must be at least 1")
+	}
+	if c.JWT.TTL <= 0 {
+		addErr("JWT_TTL must be positive")
+	}
+	if c.ShutdownTimeout <= 0 {
+		addErr("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.Mail.Enabled() {
+		if c.Mail.Port < 1 || c.Mail.Port > 65535 {
+			addErr("SMTP_PORT must be between 1 and 65535, got %d", c.Mail.Port)
+		}
+		if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+			addErr("MAIL_FROM must be an email address when SMTP_HOST is set, got %q", c.Mail.From)
+		}
+	}
+	if len(c.CORSAllowedOrigins) == 0 {
+		addErr("CORS_ALLOWED_ORIGINS must list at least one origin")
+	}
+
+	checkURL := func(name, raw string) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			addErr("%s must be an absolute URL, got %q", name, raw)
+			return
+		}
+		if c.IsProduction() && u.Scheme != "https" {
+			addErr("%s must use https in production, got %q", name, raw)
+		}
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				addErr("TRUSTED_PROXIES must list IP addresses or CIDRs, got %q", proxy)
+			}
+		}
+	}
+
+	checkURL("FRONTEND_URL", c.FrontendURL)
+	checkURL("BACKEND_URL", c.BackendURL)
+	for _, origin := range c.CORSAllowedOrigins {
+		checkURL("CORS_ALLOWED_ORIGINS", origin)
+	}
+
+	if c.IsProduction() {
+		if c.JWT.Secret == defaultJWTSecret {
+			addErr("JWT_SECRET must be set in production")
+		} else if len(c.JWT.Secret) < minJWTSecretLength {
+			addErr("JWT_SECRET must be at least %d characters in production", minJWTSecretLength)
+		}
+		if c.OAuth.Google.ClientID == "" || c.OAuth.Google.ClientSecret == "" {
+			addErr("GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET are required in production")
+		}
+		if c.OAuth.Facebook.ClientID == "" || c.OAuth.Facebook.ClientSecret == "" {
+			addErr("FACEBOOK_CLIENT_ID and FACEBOOK_CLIENT_SECRET are required in production")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parser reads typed values from the environment, collecting parse errors
+// so they can all be reported at once
+type parser struct {
+	errs []error
+}
+
+func (p *parser) str(key, defaultValue string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (p *parser) integer(key string, defaultValue int) int {
+	value := p.str(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+		return defaultValue
+	}
+	return n
+}
+
+func (p *parser) duration(key string, defaultValue time.Duration) time.Duration {
+	value := p.str(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s must be a duration such as 30s or 1h, got %q", key, value))
+		return defaultValue
+	}
+	return d
+}
+
+func (p *parser) boolean(key string, defaultValue bool) bool {
+	value := p.str(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+		return defaultValue
+	}
+	return b
+}
+
+func (p *parser) list(key string, defaultValue []string) []string {
+	value := p.str(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
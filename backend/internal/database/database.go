@@ -1,26 +1,28 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
+	"yoga-studio-app/internal/config"
 	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/tenancy"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
-func Connect() (*gorm.DB, error) {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
+func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.URL == "" {
 		return nil, fmt.Errorf("DATABASE_URL environment variable is required")
 	}
 
 	db, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  dsn,
+		DSN:                  cfg.URL,
 		PreferSimpleProtocol: true, // disables prepared statement cache
 	}), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -33,38 +35,245 @@ func Connect() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Emit a child span for every query, attached to the request span via the statement context
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	// Scope every statement on studio-owned tables to the studio in its context
+	if err := db.Use(tenancy.Plugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tenancy plugin: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
 	// Connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	log.Println("Database connected successfully")
 	return db, nil
 }
 
-func Migrate(db *gorm.DB) error {
-	log.Println("Running database migrations...")
-
-	// AutoMigrate will only add missing columns and tables
-	// It won't modify existing columns or delete unused columns
-	err := db.AutoMigrate(
+// migratedModels lists every model managed by Migrate, in dependency order
+func migratedModels() []interface{} {
+	return []interface{}{
+		&models.Studio{},
 		&models.User{},
 		&models.Class{},
+		&models.Location{},
+		&models.Room{},
+		&models.Course{},
 		&models.Schedule{},
+		&models.EnrollmentSeries{},
 		&models.Enrollment{},
 		&models.Content{},
-	)
+		&models.Substitution{},
+		&models.SubstitutionEvent{},
+		&models.Notification{},
+		&models.OccurrenceCancellation{},
+		&models.Closure{},
+		&models.CourseEnrollment{},
+		&models.CoursePayment{},
+		&models.CourseAttendance{},
+		&models.AvailabilityWindow{},
+		&models.AvailabilityException{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+		&models.GiftCard{},
+		&models.GiftCardRedemption{},
+		&models.LedgerEntry{},
+		&models.Refund{},
+		&models.Receipt{},
+		&models.ReceiptLine{},
+		&models.TaxRate{},
+		&models.FormVersion{},
+		&models.IntakeQuestion{},
+		&models.FormSignature{},
+		&models.IntakeAnswer{},
+		&models.Profile{},
+		&models.Household{},
+		&models.CreditTransaction{},
+	}
+}
+
+func Migrate(db *gorm.DB, defaultStudio string) error {
+	log.Println("Running database migrations...")
+
+	// Migrations work across every studio
+	db = db.WithContext(tenancy.AllStudios(context.Background()))
+
+	// AutoMigrate will only add missing columns and tables
+	// It won't modify existing columns or delete unused columns
+	err := db.AutoMigrate(migratedModels()...)
 
 	if err != nil {
 		// Log error but don't fail if tables already exist
 		log.Printf("Migration warning: %v", err)
 	}
 
+	if err := assignDefaultStudio(db, defaultStudio); err != nil {
+		log.Printf("Migration warning: %v", err)
+	}
+
+	if err := linkClassInstructors(db); err != nil {
+		log.Printf("Migration warning: %v", err)
+	}
+
+	if err := createStudioIndexes(db); err != nil {
+		log.Printf("Migration warning: %v", err)
+	}
+
+	if err := backfillCurrencies(db); err != nil {
+		log.Printf("Migration warning: %v", err)
+	}
+
 	log.Println("Migrations completed successfully")
 	return nil
 }
+
+// assignDefaultStudio creates the default studio and moves rows that predate
+// multi-studio support into it, and makes user emails unique per studio
+// rather than globally. It is safe to run on every startup.
+func assignDefaultStudio(db *gorm.DB, slug string) error {
+	studio := models.Studio{Slug: slug, Name: "Default Studio", Hostnames: []string{}, Timezone: models.DefaultTimezone, IsActive: true}
+	if err := db.Where("slug = ?", slug).FirstOrCreate(&studio).Error; err != nil {
+		return fmt.Errorf("failed to create default studio: %w", err)
+	}
+
+	for _, model := range migratedModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse %T: %w", model, err)
+		}
+		if stmt.Schema.LookUpField("StudioID") == nil {
+			continue
+		}
+
+		result := db.Model(model).Where("studio_id IS NULL").UpdateColumn("studio_id", studio.ID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to assign %s to the default studio: %w", stmt.Table, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Assigned %d row(s) of %s to studio %q", result.RowsAffected, stmt.Table, slug)
+		}
+	}
+
+	// The same person may sign up to several studios
+	for _, statement := range []string{
+		"ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key",
+		"ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_email",
+		// Dependents have no email
+		"DROP INDEX IF EXISTS idx_users_studio_email",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_studio_login_email ON users (studio_id, email) WHERE auth_provider <> 'dependent'",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to scope user emails to studios: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// createStudioIndexes adds unique indexes that span the studio_id column
+// embedded from StudioScoped, which struct tags can't express
+func createStudioIndexes(db *gorm.DB) error {
+	for _, statement := range []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_studio_code ON promo_codes (studio_id, code)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_gift_cards_studio_code ON gift_cards (studio_id, code)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_studio_sequence ON ledger_entries (studio_id, sequence)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_receipts_studio_number ON receipts (studio_id, number)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_form_versions_studio_kind_version ON form_versions (studio_id, kind, version)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_form_signatures_studio_version_user ON form_signatures (studio_id, form_version_id, user_id)",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create studio index: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillCurrencies stamps the studio's currency on prices recorded before
+// currencies were, and fixes what students owe on course enrollments made
+// before totals were stored, when there was no tax. Ledger entries are left
+// alone since their hashes cover them. It only touches rows without a
+// currency, so it is safe to run on every startup.
+func backfillCurrencies(db *gorm.DB) error {
+	if err := db.Exec(`
+		UPDATE course_enrollments SET subtotal = courses.price - course_enrollments.discount,
+		                              total = courses.price - course_enrollments.discount
+		FROM courses
+		WHERE courses.id = course_enrollments.course_id AND course_enrollments.currency = ''`).Error; err != nil {
+		return fmt.Errorf("failed to backfill course enrollment totals: %w", err)
+	}
+
+	for _, table := range []string{"courses", "course_enrollments", "promo_codes", "gift_cards", "receipts"} {
+		result := db.Exec(`UPDATE ` + table + ` SET currency = studios.currency
+			FROM studios WHERE studios.id = ` + table + `.studio_id AND ` + table + `.currency = ''`)
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill %s currency: %w", table, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Set the currency of %d %s row(s) to their studio's", result.RowsAffected, table)
+		}
+	}
+	return nil
+}
+
+// linkClassInstructors backfills classes.instructor_id by matching the legacy
+// free-text instructor_name against instructor users. It only touches classes
+// that are still unlinked, so it is safe to run on every startup.
+func linkClassInstructors(db *gorm.DB) error {
+	result := db.Exec(`
+		UPDATE classes SET instructor_id = users.id
+		FROM users
+		WHERE classes.instructor_id IS NULL
+		  AND users.studio_id = classes.studio_id
+		  AND users.is_instructor = true
+		  AND lower(trim(users.name)) = lower(trim(classes.instructor_name))
+		  AND (SELECT count(*) FROM users u2
+		       WHERE u2.studio_id = classes.studio_id
+		         AND u2.is_instructor = true
+		         AND lower(trim(u2.name)) = lower(trim(classes.instructor_name))) = 1`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to link classes to instructors: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Linked %d class(es) to instructor users by name", result.RowsAffected)
+	}
+
+	var unlinked []string
+	if err := db.Model(&models.Class{}).Where("instructor_id IS NULL").Distinct().Pluck("instructor_name", &unlinked).Error; err != nil {
+		return fmt.Errorf("failed to list unlinked classes: %w", err)
+	}
+	if len(unlinked) > 0 {
+		log.Printf("Classes with instructor names not matching exactly one instructor user: %v", unlinked)
+	}
+
+	return nil
+}
+
+// CheckReady pings the database and verifies that every migrated table exists
+func CheckReady(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+
+	migrator := db.WithContext(ctx).Migrator()
+	for _, model := range migratedModels() {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("migrations pending: table for %T is missing", model)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "yoga_studio"
+
+var (
+	// HTTPRequestsTotal counts handled requests by route template, method and status
+	HTTPRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests handled.",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// HTTPRequestDuration observes request latency by route template, method and status
+	HTTPRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// EnrollmentsCreated counts successful class bookings
+	EnrollmentsCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "enrollments_created_total",
+			Help:      "Total number of enrollments created.",
+		},
+	)
+
+	// EnrollmentCancellations counts cancelled bookings
+	EnrollmentCancellations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "enrollment_cancellations_total",
+			Help:      "Total number of enrollments cancelled.",
+		},
+	)
+
+	// WaitlistPromotions counts waitlisted students moved into a class
+	WaitlistPromotions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "waitlist_promotions_total",
+			Help:      "Total number of waitlist entries promoted to enrollments.",
+		},
+	)
+
+	// OAuthLoginFailures counts failed OAuth logins by provider
+	OAuthLoginFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "oauth_login_failures_total",
+			Help:      "Total number of failed OAuth logins.",
+		},
+		[]string{"provider"},
+	)
+)
+
+// Registry holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		EnrollmentsCreated,
+		EnrollmentCancellations,
+		WaitlistPromotions,
+		OAuthLoginFailures,
+	)
+
+	// Pre-populate provider labels so failures show up as 0 before the first one
+	for _, provider := range []string{"google", "facebook"} {
+		OAuthLoginFailures.WithLabelValues(provider)
+	}
+}
+
+// RegisterDBStats exposes connection pool statistics from sql.DB.Stats()
+func RegisterDBStats(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
@@ -1,39 +1,46 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
+	"yoga-studio-app/internal/apperr"
 	"yoga-studio-app/internal/auth"
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/tenancy"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens. Tokens are only valid for the studio
+// they were issued by, except for super admins, who may act in any studio.
+// It must run after StudioMiddleware.
+func AuthMiddleware(tokens *auth.TokenManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			RenderError(c, apperr.Unauthorized("Authorization header required"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
-			c.Abort()
+			RenderError(c, apperr.Unauthorized("Invalid authorization format"))
 			return
 		}
 
 		token := parts[1]
 
 		// Validate JWT token
-		claims, err := auth.ValidateToken(token)
+		claims, err := tokens.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			RenderError(c, apperr.Unauthorized("Invalid or expired token"))
+			return
+		}
+
+		studioID, _ := tenancy.StudioFrom(c.Request.Context())
+		if claims.StudioID != studioID && claims.Role != string(models.RoleSuperAdmin) {
+			RenderError(c, apperr.Unauthorized("Token was issued for a different studio"))
 			return
 		}
 
@@ -45,19 +52,35 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AdminMiddleware checks if user has admin role
+// AdminMiddleware checks if user has admin role. Super admins are admins of every studio.
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User role not found"})
-			c.Abort()
+			RenderError(c, apperr.Unauthorized("User role not found"))
+			return
+		}
+
+		if role != string(models.RoleAdmin) && role != string(models.RoleSuperAdmin) {
+			RenderError(c, apperr.Forbidden("Admin access required"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SuperAdminMiddleware checks if user has the super admin role
+func SuperAdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("user_role")
+		if !exists {
+			RenderError(c, apperr.Unauthorized("User role not found"))
 			return
 		}
 
-		if role != "ADMIN" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			c.Abort()
+		if role != string(models.RoleSuperAdmin) {
+			RenderError(c, apperr.Forbidden("Super admin access required"))
 			return
 		}
 
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+
+	"yoga-studio-app/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMiddleware renders errors reported with c.Error as the shared JSON
+// envelope. Handlers report an error and return without writing a response.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		err := apperr.From(c.Errors.Last().Err)
+		if err.Status >= 500 {
+			log.Printf("ERROR: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		if c.Writer.Written() {
+			return
+		}
+
+		RenderError(c, err)
+	}
+}
+
+// RenderError writes err as the shared JSON error envelope and aborts the chain
+func RenderError(c *gin.Context, err *apperr.Error) {
+	body := gin.H{
+		"error": err.Message,
+		"code":  err.Code,
+	}
+	if len(err.Fields) > 0 {
+		body["fields"] = err.Fields
+	}
+	if len(err.Details) > 0 {
+		body["details"] = err.Details
+	}
+
+	c.AbortWithStatusJSON(err.Status, body)
+}
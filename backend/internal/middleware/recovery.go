@@ -2,9 +2,10 @@ package middleware
 
 import (
 	"log"
-	"net/http"
 	"runtime/debug"
 
+	"yoga-studio-app/internal/apperr"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,13 +17,8 @@ func RecoveryMiddleware() gin.HandlerFunc {
 				// Log the panic with stack trace
 				log.Printf("PANIC RECOVERED: %v\n%s", err, debug.Stack())
 
-				// Return 500 error to client
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": "Internal server error",
-				})
-
-				// Abort the request
-				c.Abort()
+				// Return 500 error to client and abort the request
+				RenderError(c, apperr.Internal("Internal server error", nil))
 			}
 		}()
 
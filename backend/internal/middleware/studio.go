@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+
+	"yoga-studio-app/internal/apperr"
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/tenancy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StudioHeader names the studio a request is for, for clients that share a hostname
+const StudioHeader = "X-Studio"
+
+// StudioMiddleware resolves the studio a request is for and scopes the
+// request context to it. The X-Studio header wins, then the studio query
+// parameter (for browser navigations such as OAuth logins, which can't set
+// headers), then the request host; requests matching none are served as the
+// default studio. Naming an unknown studio is an error rather than a silent
+// fallback.
+func StudioMiddleware(db *gorm.DB, defaultSlug string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		studio, err := resolveStudio(c, db, defaultSlug)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				RenderError(c, apperr.NotFound("Studio not found"))
+				return
+			}
+			RenderError(c, apperr.Internal("Failed to resolve studio", err))
+			return
+		}
+
+		c.Set("studio", studio)
+		c.Request = c.Request.WithContext(tenancy.WithStudio(c.Request.Context(), studio.ID))
+		c.Next()
+	}
+}
+
+func resolveStudio(c *gin.Context, db *gorm.DB, defaultSlug string) (*models.Studio, error) {
+	db = db.WithContext(c.Request.Context())
+
+	if slug := c.GetHeader(StudioHeader); slug != "" {
+		return tenancy.Find(db, slug, "")
+	}
+	if slug := c.Query("studio"); slug != "" {
+		return tenancy.Find(db, slug, "")
+	}
+
+	studio, err := tenancy.Find(db, "", c.Request.Host)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return studio, err
+	}
+	return tenancy.Find(db, defaultSlug, "")
+}
+
+// CurrentStudio returns the studio resolved by StudioMiddleware
+func CurrentStudio(c *gin.Context) *models.Studio {
+	studio, _ := c.Get("studio")
+	s, _ := studio.(*models.Studio)
+	return s
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AvailabilityWindow is a weekly period in which an instructor takes private
+// sessions. Opens and Closes are local wall-clock times ("HH:MM") in the
+// studio's time zone.
+type AvailabilityWindow struct {
+	StudioScoped
+
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstructorID uuid.UUID  `gorm:"type:uuid;not null;index" json:"instructor_id"`
+	DayOfWeek    int        `gorm:"not null" json:"day_of_week"` // 0-6 (Sunday-Saturday)
+	Opens        string     `gorm:"type:varchar(5);not null" json:"opens"`
+	Closes       string     `gorm:"type:varchar(5);not null" json:"closes"`
+	ValidFrom    *time.Time `json:"valid_from"`  // applies to days starting from here; nil for always
+	ValidUntil   *time.Time `json:"valid_until"` // applies to days starting before here; nil for always
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (w *AvailabilityWindow) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// Minutes returns when the window opens and closes as minutes after local midnight
+func (w *AvailabilityWindow) Minutes() (opens, closes int) {
+	return ClockMinutes(w.Opens), ClockMinutes(w.Closes)
+}
+
+// AppliesOn reports whether the window is in effect on the local day starting at day
+func (w *AvailabilityWindow) AppliesOn(day time.Time) bool {
+	if w.DayOfWeek != int(day.Weekday()) {
+		return false
+	}
+	if w.ValidFrom != nil && day.Before(*w.ValidFrom) {
+		return false
+	}
+	return w.ValidUntil == nil || day.Before(*w.ValidUntil)
+}
+
+// ClockMinutes parses a "HH:MM" wall-clock time into minutes after midnight.
+// Malformed values parse as 0; requests validate them first.
+func ClockMinutes(clock string) int {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hours, &minutes); err != nil {
+		return 0
+	}
+	return hours*60 + minutes
+}
+
+// AvailabilityException adds or removes an instructor's availability for a
+// one-off period, such as a holiday or an extra morning
+type AvailabilityException struct {
+	StudioScoped
+
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstructorID uuid.UUID `gorm:"type:uuid;not null;index" json:"instructor_id"`
+	StartsAt     time.Time `gorm:"not null;index" json:"starts_at"`
+	EndsAt       time.Time `gorm:"not null;index" json:"ends_at"`
+	Available    bool      `gorm:"not null;default:false" json:"available"` // true adds time; false blocks it
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (e *AvailabilityException) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
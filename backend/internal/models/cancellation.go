@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OccurrenceCancellation records that the studio called off one session of a
+// schedule. The schedule itself is kept so the cancellation stays visible.
+type OccurrenceCancellation struct {
+	StudioScoped
+
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ScheduleID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_cancellation_occurrence" json:"schedule_id"`
+	OccurrenceStart time.Time `gorm:"not null;uniqueIndex:idx_cancellation_occurrence" json:"occurrence_start"`
+	OccurrenceEnd   time.Time `gorm:"not null" json:"occurrence_end"`
+	Reason          string    `json:"reason"`
+	CancelledBy     uuid.UUID `gorm:"type:uuid;not null" json:"cancelled_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (o *OccurrenceCancellation) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
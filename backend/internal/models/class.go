@@ -16,20 +16,24 @@ const (
 )
 
 type Class struct {
+	StudioScoped
+
 	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Title           string          `gorm:"not null" json:"title"`
 	Description     string          `json:"description"`
-	InstructorName  string          `gorm:"not null" json:"instructor_name"`
-	Duration        int             `gorm:"not null" json:"duration"` // in minutes
+	InstructorID    *uuid.UUID      `gorm:"type:uuid;index" json:"instructor_id"`
+	InstructorName  string          `gorm:"not null" json:"instructor_name"` // denormalized from Instructor for display
+	Duration        int             `gorm:"not null" json:"duration"`        // in minutes
 	Capacity        int             `gorm:"not null" json:"capacity"`
 	DifficultyLevel DifficultyLevel `gorm:"type:varchar(20)" json:"difficulty_level"`
 	ImageURL        string          `json:"image_url"`
 	IsActive        bool            `gorm:"default:true" json:"is_active"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
-	
+
 	// Relationships
-	Schedules []Schedule `json:"schedules,omitempty"`
+	Instructor *User      `gorm:"foreignKey:InstructorID;constraint:OnDelete:SET NULL" json:"instructor,omitempty"`
+	Schedules  []Schedule `json:"schedules,omitempty"`
 }
 
 func (c *Class) BeforeCreate(tx *gorm.DB) error {
@@ -38,4 +42,3 @@ func (c *Class) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
-
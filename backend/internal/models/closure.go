@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Closure blocks out a period when the studio doesn't run classes: a holiday,
+// a closed week, or part of a day
+type Closure struct {
+	StudioScoped
+
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title     string     `gorm:"not null" json:"title"`
+	StartsAt  time.Time  `gorm:"not null;index" json:"starts_at"`
+	EndsAt    time.Time  `gorm:"not null;index" json:"ends_at"`
+	RoomID    *uuid.UUID `gorm:"type:uuid;index" json:"room_id"` // closes a single room; nil closes the whole studio
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Covers reports whether the closure overlaps the period from start to end
+// in the given room. Studio-wide closures cover every room.
+func (c *Closure) Covers(roomID *uuid.UUID, start, end time.Time) bool {
+	if c.RoomID != nil && (roomID == nil || *roomID != *c.RoomID) {
+		return false
+	}
+	return c.StartsAt.Before(end) && start.Before(c.EndsAt)
+}
+
+func (c *Closure) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 )
 
 type Content struct {
+	StudioScoped
+
 	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	PageName   string    `gorm:"not null" json:"page_name"` // landing, about
 	SectionKey string    `gorm:"not null" json:"section_key"`
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Course is a workshop or training bought as one purchase covering many
+// sessions with a fixed roster. Its sessions are ordinary schedules with
+// CourseID set, so rooms, conflicts, closures and cancellations work as for
+// any class; students join the course rather than its sessions.
+type Course struct {
+	StudioScoped
+
+	ID                   uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title                string     `gorm:"not null" json:"title"`
+	Description          string     `json:"description"`
+	Capacity             int        `gorm:"not null" json:"capacity"` // roster size
+	RequiresApplication  bool       `gorm:"not null;default:false" json:"requires_application"`
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	Price                int64      `gorm:"not null;default:0" json:"price"`   // in minor units of Currency
+	Deposit              int64      `gorm:"not null;default:0" json:"deposit"` // part of Price
+	Currency             string     `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	LocationID           *uuid.UUID `gorm:"type:uuid" json:"location_id"`                  // where the course is taxed; nil for the studio's default rates
+	Installments         int        `gorm:"not null;default:1" json:"installments"`        // payments the balance after the deposit is split into
+	CertificationHours   float64    `gorm:"not null;default:0" json:"certification_hours"` // attended hours needed to certify; 0 for none
+	IsActive             bool       `gorm:"default:true" json:"is_active"`
+	CreatedBy            uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+
+	// Relationships
+	Schedules   []Schedule         `gorm:"foreignKey:CourseID" json:"schedules,omitempty"`
+	Enrollments []CourseEnrollment `json:"enrollments,omitempty"`
+}
+
+func (c *Course) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// RegistrationOpen reports whether students may join or apply at now
+func (c *Course) RegistrationOpen(now time.Time) bool {
+	if c.RegistrationOpensAt != nil && now.Before(*c.RegistrationOpensAt) {
+		return false
+	}
+	return c.RegistrationClosesAt == nil || now.Before(*c.RegistrationClosesAt)
+}
+
+// InstallmentAmount is the size of each payment after the deposit, rounded up
+// to the cent so the installments cover the balance
+func (c *Course) InstallmentAmount() int64 {
+	balance := c.Price - c.Deposit
+	if c.Installments <= 1 || balance <= 0 {
+		return balance
+	}
+	n := int64(c.Installments)
+	return (balance + n - 1) / n
+}
+
+type CourseEnrollmentStatus string
+
+const (
+	CourseApplied   CourseEnrollmentStatus = "applied" // waiting for the studio to review the application
+	CourseEnrolled  CourseEnrollmentStatus = "enrolled"
+	CourseRejected  CourseEnrollmentStatus = "rejected"
+	CourseWithdrawn CourseEnrollmentStatus = "withdrawn"
+)
+
+type CoursePaymentStatus string
+
+const (
+	CourseUnpaid        CoursePaymentStatus = "unpaid"
+	CourseDepositPaid   CoursePaymentStatus = "deposit_paid"
+	CoursePartiallyPaid CoursePaymentStatus = "partially_paid" // deposit and some installments paid
+	CoursePaid          CoursePaymentStatus = "paid"
+)
+
+// CourseEnrollment is a student's place on a course roster. It grants access
+// to every session of the course; only enrolled students take up Capacity.
+type CourseEnrollment struct {
+	StudioScoped
+
+	ID           uuid.UUID              `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseID     uuid.UUID              `gorm:"type:uuid;not null;index" json:"course_id"`
+	UserID       uuid.UUID              `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status       CourseEnrollmentStatus `gorm:"type:varchar(20);not null;default:'enrolled'" json:"status"`
+	Application  string                 `json:"application"`                          // the student's application, when the course requires one
+	BookedBy     *uuid.UUID             `gorm:"type:uuid" json:"booked_by,omitempty"` // set when a household member enrolled the student
+	DecisionNote string                 `json:"decision_note"`
+	DecidedBy    *uuid.UUID             `gorm:"type:uuid" json:"decided_by"`
+	DecidedAt    *time.Time             `json:"decided_at"`
+	PromoCodeID  *uuid.UUID             `gorm:"type:uuid;index" json:"promo_code_id"`
+	Discount     int64                  `gorm:"not null;default:0" json:"discount"` // taken off the course price before tax
+
+	// What the student pays, fixed when they enroll or apply
+	Subtotal int64  `gorm:"not null;default:0" json:"subtotal"` // price after discount, before tax
+	Tax      int64  `gorm:"not null;default:0" json:"tax"`
+	Total    int64  `gorm:"not null;default:0" json:"total"`
+	TaxLabel string `json:"tax_label"` // the rates applied, e.g. "VAT 20%"
+	Currency string `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+
+	PaymentStatus    CoursePaymentStatus `gorm:"type:varchar(20);not null;default:'unpaid'" json:"payment_status"`
+	AmountPaid       int64               `gorm:"not null;default:0" json:"amount_paid"` // in cents
+	InstallmentsPaid int                 `gorm:"not null;default:0" json:"installments_paid"`
+	WithdrawnAt      *time.Time          `json:"withdrawn_at"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+
+	// Relationships
+	Course     *Course            `json:"course,omitempty"`
+	User       *User              `json:"user,omitempty"`
+	Payments   []CoursePayment    `json:"payments,omitempty"`
+	Attendance []CourseAttendance `json:"attendance,omitempty"`
+}
+
+func (e *CourseEnrollment) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// AmountDue is what the student pays for the course, after any discount and with tax
+func (e *CourseEnrollment) AmountDue() int64 {
+	return e.Total
+}
+
+// RecordPayment adds amount to what has been paid and updates the payment
+// status: the first payment reaching the deposit covers it, later ones count
+// as installments. A discount larger than the balance reduces the deposit.
+func (e *CourseEnrollment) RecordPayment(course *Course, amount int64) {
+	due := e.AmountDue()
+	deposit := course.Deposit
+	if deposit > due {
+		deposit = due
+	}
+
+	depositCovered := e.AmountPaid >= deposit && e.AmountPaid > 0
+	e.AmountPaid += amount
+	if depositCovered || deposit == 0 {
+		e.InstallmentsPaid++
+	}
+	e.updatePaymentStatus(due, deposit)
+}
+
+// RecordRefund takes amount off what has been paid and updates the payment
+// status. Installments already counted are kept.
+func (e *CourseEnrollment) RecordRefund(course *Course, amount int64) {
+	due := e.AmountDue()
+	deposit := course.Deposit
+	if deposit > due {
+		deposit = due
+	}
+
+	e.AmountPaid -= amount
+	e.updatePaymentStatus(due, deposit)
+}
+
+func (e *CourseEnrollment) updatePaymentStatus(due, deposit int64) {
+	switch {
+	case e.AmountPaid >= due:
+		e.PaymentStatus = CoursePaid
+	case e.AmountPaid > deposit:
+		e.PaymentStatus = CoursePartiallyPaid
+	case e.AmountPaid == deposit && deposit > 0:
+		e.PaymentStatus = CourseDepositPaid
+	default:
+		e.PaymentStatus = CourseUnpaid
+	}
+}
+
+// CoursePayment is one payment received against a course enrollment
+type CoursePayment struct {
+	StudioScoped
+
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseEnrollmentID uuid.UUID  `gorm:"type:uuid;not null;index" json:"course_enrollment_id"`
+	Amount             int64      `gorm:"not null" json:"amount"` // in cents
+	Note               string     `json:"note"`
+	Reference          string     `gorm:"index" json:"reference"`              // payment provider's transaction ID
+	Refunded           int64      `gorm:"not null;default:0" json:"refunded"`  // in cents, refunded so far
+	GiftCardID         *uuid.UUID `gorm:"type:uuid;index" json:"gift_card_id"` // set when paid from a gift card
+	HouseholdID        *uuid.UUID `gorm:"type:uuid" json:"household_id"`       // set when paid from household credit
+	RecordedBy         uuid.UUID  `gorm:"type:uuid;not null" json:"recorded_by"`
+	PaidAt             time.Time  `gorm:"not null" json:"paid_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func (p *CoursePayment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// CourseAttendance records whether an enrolled student attended one session
+// of a course. Hours is the session length at the time, and attended hours
+// count towards CertificationHours.
+type CourseAttendance struct {
+	StudioScoped
+
+	ID                 uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CourseEnrollmentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_course_attendance_session" json:"course_enrollment_id"`
+	ScheduleID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_course_attendance_session" json:"schedule_id"`
+	OccurrenceStart    time.Time `gorm:"not null;uniqueIndex:idx_course_attendance_session" json:"occurrence_start"`
+	Present            bool      `gorm:"not null" json:"present"`
+	Hours              float64   `gorm:"not null" json:"hours"`
+	RecordedBy         uuid.UUID `gorm:"type:uuid;not null" json:"recorded_by"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (a *CourseAttendance) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
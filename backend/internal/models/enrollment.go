@@ -13,17 +13,34 @@ const (
 	PaymentPending   PaymentStatus = "pending"
 	PaymentCompleted PaymentStatus = "completed"
 	PaymentFailed    PaymentStatus = "failed"
+	PaymentRefunded  PaymentStatus = "refunded"
+)
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentActive            EnrollmentStatus = "active"
+	EnrollmentWaitlisted        EnrollmentStatus = "waitlisted" // session was full; promoted when a place frees up
+	EnrollmentCancelled         EnrollmentStatus = "cancelled"  // cancelled by the student
+	EnrollmentCancelledByStudio EnrollmentStatus = "cancelled_by_studio"
 )
 
 type Enrollment struct {
-	ID             uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID         uuid.UUID     `gorm:"type:uuid;not null" json:"user_id"`
-	ScheduleID     uuid.UUID     `gorm:"type:uuid;not null" json:"schedule_id"`
-	EnrollmentDate time.Time     `gorm:"not null" json:"enrollment_date"`
-	PaymentStatus  PaymentStatus `gorm:"type:varchar(20);default:'completed'" json:"payment_status"`
-	PaymentID      string        `json:"payment_id"`
-	CreatedAt      time.Time     `json:"created_at"`
-	
+	StudioScoped
+
+	ID              uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID        `gorm:"type:uuid;not null" json:"user_id"`
+	ScheduleID      uuid.UUID        `gorm:"type:uuid;not null" json:"schedule_id"`
+	OccurrenceStart *time.Time       `gorm:"index" json:"occurrence_start,omitempty"`    // a single session; nil holds a place in every session
+	SeriesID        *uuid.UUID       `gorm:"type:uuid;index" json:"series_id,omitempty"` // set when booked as part of an EnrollmentSeries
+	BookedBy        *uuid.UUID       `gorm:"type:uuid" json:"booked_by,omitempty"`       // set when a household member booked for the user
+	EnrollmentDate  time.Time        `gorm:"not null" json:"enrollment_date"`
+	PaymentStatus   PaymentStatus    `gorm:"type:varchar(20);default:'completed'" json:"payment_status"`
+	PaymentID       string           `json:"payment_id"`
+	Status          EnrollmentStatus `gorm:"type:varchar(30);not null;default:'active'" json:"status"`
+	CancelledAt     *time.Time       `json:"cancelled_at"`
+	CreatedAt       time.Time        `json:"created_at"`
+
 	// Relationships
 	User     User     `json:"user,omitempty"`
 	Schedule Schedule `json:"schedule,omitempty"`
@@ -39,3 +56,10 @@ func (e *Enrollment) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// HoldsPlace reports whether the enrollment holds a place in the session starting at start
+func (e *Enrollment) HoldsPlace(start time.Time) bool {
+	if e.Status != EnrollmentActive {
+		return false
+	}
+	return e.OccurrenceStart == nil || e.OccurrenceStart.Equal(start)
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FormKind is the kind of document clients sign before they book
+type FormKind string
+
+const (
+	FormWaiver FormKind = "waiver" // liability waiver
+	FormIntake FormKind = "intake" // health intake questionnaire
+)
+
+type AnswerType string
+
+const (
+	AnswerYesNo AnswerType = "yes_no"
+	AnswerText  AnswerType = "text"
+)
+
+// ErrFormPublished is returned when code tries to change a published form
+// version. Changes are published as a new version.
+var ErrFormPublished = errors.New("published forms cannot be changed; publish a new version instead")
+
+// FormVersion is one published version of the studio's waiver or intake
+// form. The latest version of each kind is current, and clients must have
+// signed it before they can book. Versions are never edited, so a signature
+// always refers to the exact text the client agreed to.
+type FormVersion struct {
+	StudioScoped
+
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Kind        FormKind  `gorm:"type:varchar(20);not null" json:"kind"`
+	Version     int       `gorm:"not null" json:"version"` // counts up from 1 per kind
+	Title       string    `gorm:"not null" json:"title"`
+	Body        string    `gorm:"not null" json:"body"`
+	PublishedBy uuid.UUID `gorm:"type:uuid;not null" json:"published_by"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	Questions []IntakeQuestion `json:"questions"`
+}
+
+func (f *FormVersion) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+func (f *FormVersion) BeforeUpdate(tx *gorm.DB) error {
+	return ErrFormPublished
+}
+
+func (f *FormVersion) BeforeDelete(tx *gorm.DB) error {
+	return ErrFormPublished
+}
+
+// IntakeQuestion is one question on an intake form
+type IntakeQuestion struct {
+	StudioScoped
+
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FormVersionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"form_version_id"`
+	Position      int        `gorm:"not null" json:"position"`
+	Prompt        string     `gorm:"not null" json:"prompt"`
+	AnswerType    AnswerType `gorm:"type:varchar(20);not null" json:"answer_type"`
+	Required      bool       `gorm:"not null;default:false" json:"required"`
+	HealthFlag    bool       `gorm:"not null;default:false" json:"health_flag"` // a yes, or any text, is shown to the client's instructors
+}
+
+func (q *IntakeQuestion) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	return nil
+}
+
+// Flags reports whether answer to q should be shown to instructors
+func (q *IntakeQuestion) Flags(answer string) bool {
+	if !q.HealthFlag {
+		return false
+	}
+	if q.AnswerType == AnswerYesNo {
+		return answer == "yes"
+	}
+	return strings.TrimSpace(answer) != ""
+}
+
+// FormSignature records a client signing a form version, with when and
+// from where, as evidence of consent
+type FormSignature struct {
+	StudioScoped
+
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FormVersionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"form_version_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind          FormKind   `gorm:"type:varchar(20);not null" json:"kind"`
+	Version       int        `gorm:"not null" json:"version"`
+	SignedName    string     `gorm:"not null" json:"signed_name"` // the name the client typed as their signature
+	SignedBy      *uuid.UUID `gorm:"type:uuid" json:"signed_by"`  // the guardian, when signed for a household dependent
+	SignedAt      time.Time  `gorm:"not null" json:"signed_at"`
+	IPAddress     string     `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent     string     `json:"user_agent"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Relationships
+	FormVersion *FormVersion   `json:"form_version,omitempty"`
+	User        *User          `json:"user,omitempty"`
+	Answers     []IntakeAnswer `gorm:"foreignKey:SignatureID" json:"answers,omitempty"`
+}
+
+func (s *FormSignature) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// IntakeAnswer is a client's answer to one intake question. The prompt is
+// copied so the answer reads the same whatever later versions ask.
+type IntakeAnswer struct {
+	StudioScoped
+
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SignatureID uuid.UUID `gorm:"type:uuid;not null;index" json:"signature_id"`
+	QuestionID  uuid.UUID `gorm:"type:uuid;not null" json:"question_id"`
+	Position    int       `gorm:"not null" json:"position"`
+	Prompt      string    `gorm:"not null" json:"prompt"`
+	Answer      string    `json:"answer"`
+	Flagged     bool      `gorm:"not null;default:false" json:"flagged"`
+}
+
+func (a *IntakeAnswer) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthDependent is the AuthProvider of household dependents, who have no
+// login of their own and are managed by the household's guardians
+const AuthDependent = "dependent"
+
+// Household groups family members so they can book for one another and
+// share a credit balance. Members who sign in are its guardians; dependents
+// don't sign in.
+type Household struct {
+	StudioScoped
+
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name            string     `gorm:"not null" json:"name"`
+	CreditBalance   int64      `gorm:"not null;default:0" json:"credit_balance"` // in minor units of Currency
+	Currency        string     `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	InviteCode      string     `gorm:"index" json:"-"` // lets another adult join, see HouseholdHandler.Invite
+	InviteExpiresAt *time.Time `json:"-"`
+	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	Members []User `json:"members,omitempty"`
+}
+
+func (h *Household) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+// InviteValidAt reports whether the household's invite code may be used at now
+func (h *Household) InviteValidAt(now time.Time) bool {
+	return h.InviteCode != "" && h.InviteExpiresAt != nil && now.Before(*h.InviteExpiresAt)
+}
+
+type CreditTransactionKind string
+
+const (
+	CreditAdded    CreditTransactionKind = "added"    // bought at the studio
+	CreditSpent    CreditTransactionKind = "spent"    // paid towards a course
+	CreditRefunded CreditTransactionKind = "refunded" // a refund of a credit payment
+)
+
+// CreditTransaction records a change to a household's credit balance
+type CreditTransaction struct {
+	StudioScoped
+
+	ID                 uuid.UUID             `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	HouseholdID        uuid.UUID             `gorm:"type:uuid;not null;index" json:"household_id"`
+	Kind               CreditTransactionKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Amount             int64                 `gorm:"not null" json:"amount"`   // negative when spent
+	Balance            int64                 `gorm:"not null" json:"balance"`  // the household's balance afterwards
+	UserID             *uuid.UUID            `gorm:"type:uuid" json:"user_id"` // the member it was spent on, or who bought it
+	CourseEnrollmentID *uuid.UUID            `gorm:"type:uuid" json:"course_enrollment_id"`
+	CoursePaymentID    *uuid.UUID            `gorm:"type:uuid" json:"course_payment_id"`
+	Note               string                `json:"note"`
+	RecordedBy         uuid.UUID             `gorm:"type:uuid;not null" json:"recorded_by"`
+	CreatedAt          time.Time             `json:"created_at"`
+}
+
+func (t *CreditTransaction) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
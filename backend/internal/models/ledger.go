@@ -0,0 +1,150 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LedgerAccount is one of the accounts money moves between in the ledger
+type LedgerAccount string
+
+const (
+	AccountCash              LedgerAccount = "cash"                // money held by the studio or its payment provider
+	AccountCourseRevenue     LedgerAccount = "course_revenue"      // course fees earned
+	AccountRefunds           LedgerAccount = "refunds"             // fees given back, offsetting revenue
+	AccountGiftCardLiability LedgerAccount = "gift_card_liability" // unspent gift card balances the studio owes
+	AccountTaxPayable        LedgerAccount = "tax_payable"         // tax collected, owed to the tax authority
+	AccountHouseholdCredit   LedgerAccount = "household_credit"    // unspent household credit the studio owes
+)
+
+type LedgerEntryKind string
+
+const (
+	LedgerCharge             LedgerEntryKind = "charge"               // a course payment received
+	LedgerRefund             LedgerEntryKind = "refund"               // a payment given back
+	LedgerGiftCardIssued     LedgerEntryKind = "gift_card_issued"     // stored value sold
+	LedgerGiftCardRedemption LedgerEntryKind = "gift_card_redemption" // stored value spent on a course
+	LedgerGiftCardCredit     LedgerEntryKind = "gift_card_credit"     // a refund returned to a gift card
+	LedgerCreditAdded        LedgerEntryKind = "credit_added"         // household credit sold
+	LedgerCreditRedemption   LedgerEntryKind = "credit_redemption"    // household credit spent on a course
+	LedgerCreditRefund       LedgerEntryKind = "credit_refund"        // a refund returned to household credit
+)
+
+// ErrLedgerImmutable is returned when code tries to change a posted entry.
+// Corrections are posted as new entries.
+var ErrLedgerImmutable = errors.New("ledger entries cannot be changed once posted")
+
+// LedgerEntry is one posting in the studio's ledger: Amount moves from
+// CreditAccount to DebitAccount, so every entry balances. Entries form a hash
+// chain per studio in Sequence order: each Hash covers the entry and the
+// previous entry's Hash, so editing or removing a posted entry breaks every
+// hash after it.
+type LedgerEntry struct {
+	StudioScoped
+
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Sequence           int64           `gorm:"not null" json:"sequence"` // unique per studio, see database.Migrate
+	Kind               LedgerEntryKind `gorm:"type:varchar(30);not null;index" json:"kind"`
+	DebitAccount       LedgerAccount   `gorm:"type:varchar(30);not null" json:"debit_account"`
+	CreditAccount      LedgerAccount   `gorm:"type:varchar(30);not null" json:"credit_account"`
+	Amount             int64           `gorm:"not null" json:"amount"` // in minor units of Currency, always positive
+	Currency           string          `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	UserID             *uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	CourseEnrollmentID *uuid.UUID      `gorm:"type:uuid;index" json:"course_enrollment_id"`
+	CoursePaymentID    *uuid.UUID      `gorm:"type:uuid;index" json:"course_payment_id"`
+	GiftCardID         *uuid.UUID      `gorm:"type:uuid;index" json:"gift_card_id"`
+	HouseholdID        *uuid.UUID      `gorm:"type:uuid;index" json:"household_id"`
+	RefundID           *uuid.UUID      `gorm:"type:uuid" json:"refund_id"`
+	Reference          string          `gorm:"index" json:"reference"` // payment provider's transaction ID, for reconciliation
+	Description        string          `json:"description"`
+	RecordedBy         uuid.UUID       `gorm:"type:uuid;not null" json:"recorded_by"`
+	CreatedAt          time.Time       `json:"created_at"`
+	PrevHash           string          `gorm:"type:char(64);not null" json:"prev_hash"`
+	Hash               string          `gorm:"type:char(64);not null" json:"hash"`
+}
+
+func (e *LedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *LedgerEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (e *LedgerEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// ComputeHash returns the hash of the entry's contents chained to PrevHash.
+// CreatedAt is hashed to the microsecond, the precision Postgres stores.
+func (e *LedgerEntry) ComputeHash() string {
+	optional := func(id *uuid.UUID) string {
+		if id == nil {
+			return ""
+		}
+		return id.String()
+	}
+	fields := []string{
+		e.PrevHash,
+		e.StudioID.String(),
+		e.ID.String(),
+		fmt.Sprint(e.Sequence),
+		string(e.Kind),
+		string(e.DebitAccount),
+		string(e.CreditAccount),
+		fmt.Sprint(e.Amount),
+		optional(e.UserID),
+		optional(e.CourseEnrollmentID),
+		optional(e.CoursePaymentID),
+		optional(e.GiftCardID),
+		optional(e.RefundID),
+		e.Reference,
+		e.Description,
+		e.RecordedBy.String(),
+		e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	}
+	// Entries from before currencies were recorded hash without one, and
+	// only entries about household credit hash a household
+	if e.Currency != "" {
+		fields = append(fields, e.Currency)
+	}
+	if e.HouseholdID != nil {
+		fields = append(fields, "household:"+e.HouseholdID.String())
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Refund records money given back against a course payment. A payment can
+// be refunded in several parts up to its amount.
+type Refund struct {
+	StudioScoped
+
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CoursePaymentID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"course_payment_id"`
+	CourseEnrollmentID uuid.UUID  `gorm:"type:uuid;not null;index" json:"course_enrollment_id"`
+	Amount             int64      `gorm:"not null" json:"amount"` // in cents
+	Reason             string     `json:"reason"`
+	Reference          string     `json:"reference"`                     // payment provider's refund ID
+	GiftCardID         *uuid.UUID `gorm:"type:uuid" json:"gift_card_id"` // set when returned to the gift card that paid
+	HouseholdID        *uuid.UUID `gorm:"type:uuid" json:"household_id"` // set when returned to household credit
+	RecordedBy         uuid.UUID  `gorm:"type:uuid;not null" json:"recorded_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
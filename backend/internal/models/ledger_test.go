@@ -0,0 +1,137 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func sampleLedgerEntry() LedgerEntry {
+	userID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	paymentID := uuid.MustParse("44444444-4444-4444-4444-444444444444")
+	return LedgerEntry{
+		StudioScoped:    StudioScoped{StudioID: uuid.MustParse("11111111-1111-1111-1111-111111111111")},
+		ID:              uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+		Sequence:        7,
+		Kind:            LedgerCharge,
+		DebitAccount:    AccountCash,
+		CreditAccount:   AccountCourseRevenue,
+		Amount:          12500,
+		UserID:          &userID,
+		CoursePaymentID: &paymentID,
+		Reference:       "pi_123",
+		Description:     "Course payment",
+		RecordedBy:      uuid.MustParse("55555555-5555-5555-5555-555555555555"),
+		CreatedAt:       time.Date(2026, 3, 1, 9, 30, 15, 123456789, time.UTC),
+		PrevHash:        "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+}
+
+// The hashed layout is part of the stored ledger: changing it invalidates
+// every posted entry
+func TestLedgerHashIsStable(t *testing.T) {
+	entry := sampleLedgerEntry()
+	const want = "5c46b15ce992a8c9503d05a9722db557d739420126ec5b1132a55470e0eeb156"
+	if got := entry.ComputeHash(); got != want {
+		t.Errorf("got hash %s, want %s", got, want)
+	}
+}
+
+func TestLedgerHashSurvivesDatabaseRoundTrip(t *testing.T) {
+	entry := sampleLedgerEntry()
+	posted := entry.ComputeHash()
+
+	// Postgres keeps microseconds, and the driver may hand times back in
+	// the server's zone
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loaded := range []time.Time{
+		entry.CreatedAt.Truncate(time.Microsecond),
+		entry.CreatedAt.Truncate(time.Microsecond).In(berlin),
+		entry.CreatedAt.Truncate(time.Microsecond).Local(),
+	} {
+		entry.CreatedAt = loaded
+		if got := entry.ComputeHash(); got != posted {
+			t.Errorf("CreatedAt %s: got hash %s, want %s", loaded, got, posted)
+		}
+	}
+
+	entry.CreatedAt = entry.CreatedAt.Add(time.Microsecond)
+	if entry.ComputeHash() == posted {
+		t.Error("hash ignores a change to CreatedAt")
+	}
+}
+
+func TestLedgerHashCoversContents(t *testing.T) {
+	base := sampleLedgerEntry()
+	posted := base.ComputeHash()
+
+	otherID := uuid.New()
+	changes := map[string]func(*LedgerEntry){
+		"amount":       func(e *LedgerEntry) { e.Amount++ },
+		"kind":         func(e *LedgerEntry) { e.Kind = LedgerRefund },
+		"accounts":     func(e *LedgerEntry) { e.DebitAccount, e.CreditAccount = e.CreditAccount, e.DebitAccount },
+		"user":         func(e *LedgerEntry) { e.UserID = nil },
+		"gift card":    func(e *LedgerEntry) { e.GiftCardID = &otherID },
+		"reference":    func(e *LedgerEntry) { e.Reference = "pi_124" },
+		"previous":     func(e *LedgerEntry) { e.PrevHash = posted },
+		"sequence":     func(e *LedgerEntry) { e.Sequence++ },
+		"studio":       func(e *LedgerEntry) { e.StudioID = otherID },
+		"recorded by":  func(e *LedgerEntry) { e.RecordedBy = otherID },
+		"description":  func(e *LedgerEntry) { e.Description += " " },
+		"payment link": func(e *LedgerEntry) { e.CoursePaymentID = &otherID },
+	}
+	for name, change := range changes {
+		entry := sampleLedgerEntry()
+		change(&entry)
+		if entry.ComputeHash() == posted {
+			t.Errorf("hash ignores a change to %s", name)
+		}
+	}
+}
+
+func TestLedgerHashAddsCurrencyOnlyWhenRecorded(t *testing.T) {
+	// Entries posted before currencies were recorded keep their hashes
+	entry := sampleLedgerEntry()
+	const before = "5c46b15ce992a8c9503d05a9722db557d739420126ec5b1132a55470e0eeb156"
+	if got := entry.ComputeHash(); got != before {
+		t.Fatalf("got hash %s without a currency, want %s", got, before)
+	}
+
+	entry.Currency = "EUR"
+	inEuros := entry.ComputeHash()
+	if inEuros == before {
+		t.Error("hash ignores the currency")
+	}
+	entry.Currency = "USD"
+	if entry.ComputeHash() == inEuros {
+		t.Error("hash ignores a change of currency")
+	}
+}
+
+func TestLedgerHashAddsHouseholdOnlyWhenSet(t *testing.T) {
+	entry := sampleLedgerEntry()
+	entry.Currency = "EUR"
+	withoutHousehold := entry.ComputeHash()
+
+	household := uuid.MustParse("66666666-6666-6666-6666-666666666666")
+	entry.HouseholdID = &household
+	withHousehold := entry.ComputeHash()
+	if withHousehold == withoutHousehold {
+		t.Error("hash ignores the household")
+	}
+	other := uuid.New()
+	entry.HouseholdID = &other
+	if entry.ComputeHash() == withHousehold {
+		t.Error("hash ignores a change of household")
+	}
+
+	// Entries without either keep the hash they were posted with
+	entry = sampleLedgerEntry()
+	if got := entry.ComputeHash(); got != "5c46b15ce992a8c9503d05a9722db557d739420126ec5b1132a55470e0eeb156" {
+		t.Errorf("entry without a household or currency got hash %s", got)
+	}
+}
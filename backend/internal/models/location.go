@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Location is a place classes are held: the studio itself, or somewhere
+// off-site such as a park
+type Location struct {
+	StudioScoped
+
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name     string    `gorm:"not null" json:"name"`
+	Address  string    `json:"address"`
+	Notes    string    `json:"notes"`
+	Timezone string    `gorm:"type:varchar(64)" json:"timezone"` // IANA zone; empty means the studio's
+	// Whether prices charged here include tax. Rates come from the location's
+	// TaxRates, or the studio's default rates when it has none.
+	PricesIncludeTax bool      `gorm:"not null;default:false" json:"prices_include_tax"`
+	IsActive         bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	Rooms []Room `json:"rooms,omitempty"`
+}
+
+func (l *Location) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// Room is a bookable space at a location. Outdoor sites get a single room
+// standing for the whole site.
+type Room struct {
+	StudioScoped
+
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LocationID uuid.UUID `gorm:"type:uuid;not null;index" json:"location_id"`
+	Name       string    `gorm:"not null" json:"name"`
+	Capacity   int       `gorm:"not null" json:"capacity"`
+	Amenities  []string  `gorm:"type:text[]" json:"amenities"`
+	IsActive   bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Relationships
+	Location *Location `json:"location,omitempty"`
+}
+
+func (r *Room) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationSubstitution   NotificationType = "substitution"
+	NotificationClassCancelled NotificationType = "class_cancelled"
+	NotificationWaitlist       NotificationType = "waitlist"
+	NotificationCourse         NotificationType = "course"
+	NotificationPrivateSession NotificationType = "private_session"
+	NotificationHousehold      NotificationType = "household"
+)
+
+// Notification is an in-app message shown to a single user
+type Notification struct {
+	StudioScoped
+
+	ID        uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type      NotificationType `gorm:"type:varchar(40);not null" json:"type"`
+	Title     string           `gorm:"not null" json:"title"`
+	Message   string           `json:"message"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
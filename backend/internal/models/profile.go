@@ -0,0 +1,174 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Visibility is who, besides the client, may see a profile field. Each
+// level includes the ones before it: admins see what instructors see.
+type Visibility string
+
+const (
+	VisibleSelf       Visibility = "self"       // only the client
+	VisibleAdmin      Visibility = "admin"      // the client and studio admins
+	VisibleInstructor Visibility = "instructor" // also the instructors teaching the client
+)
+
+func (v Visibility) rank() int {
+	switch v {
+	case VisibleAdmin:
+		return 1
+	case VisibleInstructor:
+		return 2
+	}
+	return 0
+}
+
+// Includes reports whether a field shared with v may be seen by audience
+func (v Visibility) Includes(audience Visibility) bool {
+	return audience == VisibleSelf || v.rank() >= audience.rank()
+}
+
+// ProfileField describes one field of the extended profile: who sees it
+// unless the client says otherwise, and the least it may be shared with
+type ProfileField struct {
+	Name     string     `json:"name"`
+	Default  Visibility `json:"default"`
+	Minimum  Visibility `json:"minimum"`
+	Bookable bool       `json:"bookable"` // a studio may require it before booking
+}
+
+// ProfileFields lists the extended profile fields. Emergency contacts must
+// reach the studio, and marketing consent stays visible to admins so they
+// know whom they may contact; it can never be required.
+var ProfileFields = []ProfileField{
+	{Name: "phone", Default: VisibleAdmin, Minimum: VisibleSelf, Bookable: true},
+	{Name: "emergency_contact", Default: VisibleInstructor, Minimum: VisibleAdmin, Bookable: true},
+	{Name: "date_of_birth", Default: VisibleAdmin, Minimum: VisibleSelf, Bookable: true},
+	{Name: "pronouns", Default: VisibleInstructor, Minimum: VisibleSelf, Bookable: true},
+	{Name: "injuries", Default: VisibleInstructor, Minimum: VisibleSelf, Bookable: false},
+	{Name: "notes", Default: VisibleAdmin, Minimum: VisibleSelf, Bookable: false},
+	{Name: "marketing_consent", Default: VisibleAdmin, Minimum: VisibleAdmin, Bookable: false},
+}
+
+// LookupProfileField finds a profile field by name
+func LookupProfileField(name string) (ProfileField, bool) {
+	for _, field := range ProfileFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return ProfileField{}, false
+}
+
+// Profile is a client's extended profile. It is kept apart from User so
+// that it is only loaded, and filtered by VisibleTo, where it is needed.
+type Profile struct {
+	StudioScoped
+
+	ID                           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID                       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	Phone                        string     `gorm:"type:varchar(32)" json:"phone"`
+	EmergencyContactName         string     `json:"emergency_contact_name"`
+	EmergencyContactPhone        string     `gorm:"type:varchar(32)" json:"emergency_contact_phone"`
+	EmergencyContactRelationship string     `json:"emergency_contact_relationship"`
+	DateOfBirth                  *time.Time `gorm:"type:date" json:"date_of_birth"`
+	Pronouns                     string     `gorm:"type:varchar(50)" json:"pronouns"`
+	Injuries                     string     `json:"injuries"`
+	Notes                        string     `json:"notes"`
+	MarketingConsent             bool       `gorm:"not null;default:false" json:"marketing_consent"`
+	MarketingConsentAt           *time.Time `json:"marketing_consent_at"` // when consent was last given or withdrawn
+
+	// Visibility chosen by the client, by field name; fields not listed use
+	// their default
+	Visibility map[string]Visibility `gorm:"type:jsonb;serializer:json" json:"visibility"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p *Profile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// FieldVisibility is who may see the named field. A choice narrower than
+// the field allows, made before its minimum was raised, is widened to it.
+func (p *Profile) FieldVisibility(name string) Visibility {
+	field, _ := LookupProfileField(name)
+	v, ok := p.Visibility[name]
+	if !ok {
+		return field.Default
+	}
+	if v.rank() < field.Minimum.rank() {
+		return field.Minimum
+	}
+	return v
+}
+
+// Filled reports whether the named field has been filled in
+func (p *Profile) Filled(name string) bool {
+	switch name {
+	case "phone":
+		return strings.TrimSpace(p.Phone) != ""
+	case "emergency_contact":
+		return strings.TrimSpace(p.EmergencyContactName) != "" && strings.TrimSpace(p.EmergencyContactPhone) != ""
+	case "date_of_birth":
+		return p.DateOfBirth != nil
+	case "pronouns":
+		return strings.TrimSpace(p.Pronouns) != ""
+	case "injuries":
+		return strings.TrimSpace(p.Injuries) != ""
+	case "notes":
+		return strings.TrimSpace(p.Notes) != ""
+	}
+	return true
+}
+
+// Missing lists the required fields that haven't been filled in
+func (p *Profile) Missing(required []string) []string {
+	missing := []string{}
+	for _, name := range required {
+		if !p.Filled(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// VisibleTo returns a copy of the profile with the fields audience may not
+// see cleared. Only the client sees their visibility choices.
+func (p Profile) VisibleTo(audience Visibility) Profile {
+	if audience == VisibleSelf {
+		return p
+	}
+	if !p.FieldVisibility("phone").Includes(audience) {
+		p.Phone = ""
+	}
+	if !p.FieldVisibility("emergency_contact").Includes(audience) {
+		p.EmergencyContactName, p.EmergencyContactPhone, p.EmergencyContactRelationship = "", "", ""
+	}
+	if !p.FieldVisibility("date_of_birth").Includes(audience) {
+		p.DateOfBirth = nil
+	}
+	if !p.FieldVisibility("pronouns").Includes(audience) {
+		p.Pronouns = ""
+	}
+	if !p.FieldVisibility("injuries").Includes(audience) {
+		p.Injuries = ""
+	}
+	if !p.FieldVisibility("notes").Includes(audience) {
+		p.Notes = ""
+	}
+	if !p.FieldVisibility("marketing_consent").Includes(audience) {
+		p.MarketingConsent, p.MarketingConsentAt = false, nil
+	}
+	p.Visibility = nil
+	return p
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVisibilityIncludes(t *testing.T) {
+	tests := []struct {
+		shared, audience Visibility
+		want             bool
+	}{
+		{VisibleSelf, VisibleSelf, true},
+		{VisibleSelf, VisibleAdmin, false},
+		{VisibleSelf, VisibleInstructor, false},
+		{VisibleAdmin, VisibleSelf, true},
+		{VisibleAdmin, VisibleAdmin, true},
+		{VisibleAdmin, VisibleInstructor, false},
+		{VisibleInstructor, VisibleSelf, true},
+		{VisibleInstructor, VisibleAdmin, true},
+		{VisibleInstructor, VisibleInstructor, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.shared)+" to "+string(tt.audience), func(t *testing.T) {
+			if got := tt.shared.Includes(tt.audience); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldVisibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  string
+		chosen Visibility // empty when the client hasn't chosen
+		want   Visibility
+	}{
+		{"default", "phone", "", VisibleAdmin},
+		{"narrowed", "phone", VisibleSelf, VisibleSelf},
+		{"widened", "phone", VisibleInstructor, VisibleInstructor},
+		{"emergency contact default", "emergency_contact", "", VisibleInstructor},
+		{"emergency contact down to its floor", "emergency_contact", VisibleAdmin, VisibleAdmin},
+		{"emergency contact below its floor", "emergency_contact", VisibleSelf, VisibleAdmin},
+		{"marketing consent below its floor", "marketing_consent", VisibleSelf, VisibleAdmin},
+		{"marketing consent widened", "marketing_consent", VisibleInstructor, VisibleInstructor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := Profile{Visibility: map[string]Visibility{}}
+			if tt.chosen != "" {
+				profile.Visibility[tt.field] = tt.chosen
+			}
+			if got := profile.FieldVisibility(tt.field); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisibleTo(t *testing.T) {
+	born := time.Date(1990, 4, 2, 0, 0, 0, 0, time.UTC)
+	consented := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	full := Profile{
+		Phone:                        "+44 7700 900000",
+		EmergencyContactName:         "Sam",
+		EmergencyContactPhone:        "+44 7700 900001",
+		EmergencyContactRelationship: "sibling",
+		DateOfBirth:                  &born,
+		Pronouns:                     "they/them",
+		Injuries:                     "left knee",
+		Notes:                        "prefers a front spot",
+		MarketingConsent:             true,
+		MarketingConsentAt:           &consented,
+		// The client tries to hide everything; floors keep some fields shared
+		Visibility: map[string]Visibility{
+			"phone":             VisibleSelf,
+			"emergency_contact": VisibleSelf,
+			"injuries":          VisibleInstructor,
+			"marketing_consent": VisibleSelf,
+		},
+	}
+
+	// visible lists which fields survive, by field name
+	visible := func(p Profile) map[string]bool {
+		return map[string]bool{
+			"phone":             p.Phone != "",
+			"emergency_contact": p.EmergencyContactName != "" && p.EmergencyContactPhone != "" && p.EmergencyContactRelationship != "",
+			"date_of_birth":     p.DateOfBirth != nil,
+			"pronouns":          p.Pronouns != "",
+			"injuries":          p.Injuries != "",
+			"notes":             p.Notes != "",
+			"marketing_consent": p.MarketingConsent && p.MarketingConsentAt != nil,
+		}
+	}
+
+	tests := []struct {
+		audience       Visibility
+		want           map[string]bool
+		keepVisibility bool
+	}{
+		{VisibleSelf, visible(full), true},
+		{VisibleAdmin, map[string]bool{
+			"phone": false, "emergency_contact": true, "date_of_birth": true, "pronouns": true,
+			"injuries": true, "notes": true, "marketing_consent": true,
+		}, false},
+		{VisibleInstructor, map[string]bool{
+			"phone": false, "emergency_contact": false, "date_of_birth": false, "pronouns": true,
+			"injuries": true, "notes": false, "marketing_consent": false,
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.audience), func(t *testing.T) {
+			got := full.VisibleTo(tt.audience)
+			for field, want := range tt.want {
+				if visible(got)[field] != want {
+					t.Errorf("%s: visible %v, want %v", field, !want, want)
+				}
+			}
+			if (got.Visibility != nil) != tt.keepVisibility {
+				t.Errorf("visibility choices shown: %v, want %v", got.Visibility != nil, tt.keepVisibility)
+			}
+		})
+	}
+
+	if full.Phone == "" || full.Visibility == nil {
+		t.Error("VisibleTo changed the original profile")
+	}
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercent DiscountType = "percent"
+	DiscountFixed   DiscountType = "fixed"
+)
+
+// PromoCode is a marketing discount entered at checkout. Codes are stored
+// upper case and are unique per studio, see database.Migrate.
+type PromoCode struct {
+	StudioScoped
+
+	ID              uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code            string       `gorm:"type:varchar(32);not null" json:"code"`
+	Description     string       `json:"description"`
+	DiscountType    DiscountType `gorm:"type:varchar(20);not null" json:"discount_type"`
+	Amount          int64        `gorm:"not null" json:"amount"` // percent off, or minor units of Currency off for fixed discounts
+	Currency        string       `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	MaxRedemptions  *int         `json:"max_redemptions"` // nil for unlimited
+	PerUserLimit    *int         `json:"per_user_limit"`  // nil for unlimited
+	ValidFrom       *time.Time   `json:"valid_from"`
+	ValidUntil      *time.Time   `json:"valid_until"`
+	CourseIDs       []string     `gorm:"type:text[]" json:"course_ids"` // courses the code applies to; empty for any
+	RedemptionCount int          `gorm:"not null;default:0" json:"redemption_count"`
+	IsActive        bool         `gorm:"default:true" json:"is_active"`
+	CreatedBy       uuid.UUID    `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// ValidAt reports whether the code is active and within its validity window
+func (p *PromoCode) ValidAt(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	return p.ValidUntil == nil || now.Before(*p.ValidUntil)
+}
+
+// AppliesTo reports whether the code may be used for courseID
+func (p *PromoCode) AppliesTo(courseID uuid.UUID) bool {
+	if len(p.CourseIDs) == 0 {
+		return true
+	}
+	for _, id := range p.CourseIDs {
+		if id == courseID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// Exhausted reports whether the code has been redeemed as often as allowed
+func (p *PromoCode) Exhausted() bool {
+	return p.MaxRedemptions != nil && p.RedemptionCount >= *p.MaxRedemptions
+}
+
+// Discount is the amount taken off price, rounded down to the cent and never
+// more than the price
+func (p *PromoCode) Discount(price int64) int64 {
+	var discount int64
+	switch p.DiscountType {
+	case DiscountPercent:
+		discount = price * p.Amount / 100
+	case DiscountFixed:
+		discount = p.Amount
+	}
+	if discount > price {
+		return price
+	}
+	return discount
+}
+
+// PromoRedemption records one use of a promo code
+type PromoRedemption struct {
+	StudioScoped
+
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PromoCodeID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"promo_code_id"`
+	UserID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CourseEnrollmentID *uuid.UUID `gorm:"type:uuid;index" json:"course_enrollment_id"`
+	Amount             int64      `gorm:"not null" json:"amount"` // discount given, in cents
+	CreatedAt          time.Time  `json:"created_at"`
+
+	// Relationships
+	PromoCode *PromoCode `json:"promo_code,omitempty"`
+	User      *User      `json:"user,omitempty"`
+}
+
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// GiftCard is stored value that clients redeem towards purchases. Codes are
+// generated by the studio and unique per studio, see database.Migrate.
+type GiftCard struct {
+	StudioScoped
+
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code           string     `gorm:"type:varchar(32);not null" json:"code"`
+	InitialBalance int64      `gorm:"not null" json:"initial_balance"` // in cents
+	Balance        int64      `gorm:"not null" json:"balance"`         // in cents
+	Currency       string     `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	IssuedTo       string     `json:"issued_to"` // recipient's name or email, for the studio's records
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       bool       `gorm:"default:true" json:"is_active"`
+	CreatedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (g *GiftCard) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return nil
+}
+
+// UsableAt reports whether the card can be redeemed at now
+func (g *GiftCard) UsableAt(now time.Time) bool {
+	if !g.IsActive || g.Balance <= 0 {
+		return false
+	}
+	return g.ExpiresAt == nil || now.Before(*g.ExpiresAt)
+}
+
+// GiftCardRedemption records value taken from a gift card
+type GiftCardRedemption struct {
+	StudioScoped
+
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	GiftCardID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"gift_card_id"`
+	UserID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CourseEnrollmentID *uuid.UUID `gorm:"type:uuid;index" json:"course_enrollment_id"`
+	CoursePaymentID    *uuid.UUID `gorm:"type:uuid" json:"course_payment_id"`
+	Amount             int64      `gorm:"not null" json:"amount"` // in cents
+	CreatedAt          time.Time  `json:"created_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty"`
+}
+
+func (r *GiftCardRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReceiptKind string
+
+const (
+	ReceiptPayment    ReceiptKind = "receipt"     // money received
+	ReceiptCreditNote ReceiptKind = "credit_note" // money given back
+)
+
+// Receipt is issued for every payment received, and a credit note for every
+// refund. Studio and billing details are copied in when it is issued, so a
+// receipt reads the same however often it is downloaded; re-issuing it copies
+// them again. Exactly one of CoursePaymentID, GiftCardID and RefundID is set.
+type Receipt struct {
+	StudioScoped
+
+	ID                  uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Kind                ReceiptKind `gorm:"type:varchar(20);not null;default:'receipt'" json:"kind"`
+	Number              int64       `gorm:"not null" json:"number"`         // sequential per studio across both kinds, see database.Migrate
+	UserID              *uuid.UUID  `gorm:"type:uuid;index" json:"user_id"` // nil for a sale to someone without an account
+	CoursePaymentID     *uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"course_payment_id"`
+	GiftCardID          *uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"gift_card_id"`          // the sale of a gift card
+	RefundID            *uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"refund_id"`             // set on credit notes
+	CreditTransactionID *uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"credit_transaction_id"` // a purchase of household credit
+	Revision            int         `gorm:"not null;default:1" json:"revision"`                 // incremented when re-issued
+	IssuedAt            time.Time   `gorm:"not null" json:"issued_at"`
+	Timezone            string      `gorm:"type:varchar(64);not null" json:"timezone"` // dates on the receipt are shown in it
+
+	StudioName      string `json:"studio_name"`
+	StudioLegalName string `json:"studio_legal_name"`
+	StudioAddress   string `json:"studio_address"`
+	StudioTaxID     string `json:"studio_tax_id"`
+	BilledToName    string `json:"billed_to_name"`
+	BilledToEmail   string `json:"billed_to_email"`
+
+	PaymentMethod string    `json:"payment_method"`
+	PaidAt        time.Time `gorm:"not null" json:"paid_at"`
+	Currency      string    `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	Subtotal      int64     `gorm:"not null" json:"subtotal"`      // before tax, in minor units of Currency
+	Tax           int64     `gorm:"not null;default:0" json:"tax"` // the part of this payment that is tax
+	TaxLabel      string    `json:"tax_label"`                     // the rates applied, e.g. "VAT 20%"
+	Total         int64     `gorm:"not null" json:"total"`         // amount paid
+	BalanceDue    int64     `gorm:"not null" json:"balance_due"`   // left to pay after this payment
+
+	EmailedAt     *time.Time `json:"emailed_at"`
+	EmailAttempts int        `gorm:"not null;default:0" json:"email_attempts"`
+	EmailError    string     `json:"email_error,omitempty"`
+	EmailRetryAt  *time.Time `json:"email_retry_at,omitempty"` // when a failed email is next tried
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	Lines []ReceiptLine `json:"lines"`
+}
+
+func (r *Receipt) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCreditNote reports whether the receipt records money given back
+func (r *Receipt) IsCreditNote() bool {
+	return r.Kind == ReceiptCreditNote
+}
+
+// DisplayNumber is the receipt number as printed
+func (r *Receipt) DisplayNumber() string {
+	if r.IsCreditNote() {
+		return fmt.Sprintf("CN-%06d", r.Number)
+	}
+	return fmt.Sprintf("R-%06d", r.Number)
+}
+
+// ReceiptLine is one item on a receipt
+type ReceiptLine struct {
+	StudioScoped
+
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReceiptID   uuid.UUID `gorm:"type:uuid;not null;index" json:"receipt_id"`
+	Position    int       `gorm:"not null" json:"position"`
+	Description string    `gorm:"not null" json:"description"`
+	Quantity    int       `gorm:"not null;default:1" json:"quantity"`
+	UnitAmount  int64     `gorm:"not null" json:"unit_amount"` // in cents
+	Amount      int64     `gorm:"not null" json:"amount"`      // in cents
+}
+
+func (l *ReceiptLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
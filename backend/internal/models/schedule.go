@@ -17,21 +17,75 @@ const (
 )
 
 type Schedule struct {
+	StudioScoped
+
 	ID                uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	ClassID           uuid.UUID      `gorm:"type:uuid;not null" json:"class_id"`
 	StartTime         time.Time      `gorm:"not null" json:"start_time"`
 	EndTime           time.Time      `gorm:"not null" json:"end_time"`
 	RecurrenceType    RecurrenceType `gorm:"type:varchar(20);default:'once'" json:"recurrence_type"`
 	RecurrenceEndDate *time.Time     `json:"recurrence_end_date"`
-	DayOfWeek         *int           `json:"day_of_week"`   // 0-6 (Sunday-Saturday) for weekly
-	DayOfMonth        *int           `json:"day_of_month"`  // 1-31 for monthly
+	DayOfWeek         *int           `json:"day_of_week"`                          // 0-6 (Sunday-Saturday) for weekly
+	DayOfMonth        *int           `json:"day_of_month"`                         // 1-31 for monthly
+	InstructorID      *uuid.UUID     `gorm:"type:uuid;index" json:"instructor_id"` // overrides the class instructor
+	RoomID            *uuid.UUID     `gorm:"type:uuid;index" json:"room_id"`
+	CourseID          *uuid.UUID     `gorm:"type:uuid;index" json:"course_id"`                        // a session of a course, booked through the course
+	PrivateCapacity   int            `gorm:"not null;default:0" json:"private_capacity"`              // places on a private session (1 or 2); 0 for public classes
+	InviteCode        string         `gorm:"index" json:"-"`                                          // lets a second client join a semi-private session, see PrivateSessionHandler.Join
+	Timezone          string         `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"` // IANA zone of the room's location, else the studio's
 	CreatedBy         uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
-	
+
 	// Relationships
-	Class       Class        `json:"class,omitempty"`
-	Enrollments []Enrollment `json:"enrollments,omitempty"`
+	Class         Class                    `json:"class,omitempty"`
+	Instructor    *User                    `gorm:"foreignKey:InstructorID;constraint:OnDelete:SET NULL" json:"instructor,omitempty"`
+	Room          *Room                    `json:"room,omitempty"`
+	Course        *Course                  `json:"course,omitempty"`
+	Enrollments   []Enrollment             `json:"enrollments,omitempty"`
+	Substitutions []Substitution           `json:"substitutions,omitempty"`
+	Cancellations []OccurrenceCancellation `json:"cancellations,omitempty"`
+}
+
+// EffectiveInstructorID returns the schedule's instructor override, falling
+// back to the class instructor. Class must be loaded.
+func (s *Schedule) EffectiveInstructorID() *uuid.UUID {
+	if s.InstructorID != nil {
+		return s.InstructorID
+	}
+	return s.Class.InstructorID
+}
+
+// Capacity is the number of places on the schedule: the class capacity,
+// limited by the room when one is assigned, or the places booked on a
+// private session. Class and Room must be loaded.
+func (s *Schedule) Capacity() int {
+	if s.PrivateCapacity > 0 {
+		return s.PrivateCapacity
+	}
+	if s.Room != nil && s.Room.Capacity < s.Class.Capacity {
+		return s.Room.Capacity
+	}
+	return s.Class.Capacity
+}
+
+// Zone returns the time zone the schedule recurs in: sessions keep their
+// wall-clock time there across daylight saving changes
+func (s *Schedule) Zone() *time.Location {
+	return LoadZone(s.Timezone)
+}
+
+// AfterFind presents the schedule's times in its own zone, so API responses
+// carry the studio's local offset rather than UTC
+func (s *Schedule) AfterFind(tx *gorm.DB) error {
+	zone := s.Zone()
+	s.StartTime = s.StartTime.In(zone)
+	s.EndTime = s.EndTime.In(zone)
+	if s.RecurrenceEndDate != nil {
+		end := s.RecurrenceEndDate.In(zone)
+		s.RecurrenceEndDate = &end
+	}
+	return nil
 }
 
 func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
@@ -40,4 +94,3 @@ func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
-
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SeriesStatus string
+
+const (
+	SeriesActive    SeriesStatus = "active"
+	SeriesCancelled SeriesStatus = "cancelled"
+)
+
+// EnrollmentSeries is a student's booking of every session of a recurring
+// schedule up to a date, made in one action. Each session is booked as its
+// own Enrollment so full sessions can be waitlisted individually.
+type EnrollmentSeries struct {
+	StudioScoped
+
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	ScheduleID  uuid.UUID    `gorm:"type:uuid;not null;index" json:"schedule_id"`
+	Until       time.Time    `gorm:"not null" json:"until"`
+	Status      SeriesStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	CancelledAt *time.Time   `json:"cancelled_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// Relationships
+	Schedule    *Schedule    `json:"schedule,omitempty"`
+	Enrollments []Enrollment `gorm:"foreignKey:SeriesID" json:"enrollments,omitempty"`
+}
+
+func (s *EnrollmentSeries) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
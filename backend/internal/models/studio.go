@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Studio is a tenant: one brand run from this deployment. Every other model
+// belongs to exactly one studio through StudioScoped.
+type Studio struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Slug        string    `gorm:"uniqueIndex;not null" json:"slug"` // matched against the X-Studio header
+	Name        string    `gorm:"not null" json:"name"`
+	Hostnames   []string  `gorm:"type:text[]" json:"hostnames"`                            // request hosts served as this studio
+	FrontendURL string    `json:"frontend_url"`                                            // where logins are redirected; defaults to FRONTEND_URL
+	Timezone    string    `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"` // IANA zone classes are scheduled in
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+
+	// Private session booking policy
+	PrivateBufferMinutes int `gorm:"not null;default:15" json:"private_buffer_minutes"` // kept free around an instructor's other sessions
+	PrivateNoticeHours   int `gorm:"not null;default:24" json:"private_notice_hours"`   // minimum notice for booking
+
+	// Profile fields clients must fill in before they book, see ProfileFields
+	RequiredProfileFields []string `gorm:"type:text[]" json:"required_profile_fields"`
+
+	// Pricing: every price in the studio is in Currency. Items sold outside
+	// any location are taxed with PricesIncludeTax and the default TaxRates.
+	Currency         string `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"` // ISO 4217
+	PricesIncludeTax bool   `gorm:"not null;default:false" json:"prices_include_tax"`
+
+	// Printed on receipts
+	LegalName string `json:"legal_name"`
+	Address   string `json:"address"`
+	TaxID     string `json:"tax_id"` // tax or business registration number
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Studio) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Zone returns the studio's time zone
+func (s *Studio) Zone() *time.Location {
+	return LoadZone(s.Timezone)
+}
+
+// StudioScoped is embedded by every model that belongs to a studio. The
+// tenancy plugin fills it in on create and filters every query by it.
+type StudioScoped struct {
+	StudioID uuid.UUID `gorm:"type:uuid;index" json:"studio_id"`
+}
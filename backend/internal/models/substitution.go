@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SubstitutionStatus string
+
+const (
+	SubstitutionRequested SubstitutionStatus = "requested" // waiting for an instructor to offer cover
+	SubstitutionAccepted  SubstitutionStatus = "accepted"  // cover offered, waiting for admin approval
+	SubstitutionApproved  SubstitutionStatus = "approved"
+	SubstitutionRejected  SubstitutionStatus = "rejected"
+	SubstitutionCancelled SubstitutionStatus = "cancelled"
+)
+
+// Substitution reassigns a single occurrence of a schedule to another
+// instructor, leaving the rest of the series untouched
+type Substitution struct {
+	StudioScoped
+
+	ID                   uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ScheduleID           uuid.UUID          `gorm:"type:uuid;not null;index" json:"schedule_id"`
+	OccurrenceStart      time.Time          `gorm:"not null;index" json:"occurrence_start"`
+	OccurrenceEnd        time.Time          `gorm:"not null" json:"occurrence_end"`
+	OriginalInstructorID *uuid.UUID         `gorm:"type:uuid;index" json:"original_instructor_id"`
+	SubstituteID         *uuid.UUID         `gorm:"type:uuid;index" json:"substitute_id"`
+	Status               SubstitutionStatus `gorm:"type:varchar(20);not null;default:'requested'" json:"status"`
+	Reason               string             `json:"reason"`
+	RequestedBy          uuid.UUID          `gorm:"type:uuid;not null" json:"requested_by"`
+	DecidedBy            *uuid.UUID         `gorm:"type:uuid" json:"decided_by"`
+	DecidedAt            *time.Time         `json:"decided_at"`
+	CreatedAt            time.Time          `json:"created_at"`
+	UpdatedAt            time.Time          `json:"updated_at"`
+
+	// Relationships
+	Schedule           *Schedule           `json:"schedule,omitempty"`
+	OriginalInstructor *User               `gorm:"foreignKey:OriginalInstructorID" json:"original_instructor,omitempty"`
+	Substitute         *User               `gorm:"foreignKey:SubstituteID" json:"substitute,omitempty"`
+	Events             []SubstitutionEvent `json:"events,omitempty"`
+}
+
+// IsOpen reports whether the request can still change state
+func (s *Substitution) IsOpen() bool {
+	return s.Status == SubstitutionRequested || s.Status == SubstitutionAccepted
+}
+
+func (s *Substitution) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// SubstitutionEvent records every state change of a substitution, giving
+// payroll an audit trail of who covered what and who signed it off
+type SubstitutionEvent struct {
+	StudioScoped
+
+	ID             uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubstitutionID uuid.UUID          `gorm:"type:uuid;not null;index" json:"substitution_id"`
+	Status         SubstitutionStatus `gorm:"type:varchar(20);not null" json:"status"`
+	ActorID        uuid.UUID          `gorm:"type:uuid;not null" json:"actor_id"`
+	SubstituteID   *uuid.UUID         `gorm:"type:uuid" json:"substitute_id"`
+	Note           string             `json:"note"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+func (e *SubstitutionEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
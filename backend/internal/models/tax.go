@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxRate is one tax charged on sales at a location, such as VAT or a state
+// and a city sales tax. Rates without a location are the studio's defaults.
+type TaxRate struct {
+	StudioScoped
+
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LocationID  *uuid.UUID `gorm:"type:uuid;index" json:"location_id"`
+	Name        string     `gorm:"not null" json:"name"`
+	BasisPoints int        `gorm:"not null" json:"basis_points"` // 2000 is 20%; never a float
+	Position    int        `gorm:"not null;default:0" json:"position"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (r *TaxRate) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
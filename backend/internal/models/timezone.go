@@ -0,0 +1,40 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	// Bundle the IANA database so zones resolve on hosts without zoneinfo
+	_ "time/tzdata"
+)
+
+// DefaultTimezone is used by studios that haven't set one
+const DefaultTimezone = "UTC"
+
+var zones sync.Map // name -> *time.Location
+
+// LoadZone returns the named IANA time zone, or UTC for an empty or unknown
+// name. Zones are cached since recurrence expansion looks them up constantly.
+func LoadZone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	if zone, ok := zones.Load(name); ok {
+		return zone.(*time.Location)
+	}
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	zones.Store(name, zone)
+	return zone
+}
+
+// ValidTimezone reports whether name is an IANA time zone such as Europe/London
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
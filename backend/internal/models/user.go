@@ -12,19 +12,25 @@ type UserRole string
 const (
 	RoleClient UserRole = "CLIENT"
 	RoleAdmin  UserRole = "ADMIN"
+	// RoleSuperAdmin administers every studio and manages the studios themselves
+	RoleSuperAdmin UserRole = "SUPER_ADMIN"
 )
 
 type User struct {
+	StudioScoped
+
 	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email          string    `gorm:"unique;not null" json:"email"`
+	Email          string    `gorm:"not null" json:"email"` // unique per studio among users who sign in, see database.Migrate
 	Name           string    `gorm:"not null" json:"name"`
 	AvatarURL      string    `json:"avatar_url"`
 	Role           UserRole  `gorm:"type:varchar(20);default:'CLIENT'" json:"role"`
-	AuthProvider   string    `gorm:"not null" json:"auth_provider"` // google, facebook
+	AuthProvider   string    `gorm:"not null" json:"auth_provider"` // google, facebook, or dependent
 	AuthProviderID string    `gorm:"not null" json:"auth_provider_id"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 
+	HouseholdID *uuid.UUID `gorm:"type:uuid;index" json:"household_id"`
+
 	// Instructor fields
 	IsInstructor          bool     `gorm:"default:false" json:"is_instructor"`
 	InstructorBio         string   `json:"instructor_bio,omitempty"`
@@ -35,6 +41,7 @@ type User struct {
 
 	// Relationships
 	Enrollments []Enrollment `json:"enrollments,omitempty"`
+	Profile     *Profile     `json:"profile,omitempty"` // only loaded for those allowed to see it
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -45,7 +52,12 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (u *User) IsAdmin() bool {
-	return u.Role == RoleAdmin
+	return u.Role == RoleAdmin || u.Role == RoleSuperAdmin
+}
+
+// IsDependent reports whether u is a household dependent without a login
+func (u *User) IsDependent() bool {
+	return u.AuthProvider == AuthDependent
 }
 
 func (u *User) IsInstructorUser() bool {
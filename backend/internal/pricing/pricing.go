@@ -0,0 +1,152 @@
+// Package pricing computes prices, tax and totals. Amounts are integers in
+// the minor unit of their ISO 4217 currency (cents, or yen for JPY), and
+// every total in the app is computed here so that bookings, courses,
+// receipts and the ledger agree to the unit.
+package pricing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is used by studios that haven't chosen one
+const DefaultCurrency = "USD"
+
+// minorUnits is the number of decimal places of each supported currency
+var minorUnits = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2,
+	"CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2,
+	"HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JPY": 0,
+	"KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PHP": 2, "PLN": 2, "RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2,
+	"TRY": 2, "TWD": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// Format renders amount, in minor units of currency, with its decimal
+// places and code, e.g. "12.50 EUR" or "1500 JPY"
+func Format(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits, ok := minorUnits[currency]
+	if !ok {
+		digits = 2
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, currency)
+	}
+	scale := int64(1)
+	for i := 0; i < digits; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, digits, amount%scale, currency)
+}
+
+// Rate is one tax levied on a price, in basis points: 2000 is 20%
+type Rate struct {
+	Name        string `json:"name"`
+	BasisPoints int    `json:"basis_points"`
+}
+
+// Label is how the rate is printed, e.g. "VAT 20%" or "City tax 4.5%"
+func (r Rate) Label() string {
+	percent := fmt.Sprintf("%d.%02d", r.BasisPoints/100, r.BasisPoints%100)
+	percent = strings.TrimRight(strings.TrimRight(percent, "0"), ".")
+	return fmt.Sprintf("%s %s%%", r.Name, percent)
+}
+
+// Rule is how a price is taxed where it is sold
+type Rule struct {
+	Inclusive bool   // prices already include the tax
+	Rates     []Rate // applied to the net amount and added together
+}
+
+// TaxAmount is the tax charged at one rate
+type TaxAmount struct {
+	Rate
+	Amount int64 `json:"amount"`
+}
+
+// Breakdown is a price split into its net amount and tax
+type Breakdown struct {
+	Currency string      `json:"currency"`
+	Subtotal int64       `json:"subtotal"` // before tax
+	Tax      int64       `json:"tax"`
+	Total    int64       `json:"total"`
+	Taxes    []TaxAmount `json:"taxes"`
+}
+
+// Compute applies rule to a price in minor units of currency. With
+// exclusive rules tax is added on top of price; with inclusive rules it is
+// taken out of price, so the total stays the price shown. Each rate is
+// rounded half up to the minor unit.
+func Compute(price int64, currency string, rule Rule) Breakdown {
+	b := Breakdown{Currency: currency, Taxes: []TaxAmount{}}
+	if price <= 0 || len(rule.Rates) == 0 {
+		b.Subtotal = max(price, 0)
+		b.Total = b.Subtotal
+		return b
+	}
+
+	combined := 0
+	for _, rate := range rule.Rates {
+		combined += rate.BasisPoints
+	}
+	if rule.Inclusive {
+		b.Total = price
+		b.Subtotal = divRound(price*10000, int64(10000+combined))
+	} else {
+		b.Subtotal = price
+	}
+
+	for _, rate := range rule.Rates {
+		amount := divRound(b.Subtotal*int64(rate.BasisPoints), 10000)
+		b.Taxes = append(b.Taxes, TaxAmount{Rate: rate, Amount: amount})
+		b.Tax += amount
+	}
+	if rule.Inclusive {
+		// Per-rate rounding may not add up to the tax in the price; the
+		// last rate absorbs the difference so the total is unchanged
+		b.Taxes[len(b.Taxes)-1].Amount += b.Total - b.Subtotal - b.Tax
+		b.Tax = b.Total - b.Subtotal
+	} else {
+		b.Total = b.Subtotal + b.Tax
+	}
+	return b
+}
+
+// TaxShare is the part of tax carried by the first paid of a total that
+// includes tax. Allocating each payment TaxShare(after) - TaxShare(before)
+// splits the tax across installments without rounding drift: the shares of
+// payments that add up to total add up to tax exactly.
+func TaxShare(paid, total, tax int64) int64 {
+	if total <= 0 || paid <= 0 {
+		return 0
+	}
+	if paid >= total {
+		return tax
+	}
+	return divRound(paid*tax, total)
+}
+
+// Label joins the labels of the rates in b, e.g. "VAT 20%"
+func (b Breakdown) Label() string {
+	labels := make([]string, len(b.Taxes))
+	for i, t := range b.Taxes {
+		labels[i] = t.Label()
+	}
+	return strings.Join(labels, ", ")
+}
+
+// divRound divides non-negative a by positive b, rounding half up
+func divRound(a, b int64) int64 {
+	return (a + b/2) / b
+}
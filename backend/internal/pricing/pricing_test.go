@@ -0,0 +1,89 @@
+package pricing
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	vat := []Rate{{Name: "VAT", BasisPoints: 2000}}
+	salesTax := []Rate{{Name: "State", BasisPoints: 625}, {Name: "City", BasisPoints: 200}}
+
+	tests := []struct {
+		name                 string
+		price                int64
+		rule                 Rule
+		subtotal, tax, total int64
+		rateAmounts          []int64
+	}{
+		{"no tax", 2500, Rule{}, 2500, 0, 2500, nil},
+		{"free", 0, Rule{Rates: vat}, 0, 0, 0, nil},
+		{"exclusive", 2500, Rule{Rates: vat}, 2500, 500, 3000, []int64{500}},
+		{"inclusive", 3000, Rule{Inclusive: true, Rates: vat}, 2500, 500, 3000, []int64{500}},
+		{"inclusive rounds net", 1999, Rule{Inclusive: true, Rates: vat}, 1666, 333, 1999, []int64{333}},
+		{"exclusive rounds half up", 1004, Rule{Rates: salesTax}, 1004, 83, 1087, []int64{63, 20}},
+		{"inclusive split keeps total", 1087, Rule{Inclusive: true, Rates: salesTax}, 1004, 83, 1087, []int64{63, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Compute(tt.price, "EUR", tt.rule)
+			if b.Subtotal != tt.subtotal || b.Tax != tt.tax || b.Total != tt.total {
+				t.Fatalf("got subtotal %d tax %d total %d, want %d %d %d", b.Subtotal, b.Tax, b.Total, tt.subtotal, tt.tax, tt.total)
+			}
+			if b.Subtotal+b.Tax != b.Total {
+				t.Errorf("subtotal %d + tax %d != total %d", b.Subtotal, b.Tax, b.Total)
+			}
+			if len(b.Taxes) != len(tt.rateAmounts) {
+				t.Fatalf("got %d tax lines, want %d", len(b.Taxes), len(tt.rateAmounts))
+			}
+			var sum int64
+			for i, want := range tt.rateAmounts {
+				if b.Taxes[i].Amount != want {
+					t.Errorf("%s: got %d, want %d", b.Taxes[i].Name, b.Taxes[i].Amount, want)
+				}
+				sum += b.Taxes[i].Amount
+			}
+			if len(b.Taxes) > 0 && sum != b.Tax {
+				t.Errorf("tax lines add up to %d, want %d", sum, b.Tax)
+			}
+		})
+	}
+}
+
+func TestTaxShareAddsUpAcrossInstallments(t *testing.T) {
+	total, tax := int64(10000), int64(1667)
+	payments := []int64{3333, 3333, 3334}
+
+	var paid, allocated int64
+	for _, amount := range payments {
+		allocated += TaxShare(paid+amount, total, tax) - TaxShare(paid, total, tax)
+		paid += amount
+	}
+	if allocated != tax {
+		t.Errorf("installments carry %d tax, want %d", allocated, tax)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1250, "EUR", "12.50 EUR"},
+		{5, "USD", "0.05 USD"},
+		{-1250, "GBP", "-12.50 GBP"},
+		{1500, "JPY", "1500 JPY"},
+		{12345, "KWD", "12.345 KWD"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestRateLabel(t *testing.T) {
+	for bp, want := range map[int]string{2000: "VAT 20%", 825: "VAT 8.25%", 450: "VAT 4.5%", 5: "VAT 0.05%"} {
+		if got := (Rate{Name: "VAT", BasisPoints: bp}).Label(); got != want {
+			t.Errorf("Label(%d) = %q, want %q", bp, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"yoga-studio-app/internal/mailer"
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/pricing"
+	"yoga-studio-app/internal/tenancy"
+
+	"gorm.io/gorm"
+)
+
+// maxEmailAttempts is how often sending a receipt is tried before giving up;
+// re-issuing the receipt starts over
+const maxEmailAttempts = 5
+
+// emailRetryDelay is how long after a first failed attempt a receipt is
+// emailed again; the wait doubles with each further attempt
+const emailRetryDelay = 5 * time.Minute
+
+// deliveryBatch caps the receipts sent in one pass
+const deliveryBatch = 50
+
+// Deliver emails receipts that haven't been sent, for every studio, every
+// interval until ctx is done. Receipts are queued simply by being issued.
+func Deliver(ctx context.Context, db *gorm.DB, sender *mailer.Sender, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deliverPending(ctx, db, sender)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func deliverPending(ctx context.Context, db *gorm.DB, sender *mailer.Sender) {
+	db = db.WithContext(tenancy.AllStudios(ctx))
+	now := time.Now()
+
+	var pending []models.Receipt
+	if err := db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).
+		Where("emailed_at IS NULL AND email_attempts < ? AND billed_to_email <> ''", maxEmailAttempts).
+		Where("email_retry_at IS NULL OR email_retry_at <= ?", now).
+		Order("created_at").Limit(deliveryBatch).Find(&pending).Error; err != nil {
+		log.Printf("ERROR: Failed to load receipts to email: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		receipt := &pending[i]
+
+		// Claim the receipt by counting the attempt, so that another replica
+		// running the same pass skips it
+		claim := db.Model(&models.Receipt{}).
+			Where("id = ? AND email_attempts = ? AND emailed_at IS NULL", receipt.ID, receipt.EmailAttempts).
+			UpdateColumn("email_attempts", receipt.EmailAttempts+1)
+		if claim.Error != nil {
+			log.Printf("ERROR: Failed to claim receipt for email: receipt=%s, error=%v", receipt.ID, claim.Error)
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		attempts := receipt.EmailAttempts + 1
+		updates := map[string]interface{}{"email_error": ""}
+		if err := sender.Send(ctx, message(receipt)); err != nil {
+			log.Printf("WARN: Receipt email failed: receipt=%s, attempt=%d, error=%v", receipt.ID, attempts, err)
+			updates["email_error"] = err.Error()
+			updates["email_retry_at"] = time.Now().Add(retryDelay(attempts))
+		} else {
+			updates["emailed_at"] = time.Now()
+		}
+		if err := db.Model(&models.Receipt{}).Where("id = ?", receipt.ID).UpdateColumns(updates).Error; err != nil {
+			log.Printf("ERROR: Failed to record receipt email: receipt=%s, error=%v", receipt.ID, err)
+		}
+	}
+}
+
+// retryDelay is how long to wait after a receipt's attempts-th failed email
+func retryDelay(attempts int) time.Duration {
+	return emailRetryDelay << (attempts - 1)
+}
+
+// message is the email a receipt is sent in
+func message(r *models.Receipt) mailer.Message {
+	body := fmt.Sprintf("Hi %s,\n\nThank you for your payment of %s to %s. Your receipt %s is attached.\n\n%s\n",
+		r.BilledToName, pricing.Format(r.Total, r.Currency), r.StudioName, r.DisplayNumber(), r.StudioName)
+	subject := fmt.Sprintf("Your receipt %s from %s", r.DisplayNumber(), r.StudioName)
+	if r.IsCreditNote() {
+		body = fmt.Sprintf("Hi %s,\n\n%s has refunded %s to you. Your credit note %s is attached.\n\n%s\n",
+			r.BilledToName, r.StudioName, pricing.Format(r.Total, r.Currency), r.DisplayNumber(), r.StudioName)
+		subject = fmt.Sprintf("Your credit note %s from %s", r.DisplayNumber(), r.StudioName)
+	}
+	return mailer.Message{
+		To:      r.BilledToEmail,
+		Subject: subject,
+		Body:    body,
+		Attachments: []mailer.Attachment{{
+			Filename:    Filename(r),
+			ContentType: "application/pdf",
+			Data:        Render(r),
+		}},
+	}
+}
@@ -0,0 +1,148 @@
+// Package receipts renders receipts as PDF and emails them to clients.
+package receipts
+
+import (
+	"fmt"
+	"strings"
+
+	"yoga-studio-app/internal/models"
+	"yoga-studio-app/internal/pricing"
+)
+
+// Layout, in points from the bottom left of an A4 page
+const (
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50
+	columnQty    = 370.0
+	columnUnit   = 460.0
+	lineHeight   = 14.0
+	maxLineChars = 55
+)
+
+// labels are the words that differ between receipts and credit notes
+type labels struct {
+	title, number, date, total string
+}
+
+func labelsFor(r *models.Receipt) labels {
+	if r.IsCreditNote() {
+		return labels{title: "CREDIT NOTE", number: "Credit note no.", date: "Refunded", total: "Total refunded"}
+	}
+	return labels{title: "RECEIPT", number: "Receipt no.", date: "Paid", total: "Total paid"}
+}
+
+// Filename is the name a receipt is downloaded and attached as
+func Filename(r *models.Receipt) string {
+	if r.IsCreditNote() {
+		return "credit-note-" + r.DisplayNumber() + ".pdf"
+	}
+	return "receipt-" + r.DisplayNumber() + ".pdf"
+}
+
+// Render lays out r as a one page PDF
+func Render(r *models.Receipt) []byte {
+	var d document
+	zone := models.LoadZone(r.Timezone)
+	label := labelsFor(r)
+	amount := func(v int64) string {
+		return pricing.Format(v, r.Currency)
+	}
+	y := pageHeight - 60
+
+	// Studio details
+	d.text(marginLeft, y, 18, true, r.StudioName)
+	d.textRight(marginRight, y, 18, true, label.title)
+	y -= 20
+	if r.StudioLegalName != "" && r.StudioLegalName != r.StudioName {
+		d.text(marginLeft, y, 10, false, r.StudioLegalName)
+		y -= lineHeight
+	}
+	for _, line := range strings.Split(r.StudioAddress, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			d.text(marginLeft, y, 10, false, line)
+			y -= lineHeight
+		}
+	}
+	if r.StudioTaxID != "" {
+		d.text(marginLeft, y, 10, false, "Tax ID: "+r.StudioTaxID)
+		y -= lineHeight
+	}
+
+	// Receipt and client details
+	y -= 20
+	top := y
+	if r.BilledToName != "" || r.BilledToEmail != "" {
+		d.text(marginLeft, y, 10, true, "Billed to")
+		y -= lineHeight
+		d.text(marginLeft, y, 10, false, r.BilledToName)
+		y -= lineHeight
+		d.text(marginLeft, y, 10, false, r.BilledToEmail)
+	}
+
+	y = top
+	for _, field := range [][2]string{
+		{label.number, r.DisplayNumber()},
+		{"Issued", r.IssuedAt.In(zone).Format("2 Jan 2006")},
+		{label.date, r.PaidAt.In(zone).Format("2 Jan 2006")},
+		{"Payment method", r.PaymentMethod},
+	} {
+		d.text(columnQty-40, y, 10, true, field[0])
+		d.textRight(marginRight, y, 10, false, field[1])
+		y -= lineHeight
+	}
+
+	// Line items
+	y -= 30
+	d.text(marginLeft, y, 10, true, "Description")
+	d.textRight(columnQty, y, 10, true, "Qty")
+	d.textRight(columnUnit, y, 10, true, "Unit price")
+	d.textRight(marginRight, y, 10, true, "Amount")
+	y -= 6
+	d.rule(marginLeft, marginRight, y)
+	y -= lineHeight + 2
+	for _, line := range r.Lines {
+		d.text(marginLeft, y, 10, false, truncate(line.Description, maxLineChars))
+		d.textRight(columnQty, y, 10, false, fmt.Sprint(line.Quantity))
+		d.textRight(columnUnit, y, 10, false, amount(line.UnitAmount))
+		d.textRight(marginRight, y, 10, false, amount(line.Amount))
+		y -= lineHeight + 4
+	}
+	d.rule(marginLeft, marginRight, y+lineHeight/2)
+
+	// Totals
+	y -= 6
+	d.text(columnUnit-60, y, 10, false, "Subtotal")
+	d.textRight(marginRight, y, 10, false, amount(r.Subtotal))
+	y -= lineHeight + 2
+	if r.Tax > 0 || r.TaxLabel != "" {
+		// The rates go to the left of the label, where the line items end
+		d.textRight(columnUnit-70, y, 9, false, truncate(r.TaxLabel, maxLineChars))
+		d.text(columnUnit-60, y, 10, false, "Tax")
+		d.textRight(marginRight, y, 10, false, amount(r.Tax))
+		y -= lineHeight + 2
+	}
+	d.text(columnUnit-60, y, 11, true, label.total)
+	d.textRight(marginRight, y, 11, true, amount(r.Total))
+	if r.BalanceDue > 0 {
+		y -= lineHeight + 2
+		d.text(columnUnit-60, y, 10, false, "Balance due")
+		d.textRight(marginRight, y, 10, false, amount(r.BalanceDue))
+	}
+
+	footer := "Thank you for practising with " + r.StudioName + "."
+	if r.Revision > 1 {
+		footer += fmt.Sprintf(" Re-issued %s, revision %d.", strings.ToLower(label.title), r.Revision)
+	}
+	d.text(marginLeft, 60, 9, false, footer)
+
+	return d.bytes()
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
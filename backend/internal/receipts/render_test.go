@@ -0,0 +1,78 @@
+package receipts
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"yoga-studio-app/internal/models"
+)
+
+func sampleReceipt(kind models.ReceiptKind) *models.Receipt {
+	return &models.Receipt{
+		Kind:          kind,
+		Number:        42,
+		Revision:      1,
+		IssuedAt:      time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+		Timezone:      "UTC",
+		StudioName:    "Lotus Studio",
+		BilledToName:  "Sam",
+		BilledToEmail: "sam@example.com",
+		PaidAt:        time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		Currency:      "EUR",
+		Subtotal:      2100,
+		Tax:           400,
+		TaxLabel:      "VAT 19%",
+		Total:         2500,
+		Lines:         []models.ReceiptLine{{Position: 1, Description: "Spring course", Quantity: 1, UnitAmount: 2100, Amount: 2100}},
+	}
+}
+
+func TestRenderLabelsByKind(t *testing.T) {
+	tests := []struct {
+		kind     models.ReceiptKind
+		want     []string
+		filename string
+	}{
+		{models.ReceiptPayment, []string{"(RECEIPT)", "(Receipt no.)", "(R-000042)", "(Total paid)", "(VAT 19%)", "(4.00 EUR)", "(25.00 EUR)"}, "receipt-R-000042.pdf"},
+		{models.ReceiptCreditNote, []string{"(CREDIT NOTE)", "(Credit note no.)", "(CN-000042)", "(Total refunded)", "(Refunded)", "(VAT 19%)", "(4.00 EUR)"}, "credit-note-CN-000042.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			r := sampleReceipt(tt.kind)
+			pdf := Render(r)
+			for _, want := range tt.want {
+				if !bytes.Contains(pdf, []byte(want)) {
+					t.Errorf("PDF is missing %s", want)
+				}
+			}
+			if got := Filename(r); got != tt.filename {
+				t.Errorf("got filename %s, want %s", got, tt.filename)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutBillingDetails(t *testing.T) {
+	r := sampleReceipt(models.ReceiptPayment)
+	r.BilledToName, r.BilledToEmail = "", ""
+	if bytes.Contains(Render(r), []byte("(Billed to)")) {
+		t.Error("receipt without a purchaser shows an empty Billed to block")
+	}
+}
+
+func TestMessageByKind(t *testing.T) {
+	receipt := message(sampleReceipt(models.ReceiptPayment))
+	if !strings.Contains(receipt.Subject, "receipt R-000042") || !strings.Contains(receipt.Body, "payment of 25.00 EUR") {
+		t.Errorf("unexpected receipt email: %q / %q", receipt.Subject, receipt.Body)
+	}
+
+	creditNote := message(sampleReceipt(models.ReceiptCreditNote))
+	if !strings.Contains(creditNote.Subject, "credit note CN-000042") || !strings.Contains(creditNote.Body, "refunded 25.00 EUR") {
+		t.Errorf("unexpected credit note email: %q / %q", creditNote.Subject, creditNote.Body)
+	}
+	if creditNote.Attachments[0].Filename != "credit-note-CN-000042.pdf" {
+		t.Errorf("got attachment %s", creditNote.Attachments[0].Filename)
+	}
+}
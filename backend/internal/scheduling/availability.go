@@ -0,0 +1,52 @@
+package scheduling
+
+import (
+	"yoga-studio-app/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// InstructorBusy reports whether instructorID already teaches a session that
+// overlaps occurrence. schedules must have their Class loaded; approved
+// substitutions move single occurrences between instructors, so a session
+// handed to a substitute frees its regular instructor and occupies the substitute.
+// Cancelled sessions don't count when schedules have Cancellations loaded.
+func InstructorBusy(instructorID uuid.UUID, occurrence Occurrence, schedules []models.Schedule, substitutions []models.Substitution) bool {
+	type occurrenceKey struct {
+		scheduleID uuid.UUID
+		start      int64
+	}
+	handedOff := make(map[occurrenceKey]bool)
+	for _, sub := range substitutions {
+		if sub.Status != models.SubstitutionApproved || sub.SubstituteID == nil {
+			continue
+		}
+		covered := Occurrence{Start: sub.OccurrenceStart, End: sub.OccurrenceEnd}
+		if *sub.SubstituteID == instructorID && covered.Overlaps(occurrence) {
+			return true
+		}
+		handedOff[occurrenceKey{sub.ScheduleID, sub.OccurrenceStart.Unix()}] = true
+	}
+
+	for _, schedule := range schedules {
+		id := schedule.EffectiveInstructorID()
+		if id == nil || *id != instructorID {
+			continue
+		}
+		cancelled := make(map[int64]bool, len(schedule.Cancellations))
+		for _, cancellation := range schedule.Cancellations {
+			cancelled[cancellation.OccurrenceStart.Unix()] = true
+		}
+		length := schedule.EndTime.Sub(schedule.StartTime)
+		for _, theirs := range Expand(schedule, occurrence.Start.Add(-length), occurrence.End) {
+			if cancelled[theirs.Start.Unix()] {
+				continue
+			}
+			if theirs.Overlaps(occurrence) && !handedOff[occurrenceKey{schedule.ID, theirs.Start.Unix()}] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
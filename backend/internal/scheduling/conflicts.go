@@ -0,0 +1,116 @@
+package scheduling
+
+import (
+	"strings"
+	"time"
+
+	"yoga-studio-app/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Horizon is how far ahead open-ended recurring schedules are checked
+const Horizon = 365 * 24 * time.Hour
+
+type ConflictType string
+
+const (
+	// ConflictInstructor means the same instructor would teach two sessions at once
+	ConflictInstructor ConflictType = "instructor"
+	// ConflictRoom means two classes would use the same room at the same time
+	ConflictRoom ConflictType = "room"
+	// ConflictTimeSlot means two classes without an assigned room would run at the same time
+	ConflictTimeSlot ConflictType = "time_slot"
+)
+
+// Conflict describes one overlapping pair of occurrences
+type Conflict struct {
+	Type          ConflictType `json:"type"`
+	ScheduleID    uuid.UUID    `json:"schedule_id"`
+	ClassID       uuid.UUID    `json:"class_id"`
+	ClassTitle    string       `json:"class_title"`
+	Instructor    string       `json:"instructor"`
+	Occurrence    Occurrence   `json:"occurrence"`
+	ConflictsWith Occurrence   `json:"conflicts_with"`
+}
+
+// Window returns the time range in which a schedule's occurrences must be
+// checked: from its first session until its recurrence ends, capped at Horizon.
+func Window(schedule models.Schedule) (time.Time, time.Time) {
+	from := schedule.StartTime
+	if schedule.RecurrenceType == models.Once || schedule.RecurrenceType == "" {
+		return from, schedule.EndTime
+	}
+	to := from.Add(Horizon)
+	if schedule.RecurrenceEndDate != nil && schedule.RecurrenceEndDate.Before(to) {
+		to = *schedule.RecurrenceEndDate
+	}
+	return from, to
+}
+
+// FindConflicts checks every occurrence of candidate against the occurrences
+// of existing schedules. Every schedule, candidate included, must have its
+// Class loaded, and its Instructor when it overrides the class instructor.
+func FindConflicts(candidate models.Schedule, existing []models.Schedule) []Conflict {
+	from, to := Window(candidate)
+	mine := Expand(candidate, from, to)
+
+	var conflicts []Conflict
+	for _, other := range existing {
+		if other.ID == candidate.ID {
+			continue
+		}
+
+		var conflictType ConflictType
+		switch {
+		case sameInstructor(candidate, other):
+			conflictType = ConflictInstructor
+		case candidate.RoomID != nil && other.RoomID != nil:
+			if *candidate.RoomID != *other.RoomID {
+				// Different rooms can run side by side
+				continue
+			}
+			conflictType = ConflictRoom
+		default:
+			conflictType = ConflictTimeSlot
+		}
+
+		// Widen by one session length on each side so sessions straddling the window edges are caught
+		theirs := Expand(other, from.Add(-other.EndTime.Sub(other.StartTime)), to.Add(candidate.EndTime.Sub(candidate.StartTime)))
+		for _, a := range mine {
+			for _, b := range theirs {
+				if a.Overlaps(b) {
+					conflicts = append(conflicts, Conflict{
+						Type:          conflictType,
+						ScheduleID:    other.ID,
+						ClassID:       other.ClassID,
+						ClassTitle:    other.Class.Title,
+						Instructor:    instructorName(other),
+						Occurrence:    a,
+						ConflictsWith: b,
+					})
+				}
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// sameInstructor compares instructor users when both schedules are linked to
+// one, and falls back to the legacy instructor name otherwise
+func sameInstructor(a, b models.Schedule) bool {
+	idA, idB := a.EffectiveInstructorID(), b.EffectiveInstructorID()
+	if idA != nil && idB != nil {
+		return *idA == *idB
+	}
+	nameA, nameB := strings.TrimSpace(instructorName(a)), strings.TrimSpace(instructorName(b))
+	return nameA != "" && strings.EqualFold(nameA, nameB)
+}
+
+func instructorName(s models.Schedule) string {
+	if s.InstructorID != nil && s.Instructor != nil {
+		return s.Instructor.Name
+	}
+	return s.Class.InstructorName
+}
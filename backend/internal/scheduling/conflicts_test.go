@@ -0,0 +1,84 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"yoga-studio-app/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestFindConflicts(t *testing.T) {
+	monday := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+	anna, ben := uuid.New(), uuid.New()
+	roomA, roomB := uuid.New(), uuid.New()
+
+	session := func(start time.Time, minutes int, instructor uuid.UUID) models.Schedule {
+		return models.Schedule{
+			ID:             uuid.New(),
+			StartTime:      start,
+			EndTime:        start.Add(time.Duration(minutes) * time.Minute),
+			RecurrenceType: models.Once,
+			Class:          models.Class{Title: "Flow", InstructorID: &instructor},
+		}
+	}
+	weekly := func(s models.Schedule, weeks int) models.Schedule {
+		end := s.StartTime.AddDate(0, 0, 7*weeks)
+		s.RecurrenceType = models.Weekly
+		s.RecurrenceEndDate = &end
+		return s
+	}
+
+	legacy := func(s models.Schedule, name string) models.Schedule {
+		s.Class.InstructorID = nil
+		s.Class.InstructorName = name
+		return s
+	}
+
+	inRoom := func(s models.Schedule, room uuid.UUID) models.Schedule {
+		s.RoomID = &room
+		return s
+	}
+
+	candidate := session(monday, 60, anna)
+	tests := []struct {
+		name      string
+		candidate models.Schedule
+		existing  []models.Schedule
+		want      []ConflictType
+	}{
+		{"same instructor", candidate, []models.Schedule{session(monday.Add(30*time.Minute), 60, anna)}, []ConflictType{ConflictInstructor}},
+		{"another instructor", candidate, []models.Schedule{session(monday, 60, ben)}, []ConflictType{ConflictTimeSlot}},
+		{"same legacy instructor name", legacy(candidate, "Anna"), []models.Schedule{legacy(session(monday, 60, ben), " anna ")}, []ConflictType{ConflictInstructor}},
+		{"same instructor in another room", inRoom(candidate, roomA), []models.Schedule{inRoom(session(monday.Add(30*time.Minute), 60, anna), roomB)}, []ConflictType{ConflictInstructor}},
+		{"same room", inRoom(candidate, roomA), []models.Schedule{inRoom(session(monday, 60, ben), roomA)}, []ConflictType{ConflictRoom}},
+		{"different rooms", inRoom(candidate, roomA), []models.Schedule{inRoom(session(monday, 60, ben), roomB)}, nil},
+		{"only one room assigned", inRoom(candidate, roomA), []models.Schedule{session(monday, 60, ben)}, []ConflictType{ConflictTimeSlot}},
+		{"weekly against a later one-off in the same room", inRoom(weekly(candidate, 4), roomA), []models.Schedule{inRoom(session(monday.AddDate(0, 0, 14), 60, ben), roomA)}, []ConflictType{ConflictRoom}},
+		{"back to back", candidate, []models.Schedule{session(monday.Add(time.Hour), 60, anna)}, nil},
+		{"itself", candidate, []models.Schedule{candidate}, nil},
+		{"weekly against a later one-off", weekly(candidate, 4), []models.Schedule{session(monday.AddDate(0, 0, 14), 60, ben)}, []ConflictType{ConflictTimeSlot}},
+		{"one-off starting before a weekly session", session(monday.AddDate(0, 0, 6).Add(23*time.Hour+30*time.Minute), 60, ben), []models.Schedule{weekly(candidate, 4)}, []ConflictType{ConflictTimeSlot}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := FindConflicts(tt.candidate, tt.existing)
+			if len(conflicts) != len(tt.want) {
+				t.Fatalf("got %d conflicts %+v, want %v", len(conflicts), conflicts, tt.want)
+			}
+			for i, want := range tt.want {
+				if conflicts[i].Type != want {
+					t.Errorf("conflict %d: got %s, want %s", i, conflicts[i].Type, want)
+				}
+				if conflicts[i].ScheduleID != tt.existing[0].ID {
+					t.Errorf("conflict %d: got schedule %s, want %s", i, conflicts[i].ScheduleID, tt.existing[0].ID)
+				}
+				if !conflicts[i].Occurrence.Overlaps(conflicts[i].ConflictsWith) {
+					t.Errorf("conflict %d: %+v doesn't overlap %+v", i, conflicts[i].Occurrence, conflicts[i].ConflictsWith)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package scheduling
+
+import (
+	"time"
+
+	"yoga-studio-app/internal/models"
+)
+
+// maxOccurrences caps expansion so an open-ended daily schedule can't run away
+const maxOccurrences = 1000
+
+// Occurrence is a single concrete session generated from a Schedule
+type Occurrence struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Overlaps reports whether two occurrences share any time. Back-to-back
+// sessions (one ends exactly when the next starts) do not overlap.
+func (o Occurrence) Overlaps(other Occurrence) bool {
+	return o.Start.Before(other.End) && other.Start.Before(o.End)
+}
+
+// Expand returns the occurrences of schedule that start within [from, to].
+// The schedule's StartTime/EndTime define the first session and its length;
+// recurrence stops after RecurrenceEndDate when set. Recurrences step in the
+// schedule's time zone, so a 9:00 class stays at 9:00 local time across
+// daylight saving changes, and DayOfWeek/DayOfMonth are local days.
+func Expand(schedule models.Schedule, from, to time.Time) []Occurrence {
+	length := schedule.EndTime.Sub(schedule.StartTime)
+	last := to
+	if schedule.RecurrenceEndDate != nil && schedule.RecurrenceEndDate.Before(last) {
+		last = *schedule.RecurrenceEndDate
+	}
+
+	var occurrences []Occurrence
+	emit := func(start time.Time) bool {
+		if start.After(last) || len(occurrences) >= maxOccurrences {
+			return false
+		}
+		if !start.Before(from) {
+			occurrences = append(occurrences, Occurrence{Start: start, End: start.Add(length)})
+		}
+		return true
+	}
+
+	first := schedule.StartTime.In(schedule.Zone())
+	switch schedule.RecurrenceType {
+	case models.Daily:
+		// Each session is counted from the first rather than the previous
+		// one, so a time skipped by a DST change doesn't shift later sessions
+		for n := 0; emit(first.AddDate(0, 0, n)); n++ {
+		}
+	case models.Weekly:
+		offset := 0
+		if schedule.DayOfWeek != nil {
+			offset = (*schedule.DayOfWeek - int(first.Weekday()) + 7) % 7
+		}
+		for n := 0; emit(first.AddDate(0, 0, offset+7*n)); n++ {
+		}
+	case models.Monthly:
+		day := first.Day()
+		if schedule.DayOfMonth != nil {
+			day = *schedule.DayOfMonth
+		}
+		if day < 1 || day > 31 {
+			// No month has this day, so the schedule never runs
+			break
+		}
+		for month := 0; ; month++ {
+			start, ok := dayInMonth(first, month, day)
+			// A day a month lacks spills into the start of the next month,
+			// still before that month's session, so this stops in order
+			if start.After(last) {
+				break
+			}
+			if !ok || start.Before(first) {
+				// Months without this day (e.g. the 31st) are skipped
+				continue
+			}
+			if !emit(start) {
+				break
+			}
+		}
+	default:
+		emit(first)
+	}
+
+	return occurrences
+}
+
+// OccurrenceAt returns the occurrence of schedule that starts exactly at start.
+// ok is false if the schedule has no session then.
+func OccurrenceAt(schedule models.Schedule, start time.Time) (Occurrence, bool) {
+	for _, occurrence := range Expand(schedule, start, start) {
+		if occurrence.Start.Equal(start) {
+			return occurrence, true
+		}
+	}
+	return Occurrence{}, false
+}
+
+// dayInMonth returns the given day of the month that is monthOffset months
+// after base, at base's time of day. ok is false if that month is too short.
+func dayInMonth(base time.Time, monthOffset, day int) (time.Time, bool) {
+	year, month, _ := base.Date()
+	firstOfMonth := time.Date(year, month+time.Month(monthOffset), 1, base.Hour(), base.Minute(), base.Second(), 0, base.Location())
+	t := firstOfMonth.AddDate(0, 0, day-1)
+	return t, t.Month() == firstOfMonth.Month()
+}
@@ -0,0 +1,282 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"yoga-studio-app/internal/models"
+)
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return zone
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// localClock formats occurrence starts as local wall-clock times with offsets
+func localClock(occurrences []Occurrence, zone *time.Location) []string {
+	var out []string
+	for _, o := range occurrences {
+		out = append(out, o.Start.In(zone).Format(time.RFC3339))
+	}
+	return out
+}
+
+func assertClock(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("occurrence %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExpandKeepsWallClockAcrossDST(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		schedule func(zone *time.Location) models.Schedule
+		from, to string
+		want     []string
+	}{
+		{
+			name:     "weekly across spring forward",
+			timezone: "America/New_York",
+			schedule: func(zone *time.Location) models.Schedule {
+				start := time.Date(2026, 3, 1, 9, 0, 0, 0, zone) // Sunday
+				return models.Schedule{StartTime: start, EndTime: start.Add(time.Hour), RecurrenceType: models.Weekly, DayOfWeek: intPtr(0)}
+			},
+			from: "2026-03-01T00:00:00Z",
+			to:   "2026-03-16T00:00:00Z",
+			want: []string{"2026-03-01T09:00:00-05:00", "2026-03-08T09:00:00-04:00", "2026-03-15T09:00:00-04:00"},
+		},
+		{
+			name:     "weekly across fall back",
+			timezone: "America/New_York",
+			schedule: func(zone *time.Location) models.Schedule {
+				start := time.Date(2026, 10, 25, 18, 30, 0, 0, zone) // Sunday
+				return models.Schedule{StartTime: start, EndTime: start.Add(time.Hour), RecurrenceType: models.Weekly}
+			},
+			from: "2026-10-25T00:00:00Z",
+			to:   "2026-11-09T00:00:00Z",
+			want: []string{"2026-10-25T18:30:00-04:00", "2026-11-01T18:30:00-05:00", "2026-11-08T18:30:00-05:00"},
+		},
+		{
+			name:     "daily across spring forward",
+			timezone: "Europe/London",
+			schedule: func(zone *time.Location) models.Schedule {
+				start := time.Date(2026, 3, 28, 7, 0, 0, 0, zone)
+				return models.Schedule{StartTime: start, EndTime: start.Add(45 * time.Minute), RecurrenceType: models.Daily}
+			},
+			from: "2026-03-28T00:00:00Z",
+			to:   "2026-03-30T23:00:00Z",
+			want: []string{"2026-03-28T07:00:00Z", "2026-03-29T07:00:00+01:00", "2026-03-30T07:00:00+01:00"},
+		},
+		{
+			name:     "daily across fall back",
+			timezone: "Europe/London",
+			schedule: func(zone *time.Location) models.Schedule {
+				start := time.Date(2026, 10, 24, 7, 0, 0, 0, zone)
+				return models.Schedule{StartTime: start, EndTime: start.Add(45 * time.Minute), RecurrenceType: models.Daily}
+			},
+			from: "2026-10-24T00:00:00Z",
+			to:   "2026-10-26T23:00:00Z",
+			want: []string{"2026-10-24T07:00:00+01:00", "2026-10-25T07:00:00Z", "2026-10-26T07:00:00Z"},
+		},
+		{
+			name:     "monthly across both transitions",
+			timezone: "America/New_York",
+			schedule: func(zone *time.Location) models.Schedule {
+				start := time.Date(2026, 2, 15, 10, 0, 0, 0, zone)
+				end := time.Date(2026, 12, 1, 0, 0, 0, 0, zone)
+				return models.Schedule{StartTime: start, EndTime: start.Add(time.Hour), RecurrenceType: models.Monthly, RecurrenceEndDate: &end}
+			},
+			from: "2026-02-01T00:00:00Z",
+			to:   "2027-01-01T00:00:00Z",
+			want: []string{
+				"2026-02-15T10:00:00-05:00", "2026-03-15T10:00:00-04:00", "2026-04-15T10:00:00-04:00",
+				"2026-05-15T10:00:00-04:00", "2026-06-15T10:00:00-04:00", "2026-07-15T10:00:00-04:00",
+				"2026-08-15T10:00:00-04:00", "2026-09-15T10:00:00-04:00", "2026-10-15T10:00:00-04:00",
+				"2026-11-15T10:00:00-05:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := mustZone(t, tt.timezone)
+			schedule := tt.schedule(zone)
+			schedule.Timezone = tt.timezone
+			// Schedules come back from the database in UTC; expansion must not depend on that
+			schedule.StartTime = schedule.StartTime.UTC()
+			schedule.EndTime = schedule.EndTime.UTC()
+
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			to, _ := time.Parse(time.RFC3339, tt.to)
+			assertClock(t, localClock(Expand(schedule, from, to), zone), tt.want)
+		})
+	}
+}
+
+func TestExpandSessionInSkippedHourDoesNotDrift(t *testing.T) {
+	zone := mustZone(t, "America/New_York")
+	// 02:30 doesn't exist on 2026-03-08; the sessions either side must stay at 02:30
+	start := time.Date(2026, 3, 6, 2, 30, 0, 0, zone)
+	schedule := models.Schedule{
+		StartTime:      start,
+		EndTime:        start.Add(time.Hour),
+		RecurrenceType: models.Daily,
+		Timezone:       "America/New_York",
+	}
+
+	occurrences := Expand(schedule, start, start.AddDate(0, 0, 4))
+	if len(occurrences) != 5 {
+		t.Fatalf("got %d occurrences, want 5", len(occurrences))
+	}
+	for _, i := range []int{0, 1, 3, 4} {
+		local := occurrences[i].Start.In(zone)
+		if local.Hour() != 2 || local.Minute() != 30 {
+			t.Errorf("occurrence %d at %s, want 02:30 local", i, local.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestExpandWeeklyUsesLocalDayOfWeek(t *testing.T) {
+	zone := mustZone(t, "Australia/Sydney")
+	// Monday 08:00 in Sydney is still Sunday in UTC
+	start := time.Date(2026, 4, 6, 8, 0, 0, 0, zone)
+	schedule := models.Schedule{
+		StartTime:      start.UTC(),
+		EndTime:        start.Add(time.Hour).UTC(),
+		RecurrenceType: models.Weekly,
+		DayOfWeek:      intPtr(int(time.Monday)),
+		Timezone:       "Australia/Sydney",
+	}
+
+	// Sydney leaves daylight saving on 2026-04-05, so the offset is +10:00 throughout
+	got := localClock(Expand(schedule, start, start.AddDate(0, 0, 14)), zone)
+	assertClock(t, got, []string{"2026-04-06T08:00:00+10:00", "2026-04-13T08:00:00+10:00", "2026-04-20T08:00:00+10:00"})
+}
+
+func TestOccurrenceAtAfterDSTChange(t *testing.T) {
+	zone := mustZone(t, "Europe/London")
+	start := time.Date(2026, 3, 22, 18, 0, 0, 0, zone)
+	schedule := models.Schedule{
+		StartTime:      start.UTC(),
+		EndTime:        start.Add(time.Hour).UTC(),
+		RecurrenceType: models.Weekly,
+		Timezone:       "Europe/London",
+	}
+
+	// After the clocks go forward the 18:00 class is at 17:00 UTC, not 18:00 UTC
+	if _, ok := OccurrenceAt(schedule, time.Date(2026, 3, 29, 17, 0, 0, 0, time.UTC)); !ok {
+		t.Error("expected a session at 18:00 BST")
+	}
+	if _, ok := OccurrenceAt(schedule, time.Date(2026, 3, 29, 18, 0, 0, 0, time.UTC)); ok {
+		t.Error("unexpected session at 19:00 BST")
+	}
+}
+
+func TestExpandMonthly(t *testing.T) {
+	start := time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start      time.Time
+		dayOfMonth *int
+		end        *time.Time
+		from, to   string
+		want       []string
+	}{
+		{
+			name:  "same day as the first session",
+			start: start,
+			from:  "2026-01-01T00:00:00Z",
+			to:    "2026-04-01T00:00:00Z",
+			want:  []string{"2026-01-15T09:00:00Z", "2026-02-15T09:00:00Z", "2026-03-15T09:00:00Z"},
+		},
+		{
+			name:       "31st skips short months",
+			start:      time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC),
+			dayOfMonth: intPtr(31),
+			from:       "2026-01-01T00:00:00Z",
+			to:         "2026-07-01T00:00:00Z",
+			want:       []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			name:       "29th skips February outside leap years",
+			start:      time.Date(2027, 1, 29, 9, 0, 0, 0, time.UTC),
+			dayOfMonth: intPtr(29),
+			from:       "2027-01-01T00:00:00Z",
+			to:         "2028-03-01T00:00:00Z",
+			want: []string{
+				"2027-01-29T09:00:00Z", "2027-03-29T09:00:00Z", "2027-04-29T09:00:00Z", "2027-05-29T09:00:00Z",
+				"2027-06-29T09:00:00Z", "2027-07-29T09:00:00Z", "2027-08-29T09:00:00Z", "2027-09-29T09:00:00Z",
+				"2027-10-29T09:00:00Z", "2027-11-29T09:00:00Z", "2027-12-29T09:00:00Z", "2028-01-29T09:00:00Z",
+				"2028-02-29T09:00:00Z",
+			},
+		},
+		{
+			name:       "day before the first session starts next month",
+			start:      start,
+			dayOfMonth: intPtr(10),
+			from:       "2026-01-01T00:00:00Z",
+			to:         "2026-03-31T00:00:00Z",
+			want:       []string{"2026-02-10T09:00:00Z", "2026-03-10T09:00:00Z"},
+		},
+		{
+			name:  "window after the first session",
+			start: start,
+			from:  "2026-03-01T00:00:00Z",
+			to:    "2026-05-01T00:00:00Z",
+			want:  []string{"2026-03-15T09:00:00Z", "2026-04-15T09:00:00Z"},
+		},
+		{
+			name:  "stops at the recurrence end",
+			start: start,
+			end:   func() *time.Time { end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); return &end }(),
+			from:  "2026-01-01T00:00:00Z",
+			to:    "2026-12-31T00:00:00Z",
+			want:  []string{"2026-01-15T09:00:00Z", "2026-02-15T09:00:00Z"},
+		},
+		{
+			name:       "day zero never occurs",
+			start:      start,
+			dayOfMonth: intPtr(0),
+			from:       "2026-01-01T00:00:00Z",
+			to:         "2026-12-31T00:00:00Z",
+		},
+		{
+			name:       "day 32 never occurs",
+			start:      start,
+			dayOfMonth: intPtr(32),
+			from:       "2026-01-01T00:00:00Z",
+			to:         "2026-12-31T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := models.Schedule{
+				StartTime:         tt.start,
+				EndTime:           tt.start.Add(time.Hour),
+				RecurrenceType:    models.Monthly,
+				DayOfMonth:        tt.dayOfMonth,
+				RecurrenceEndDate: tt.end,
+				Timezone:          "UTC",
+			}
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			to, _ := time.Parse(time.RFC3339, tt.to)
+			assertClock(t, localClock(Expand(schedule, from, to), time.UTC), tt.want)
+		})
+	}
+}
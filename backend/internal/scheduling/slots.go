@@ -0,0 +1,124 @@
+package scheduling
+
+import (
+	"sort"
+	"time"
+
+	"yoga-studio-app/internal/models"
+)
+
+// maxSlots caps the open slots returned for one query
+const maxSlots = 500
+
+// AvailablePeriods returns the periods overlapping [from, to) in which an
+// instructor takes private sessions: their weekly windows as local times in
+// zone, plus exceptions that add time, minus exceptions that block it.
+// Periods are sorted and don't overlap; callers pass from on to OpenSlots.
+func AvailablePeriods(windows []models.AvailabilityWindow, exceptions []models.AvailabilityException, zone *time.Location, from, to time.Time) []Occurrence {
+	var periods []Occurrence
+	local := from.In(zone)
+	for day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, zone); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for i := range windows {
+			if !windows[i].AppliesOn(day) {
+				continue
+			}
+			opens, closes := windows[i].Minutes()
+			// time.Date normalizes the minutes, keeping wall-clock time across DST changes
+			periods = append(periods, Occurrence{
+				Start: time.Date(day.Year(), day.Month(), day.Day(), 0, opens, 0, 0, zone),
+				End:   time.Date(day.Year(), day.Month(), day.Day(), 0, closes, 0, 0, zone),
+			})
+		}
+	}
+	for _, e := range exceptions {
+		if e.Available {
+			periods = append(periods, Occurrence{Start: e.StartsAt.In(zone), End: e.EndsAt.In(zone)})
+		}
+	}
+
+	periods = merge(clip(periods, from, to))
+	for _, e := range exceptions {
+		if !e.Available {
+			periods = subtract(periods, Occurrence{Start: e.StartsAt.In(zone), End: e.EndsAt.In(zone)})
+		}
+	}
+	return periods
+}
+
+// OpenSlots returns sessions of length duration that fit inside periods,
+// starting at each period's start and every step after it. Slots starting
+// before earliest, or for which busy returns true, are left out.
+func OpenSlots(periods []Occurrence, duration, step time.Duration, earliest time.Time, busy func(Occurrence) bool) []Occurrence {
+	slots := []Occurrence{}
+	for _, period := range periods {
+		start := period.Start
+		if start.Before(earliest) {
+			start = start.Add(earliest.Sub(start).Truncate(step))
+			if start.Before(earliest) {
+				start = start.Add(step)
+			}
+		}
+		for ; !start.Add(duration).After(period.End); start = start.Add(step) {
+			slot := Occurrence{Start: start, End: start.Add(duration)}
+			if busy(slot) {
+				continue
+			}
+			slots = append(slots, slot)
+			if len(slots) >= maxSlots {
+				return slots
+			}
+		}
+	}
+	return slots
+}
+
+// clip drops periods outside [from, to) and trims those running past to.
+// Starts are kept so slots stay aligned to the start of each window.
+func clip(periods []Occurrence, from, to time.Time) []Occurrence {
+	var clipped []Occurrence
+	for _, p := range periods {
+		if p.End.After(to) {
+			p.End = to
+		}
+		if p.End.After(from) && p.Start.Before(p.End) {
+			clipped = append(clipped, p)
+		}
+	}
+	return clipped
+}
+
+// merge sorts periods and joins those that overlap or touch
+func merge(periods []Occurrence) []Occurrence {
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+	var merged []Occurrence
+	for _, p := range periods {
+		if n := len(merged); n > 0 && !p.Start.After(merged[n-1].End) {
+			if p.End.After(merged[n-1].End) {
+				merged[n-1].End = p.End
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// subtract removes the time in blocked from sorted, non-overlapping periods
+func subtract(periods []Occurrence, blocked Occurrence) []Occurrence {
+	var remaining []Occurrence
+	for _, p := range periods {
+		if !p.Overlaps(blocked) {
+			remaining = append(remaining, p)
+			continue
+		}
+		if p.Start.Before(blocked.Start) {
+			remaining = append(remaining, Occurrence{Start: p.Start, End: blocked.Start})
+		}
+		if blocked.End.Before(p.End) {
+			remaining = append(remaining, Occurrence{Start: blocked.End, End: p.End})
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"yoga-studio-app/internal/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Init configures W3C trace-context propagation and, when tracing is enabled,
+// an OTLP/HTTP exporter. The exporter endpoint is taken from the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT variable (defaults to http://localhost:4318).
+// The returned function flushes pending spans and must be called on shutdown.
+func Init(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	// Always propagate incoming trace context, even when we don't export
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		log.Println("Tracing disabled (set OTEL_ENABLED=true to export spans)")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	log.Printf("Tracing enabled, exporting spans for service %s", cfg.ServiceName)
+	return provider.Shutdown, nil
+}
+
+// HTTPClient returns a client whose outbound requests are traced and carry
+// the W3C traceparent header. Use it for calls to third-party services.
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+}
@@ -0,0 +1,178 @@
+// Package tenancy scopes database access to the studio serving a request.
+//
+// The studio is carried in the request context. A GORM plugin reads it on
+// every statement: queries, updates and deletes on models embedding
+// models.StudioScoped are filtered to that studio, and creates are stamped
+// with it. Statements on those models fail outright when the context names
+// no studio, so a handler that forgets to bind the request context can't
+// fall back to reading every studio's rows. Migrations and super-admin
+// tools opt out explicitly with AllStudios.
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"yoga-studio-app/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// studioField is the field models.StudioScoped adds to a model
+const studioField = "StudioID"
+
+// ErrNoStudio is returned for statements on studio-scoped models whose
+// context neither names a studio nor opts out with AllStudios
+var ErrNoStudio = errors.New("tenancy: no studio in context")
+
+type contextKey struct{}
+
+// scope is stored in the context; an unrestricted scope has no studio
+type scope struct {
+	studioID     uuid.UUID
+	unrestricted bool
+}
+
+// WithStudio returns a context scoped to the given studio
+func WithStudio(ctx context.Context, studioID uuid.UUID) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{studioID: studioID})
+}
+
+// AllStudios returns a context whose statements see every studio's rows.
+// Only migrations and super-admin tooling should use it.
+func AllStudios(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{unrestricted: true})
+}
+
+// StudioFrom returns the studio a context is scoped to
+func StudioFrom(ctx context.Context) (uuid.UUID, bool) {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok || s.unrestricted {
+		return uuid.Nil, false
+	}
+	return s.studioID, true
+}
+
+// studioFor returns the studio a statement must be limited to. ok is false
+// when the statement may run unrestricted; a missing scope is an error.
+func studioFor(db *gorm.DB) (studioID uuid.UUID, ok bool) {
+	s, found := db.Statement.Context.Value(contextKey{}).(scope)
+	if !found {
+		db.AddError(ErrNoStudio)
+		return uuid.Nil, false
+	}
+	return s.studioID, !s.unrestricted
+}
+
+// Find looks up an active studio by slug, or failing that by hostname.
+// Empty arguments are skipped.
+func Find(db *gorm.DB, slug, host string) (*models.Studio, error) {
+	var studio models.Studio
+	query := db.Where("is_active = ?", true)
+	switch {
+	case slug != "":
+		query = query.Where("slug = ?", slug)
+	case host != "":
+		query = query.Where("? = ANY(hostnames)", normalizeHost(host))
+	default:
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := query.First(&studio).Error; err != nil {
+		return nil, err
+	}
+	return &studio, nil
+}
+
+// normalizeHost strips any port and lowercases a request host
+func normalizeHost(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return strings.ToLower(host)
+}
+
+// Plugin enforces studio scoping on every statement
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "tenancy"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenancy:query", scopeStatement); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenancy:row", scopeStatement); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenancy:update", scopeStatement); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tenancy:delete", scopeStatement); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register("tenancy:create", stampStudio)
+}
+
+// scopedField returns the StudioID field of the statement's model, or nil
+// for models that don't belong to a studio and raw SQL
+func scopedField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(studioField)
+}
+
+// scopeStatement restricts statements on studio-scoped models to the context's studio
+func scopeStatement(db *gorm.DB) {
+	field := scopedField(db)
+	if field == nil {
+		return
+	}
+	studioID, ok := studioFor(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: studioID},
+	}})
+}
+
+// stampStudio assigns new studio-scoped rows to the context's studio,
+// refusing rows explicitly assigned to a different one
+func stampStudio(db *gorm.DB) {
+	field := scopedField(db)
+	if field == nil {
+		return
+	}
+	studioID, ok := studioFor(db)
+	if !ok {
+		return
+	}
+
+	stamp := func(rv reflect.Value) {
+		current, isZero := field.ValueOf(db.Statement.Context, rv)
+		if !isZero {
+			if id, ok := current.(uuid.UUID); ok && id != studioID {
+				db.AddError(gorm.ErrInvalidData)
+			}
+			return
+		}
+		db.AddError(field.Set(db.Statement.Context, rv, studioID))
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			stamp(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+}
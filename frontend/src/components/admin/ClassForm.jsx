@@ -6,6 +6,7 @@ const ClassForm = ({ initialData = null, onSubmit, onCancel }) => {
   const [formData, setFormData] = useState({
     title: initialData?.title || '',
     description: initialData?.description || '',
+    instructor_id: initialData?.instructor_id || '',
     instructor_name: initialData?.instructor_name || '',
     duration: initialData?.duration || 60,
     capacity: initialData?.capacity || 15,
@@ -43,7 +44,9 @@ const ClassForm = ({ initialData = null, onSubmit, onCancel }) => {
     setError('');
 
     try {
-      await onSubmit(formData);
+      // The API derives instructor_name from instructor_id; only send the id when one is selected
+      const { instructor_id, ...rest } = formData;
+      await onSubmit(instructor_id ? { ...rest, instructor_id } : rest);
     } catch (err) {
       setError(err.message || 'Failed to save class');
     } finally {
@@ -108,15 +111,15 @@ const ClassForm = ({ initialData = null, onSubmit, onCancel }) => {
           </label>
           {instructors.length > 0 ? (
             <select
-              name="instructor_name"
-              value={formData.instructor_name}
+              name="instructor_id"
+              value={formData.instructor_id}
               onChange={handleChange}
               required
               className="w-full px-4 py-3 border-2 border-neutral-200 rounded-lg focus:outline-none focus:ring-2 focus:ring-neutral-900 focus:border-transparent text-base"
             >
               <option value="">Select an instructor...</option>
               {instructors.map(instructor => (
-                <option key={instructor.id} value={instructor.name}>
+                <option key={instructor.id} value={instructor.id}>
                   {instructor.name}
                   {instructor.years_experience > 0 && ` (${instructor.years_experience} yrs exp)`}
                 </option>
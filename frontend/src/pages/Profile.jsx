@@ -2,7 +2,7 @@ import { useState, useEffect } from 'react';
 import { motion } from 'framer-motion';
 import { FaCamera } from 'react-icons/fa';
 import useAuthStore from '../store/authStore';
-import { userAPI, enrollmentAPI } from '../services/api';
+import { userAPI, enrollmentAPI, authAPI } from '../services/api';
 import { format, parseISO } from 'date-fns';
 
 const Profile = () => {
@@ -79,7 +79,7 @@ const Profile = () => {
         fetchEnrollments();
       } catch (err) {
         const errorMessage = err.response?.data?.error || 'Failed to cancel enrollment';
-        const minutesUntil = err.response?.data?.minutes_until_class;
+        const minutesUntil = err.response?.data?.details?.minutes_until_class;
         
         if (minutesUntil !== undefined) {
           alert(`${errorMessage}\n\nClass starts in ${minutesUntil} minutes. Cancellations must be made at least 1 hour before class.`);
@@ -118,7 +118,7 @@ const Profile = () => {
           <h2 className="text-4xl font-heading text-neutral-900 mb-6">Sign In Required</h2>
           <p className="text-lg text-neutral-600 mb-8">Please sign in to view your profile.</p>
           <button 
-            onClick={() => window.location.href = `${import.meta.env.VITE_API_URL || 'http://localhost:8080'}/api/v1/auth/google`} 
+            onClick={() => authAPI.googleLogin()} 
             className="btn-primary"
           >
             Sign In
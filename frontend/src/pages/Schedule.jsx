@@ -1,18 +1,22 @@
 import { useState, useEffect } from 'react';
 import { motion } from 'framer-motion';
-import { schedulesAPI, enrollmentAPI } from '../services/api';
+import { schedulesAPI, enrollmentAPI, closuresAPI } from '../services/api';
 import useAuthStore from '../store/authStore';
 import { format, parseISO, startOfDay, endOfDay, startOfWeek, endOfWeek, startOfMonth, endOfMonth, isWithinInterval } from 'date-fns';
 
 const Schedule = () => {
   const [view, setView] = useState('weekly');
   const [schedules, setSchedules] = useState([]);
+  const [closures, setClosures] = useState([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const { isAuthenticated } = useAuthStore();
 
   useEffect(() => {
     fetchSchedules();
+    closuresAPI.getUpcoming()
+      .then((response) => setClosures(response.data || []))
+      .catch((err) => console.error('Error fetching closures:', err));
   }, []);
 
   const fetchSchedules = async () => {
@@ -158,6 +162,20 @@ const Schedule = () => {
         </div>
       </section>
 
+      {/* Upcoming studio closures */}
+      {closures.length > 0 && (
+        <section className="py-4 bg-yellow-50 border-b border-yellow-200">
+          <div className="max-w-7xl mx-auto px-6 lg:px-12 text-sm text-yellow-800 space-y-1">
+            {closures.map((closure) => (
+              <p key={closure.id}>
+                <strong>{closure.title}:</strong> studio closed{' '}
+                {format(parseISO(closure.starts_at), 'EEE d MMM HH:mm')} – {format(parseISO(closure.ends_at), 'EEE d MMM HH:mm')}
+              </p>
+            ))}
+          </div>
+        </section>
+      )}
+
       {/* View Toggle */}
       <section className="py-6 border-b border-neutral-200 bg-neutral-50">
         <div className="max-w-7xl mx-auto px-6 lg:px-12">
@@ -202,7 +220,10 @@ const Schedule = () => {
                        // Safety checks for nested objects
                        const classData = scheduleItem?.class || scheduleItem?.Class || {};
                        const enrollments = scheduleItem?.enrollments || scheduleItem?.Enrollments || [];
-                       const spotsAvailable = (classData.capacity || 0) - enrollments.length;
+                       // A room smaller than the class caps the places available
+                       const room = scheduleItem?.room;
+                       const capacity = room ? Math.min(room.capacity, classData.capacity || 0) : (classData.capacity || 0);
+                       const spotsAvailable = capacity - enrollments.length;
 
                        // Check if class has already started or passed
                        let hasStarted = false;
@@ -215,7 +236,13 @@ const Schedule = () => {
                          console.error('Error checking class start time:', err);
                        }
 
-                       const canEnroll = spotsAvailable > 0 && !hasStarted;
+                       // The studio may have called off this session
+                       const startMs = new Date(scheduleItem.start_time).getTime();
+                       const isCancelled = (scheduleItem.cancellations || []).some(
+                         (cancellation) => new Date(cancellation.occurrence_start).getTime() === startMs
+                       );
+
+                       const canEnroll = spotsAvailable > 0 && !hasStarted && !isCancelled;
 
                        return (
                          <motion.div
@@ -254,13 +281,21 @@ const Schedule = () => {
                                  </span>
                                  <span>•</span>
                                  <span>{classData.duration || 60} min</span>
+                                 {room && (
+                                   <>
+                                     <span>•</span>
+                                     <span>{room.location ? `${room.location.name} · ${room.name}` : room.name}</span>
+                                   </>
+                                 )}
                                </div>
                              </div>
 
                              {/* Enrollment */}
                              <div className="lg:col-span-3 flex flex-col justify-between items-end">
                                <div className="text-right mb-4">
-                                 {hasStarted ? (
+                                 {isCancelled ? (
+                                   <p className="text-sm text-red-600 font-medium">Cancelled by studio</p>
+                                 ) : hasStarted ? (
                                    <p className="text-sm text-neutral-500 font-medium">Class Started</p>
                                  ) : spotsAvailable > 0 ? (
                                    <p className="text-sm text-neutral-600">
@@ -279,7 +314,7 @@ const Schedule = () => {
                                      : 'btn-outline'
                                  }`}
                                >
-                                 {hasStarted ? 'Started' : spotsAvailable <= 0 ? 'Full' : 'Enroll'}
+                                 {isCancelled ? 'Cancelled' : hasStarted ? 'Started' : spotsAvailable <= 0 ? 'Full' : 'Enroll'}
                                </button>
                              </div>
                            </div>
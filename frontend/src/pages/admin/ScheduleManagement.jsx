@@ -62,7 +62,22 @@ const ScheduleManagement = () => {
 
       console.log('Creating schedule with payload:', payload);
       
-      await adminAPI.createSchedule(payload);
+      try {
+        await adminAPI.createSchedule(payload);
+      } catch (err) {
+        // Overlaps come back as 409 with the conflicting occurrences; let the admin override
+        const data = err.response?.data;
+        if (err.response?.status !== 409 || data?.code !== 'conflict') throw err;
+
+        const conflicts = data.details?.conflicts || [];
+        const summary = conflicts.slice(0, 5).map((c) =>
+          `• ${c.class_title} (${c.instructor}) on ${new Date(c.conflicts_with.start).toLocaleString()}` +
+          (c.type === 'instructor' ? ' - same instructor' : '')
+        ).join('\n');
+        if (!window.confirm(`${data.error}\n\n${summary}\n\nCreate the schedule anyway?`)) return;
+
+        await adminAPI.createSchedule({ ...payload, override_conflicts: true });
+      }
       
       alert('Schedule created successfully!');
       setShowForm(false);
@@ -126,13 +141,25 @@ const ScheduleManagement = () => {
     }
   };
 
-  const handleDelete = async (scheduleId) => {
+  const handleDelete = async (schedule) => {
     if (!confirm('Delete this schedule?')) return;
     try {
-      await adminAPI.deleteSchedule(scheduleId);
+      await adminAPI.deleteSchedule(schedule.id);
       fetchData();
     } catch (err) {
-      alert('Failed to delete schedule');
+      // Schedules with bookings can't be deleted; a one-off class can be cancelled instead
+      const data = err.response?.data;
+      if (err.response?.status !== 409 || schedule.recurrence_type !== 'once') {
+        alert(data?.error || 'Failed to delete schedule');
+        return;
+      }
+      if (!window.confirm(`${data.error}\n\nCancel this class and notify the booked students instead?`)) return;
+      try {
+        await adminAPI.cancelOccurrence(schedule.id, { occurrence_start: schedule.start_time });
+        fetchData();
+      } catch (cancelErr) {
+        alert(cancelErr.response?.data?.error || 'Failed to cancel class');
+      }
     }
   };
 
@@ -363,7 +390,7 @@ const ScheduleManagement = () => {
                 </p>
               </div>
               <button
-                onClick={() => handleDelete(schedule.id)}
+                onClick={() => handleDelete(schedule)}
                 className="px-4 py-2 bg-red-50 text-red-600 rounded-lg hover:bg-red-100 transition-colors flex items-center gap-2 font-medium"
               >
                 <FaTrash />
@@ -2,11 +2,16 @@ import axios from 'axios';
 
 const API_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080';
 
+// Studio this frontend serves; unset when the API resolves the studio from its hostname
+const STUDIO = import.meta.env.VITE_STUDIO;
+const studioQuery = STUDIO ? `?studio=${encodeURIComponent(STUDIO)}` : '';
+
 // Create axios instance
 const api = axios.create({
   baseURL: `${API_URL}/api/v1`,
   headers: {
     'Content-Type': 'application/json',
+    ...(STUDIO && { 'X-Studio': STUDIO }),
   },
   timeout: 30000, // 30 second timeout
 });
@@ -88,11 +93,11 @@ export default api;
 // Auth endpoints
 export const authAPI = {
   googleLogin: () => {
-    window.location.href = `${API_URL}/api/v1/auth/google`;
+    window.location.href = `${API_URL}/api/v1/auth/google${studioQuery}`;
   },
   
   facebookLogin: () => {
-    window.location.href = `${API_URL}/api/v1/auth/facebook`;
+    window.location.href = `${API_URL}/api/v1/auth/facebook${studioQuery}`;
   },
   
   logout: () => api.post('/auth/logout'),
@@ -100,30 +105,78 @@ export const authAPI = {
   getProfile: () => api.get('/users/me'),
 };
 
+// Largest page the API serves (see backend internal/api/pagination.go)
+const MAX_PAGE_SIZE = 200;
+
+// List endpoints return { items, total, limit, offset }. Unwrap items into
+// response.data so callers keep working with arrays, and expose the rest
+// as response.pagination.
+const getList = (url, params) =>
+  api.get(url, { params: { limit: MAX_PAGE_SIZE, ...params } }).then((response) => {
+    const { items, ...pagination } = response.data;
+    return { ...response, data: items, pagination };
+  });
+
 // Classes endpoints
 export const classesAPI = {
-  getAll: () => api.get('/classes'),
+  getAll: (params) => getList('/classes', params),
   getById: (id) => api.get(`/classes/${id}`),
 };
 
 // Schedules endpoints
 export const schedulesAPI = {
-  getAll: (params) => api.get('/schedules', { params }),
+  getAll: (params) => getList('/schedules', params),
   getById: (id) => api.get(`/schedules/${id}`),
 };
 
+// Studio closures endpoints
+export const closuresAPI = {
+  getUpcoming: (params) => getList('/closures', params),
+};
+
 // Enrollments endpoints
 export const enrollmentsAPI = {
   create: (data) => api.post('/enrollments', data),
-  getMy: () => api.get('/enrollments/my'),
+  getMy: (params) => getList('/enrollments/my', params),
   cancel: (id) => api.delete(`/enrollments/${id}`),
 };
 
 // Alias for consistency
 export const enrollmentAPI = {
   enroll: (scheduleId) => api.post('/enrollments', { schedule_id: scheduleId }),
-  getMyEnrollments: () => api.get('/enrollments/my'),
+  getMyEnrollments: (params) => getList('/enrollments/my', params),
   cancel: (enrollmentId) => api.delete(`/enrollments/${enrollmentId}`),
+  bookSeries: (scheduleId, until, joinWaitlist = true) =>
+    api.post('/enrollments/series', { schedule_id: scheduleId, until, join_waitlist: joinWaitlist }),
+  getSeries: (seriesId) => api.get(`/enrollments/series/${seriesId}`),
+  cancelSeries: (seriesId) => api.delete(`/enrollments/series/${seriesId}`),
+};
+
+// Course endpoints
+export const courseAPI = {
+  getAll: (params) => getList('/courses', params),
+  getById: (id) => api.get(`/courses/${id}`),
+  getMine: (params) => getList('/courses/my', params),
+  enroll: (id, data) => api.post(`/courses/${id}/enroll`, data), // application, promo_code, gift_card_code
+  withdraw: (id) => api.delete(`/courses/${id}/enroll`),
+  redeemGiftCard: (id, code) => api.post(`/courses/${id}/enroll/gift-card`, { code }),
+};
+
+// Promo code and gift card endpoints
+export const promotionAPI = {
+  checkPromoCode: (code, courseId) => api.get('/promo-codes/check', { params: { code, course_id: courseId } }),
+  getGiftCardBalance: (code) => api.get('/gift-cards/balance', { params: { code } }),
+};
+
+// Private session endpoints
+export const privateSessionAPI = {
+  getAvailability: (instructorId) => api.get('/private-sessions/availability', { params: { instructor_id: instructorId } }),
+  getSlots: (params) => api.get('/private-sessions/slots', { params }),
+  book: (data) => api.post('/private-sessions', data),
+  getMine: () => api.get('/private-sessions/my'),
+  setAvailability: (windows) => api.put('/private-sessions/availability', { windows }),
+  addException: (data) => api.post('/private-sessions/availability/exceptions', data),
+  deleteException: (id) => api.delete(`/private-sessions/availability/exceptions/${id}`),
 };
 
 // Content endpoints
@@ -143,6 +196,8 @@ export const userAPI = {
     });
   },
   updateInstructorBio: (data) => api.put('/users/me/instructor-bio', data),
+  getReceipts: (params) => getList('/users/me/receipts', params),
+  downloadReceipt: (id) => api.get(`/users/me/receipts/${id}/pdf`, { responseType: 'blob' }),
 };
 
 // Instructor endpoints
@@ -158,23 +213,24 @@ export const instructorAPI = {
 // Admin endpoints
 export const adminAPI = {
   // Classes management
-  getAllClasses: () => api.get('/classes'), // Use public endpoint to get all
+  getAllClasses: (params) => getList('/classes', params), // Use public endpoint to get all
   createClass: (data) => api.post('/admin/classes', data),
   updateClass: (id, data) => api.put(`/admin/classes/${id}`, data),
   deleteClass: (id) => api.delete(`/admin/classes/${id}`),
   
   // Schedules management
-  getAllSchedules: (params) => api.get('/schedules', { params }),
+  getAllSchedules: (params) => getList('/schedules', params),
   createSchedule: (data) => api.post('/admin/schedules', data),
   updateSchedule: (id, data) => api.put(`/admin/schedules/${id}`, data),
   deleteSchedule: (id) => api.delete(`/admin/schedules/${id}`),
+  cancelOccurrence: (id, data) => api.post(`/admin/schedules/${id}/cancel-occurrence`, data),
   
   // Content management
   getContent: (page) => api.get(`/content/${page}`),
   updateContent: (data) => api.put('/admin/content', data),
   
   // User management
-  getAllUsers: () => api.get('/admin/users'),
+  getAllUsers: (params) => getList('/admin/users', params),
   updateUserRole: (id, role) => api.put(`/admin/users/${id}/role`, { role }),
   
   // Analytics